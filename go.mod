@@ -3,13 +3,15 @@ module github.com/theochva/goyaml
 go 1.16
 
 require (
-	github.com/BurntSushi/toml v0.3.1
+	github.com/BurntSushi/toml v1.3.2
 	github.com/Masterminds/sprig/v3 v3.2.2
 	github.com/goreleaser/goreleaser v0.159.0
+	github.com/klauspost/compress v1.11.3
 	github.com/onsi/ginkgo v1.15.1
 	github.com/onsi/gomega v1.11.0
 	github.com/pkg/errors v0.9.1
 	github.com/spf13/cobra v1.1.3
 	github.com/theochva/go-misc v0.2.0
+	golang.org/x/sys v0.0.0-20210112080510-489259a85091
 	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776
 )
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/theochva/goyaml/internal/commands/cli"
+)
+
+type _AnchorsCommand struct {
+	cli.AppSubCommand
+
+	globalOpts GlobalOptions
+}
+
+func init() {
+	registerCommand(func(globalOpts GlobalOptions) cli.AppSubCommand {
+		subCmd := &_AnchorsCommand{
+			globalOpts: globalOpts,
+		}
+
+		cliCmd := &cobra.Command{
+			Use:                   "anchors",
+			DisableFlagsInUseLine: true,
+			Annotations: map[string]string{
+				_CmdOptKeepNodes: _CmdOptValueTrue,
+			},
+			Short: "List the anchors of the yaml and the aliases that refer to them",
+			Long: `List the anchors (e.g. '&defaults') of the yaml and the aliases (e.g. '*defaults' or '<<: *defaults')
+that refer to them.  Each anchor is printed on its own line as:
+
+  <anchor>: <key> -> <alias-key>, <alias-key>, ...
+
+Array items are referred to by their index and maps merged with '<<' by '<<', e.g. 'services.0.<<'.`,
+			Args: cobra.NoArgs,
+			RunE: subCmd.run,
+			Example: cli.ReplaceProgName(`  $PROG_NAME -f /tmp/foo.yaml anchors
+
+  cat /tmp/foo.yaml | $PROG_NAME anchors`),
+		}
+
+		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
+		return subCmd
+	})
+}
+
+func (c *_AnchorsCommand) run(cmd *cobra.Command, args []string) (err error) {
+	for _, anchor := range c.globalOpts.YamlFile().Anchors() {
+		if len(anchor.Aliases) == 0 {
+			cmd.Printf("%s: %s\n", anchor.Name, anchor.Path)
+			continue
+		}
+		cmd.Printf("%s: %s -> %s\n", anchor.Name, anchor.Path, strings.Join(anchor.Aliases, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/theochva/go-misc/pkg/osext"
+)
+
+// TestAnchorsCommand - test suite for the anchors and flatten-aliases commands
+func TestAnchorsCommand(t *testing.T) {
+	RegisterFailHandler(Fail)
+}
+
+var _ = Describe("Commands 'anchors' and 'flatten-aliases' scenarios", func() {
+	var anchoredYAML = strings.TrimSpace(`
+defaults: &defaults
+  retries: 3
+  timeout: 10
+service-a:
+  <<: *defaults
+  timeout: 20
+service-b:
+  settings: *defaults
+`)
+	var flattenedYAML = strings.TrimSpace(`
+defaults:
+  retries: 3
+  timeout: 10
+service-a:
+  retries: 3
+  timeout: 20
+service-b:
+  settings:
+    retries: 3
+    timeout: 10
+`)
+
+	It("prints out help for the 'anchors' command", func() {
+		out, err := runCommand("", "anchors", "--help")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(getHelpTextForCommand("anchors")))
+	})
+	It("lists the anchors and the aliases that refer to them", func() {
+		// cat file.yaml | goyaml anchors
+		out, err := runCommand(anchoredYAML, "anchors")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("defaults: defaults -> service-a.<<, service-b.settings"))
+	})
+	It("flattens the aliases", func() {
+		// cat file.yaml | goyaml flatten-aliases
+		out, err := runCommand(anchoredYAML, "flatten-aliases")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(flattenedYAML))
+	})
+	It("keeps the anchors when setting a value with '--keep-anchors'", func() {
+		// cat file.yaml | goyaml --keep-anchors set key value
+		out, err := runCommand(anchoredYAML, "--keep-anchors", "set", "service-b.settings.retries", "5", "-t", "int")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(strings.Replace(anchoredYAML, "retries: 3", "retries: 5", 1)))
+	})
+	It("prints an error message when deleting an anchored value used by aliases", func() {
+		// cat file.yaml | goyaml --keep-anchors delete key
+		out, err := runCommand(anchoredYAML, "--keep-anchors", "delete", "defaults")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error:"))
+	})
+	When("The yaml is in a file", func() {
+		var workDir, workFile string
+
+		BeforeEach(func() {
+			var err error
+
+			workDir, err = os.MkdirTemp("", "anchors*")
+			Expect(err).ToNot(HaveOccurred())
+			workFile = filepath.Join(workDir, "test.yaml")
+			Expect(os.WriteFile(workFile, []byte(anchoredYAML), 0644)).To(Succeed())
+		})
+		AfterEach(func() {
+			os.RemoveAll(workDir)
+		})
+		It("flattens the aliases in the file", func() {
+			// goyaml -f file.yaml flatten-aliases
+			out, err := runCommand("", "-f", workFile, "flatten-aliases")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEmpty())
+
+			contents, err := osext.ReadFileAsString(workFile, true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal(flattenedYAML))
+		})
+		It("exits with the 'changed' exit code only when the file had aliases", func() {
+			// goyaml -f file.yaml --backup flatten-aliases --changed-exit-code 3
+			out, err := runCommand("", "-f", workFile, "--backup", "flatten-aliases", "--changed-exit-code", "3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEmpty())
+			Expect(testApp.ExitCode()).To(Equal(3))
+			Expect(os.Remove(workFile + ".bak")).To(Succeed())
+
+			// Flattening again changes nothing, so the file is neither saved nor backed up
+			unformattedYAML := "a:   {b: 1}\n"
+			Expect(os.WriteFile(workFile, []byte(unformattedYAML), 0644)).To(Succeed())
+
+			testApp = createTestApp()
+			out, err = runCommand("", "-f", workFile, "--backup", "flatten-aliases", "--changed-exit-code", "3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEmpty())
+			Expect(testApp.ExitCode()).To(Equal(0))
+			Expect(workFile + ".bak").ToNot(BeAnExistingFile())
+
+			contents, err := osext.ReadFileAsString(workFile, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal(unformattedYAML))
+		})
+	})
+})
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/theochva/goyaml/internal/commands/cli"
+)
+
+type _BundleCommand struct {
+	cli.AppSubCommand
+
+	globalOpts GlobalOptions
+	outputFile string
+}
+
+func init() {
+	registerCommand(func(globalOpts GlobalOptions) cli.AppSubCommand {
+		subCmd := &_BundleCommand{
+			globalOpts: globalOpts,
+		}
+
+		cliCmd := &cobra.Command{
+			Use:                   "bundle [-o|--output <output-yaml-file>]",
+			DisableFlagsInUseLine: true,
+			Annotations: map[string]string{
+				_CmdOptKeepNodes:   _CmdOptValueTrue,
+				_CmdOptResolveRefs: _CmdOptValueTrue,
+			},
+			Short: "Write out the yaml with its references and includes inlined",
+			Long: `Write out the yaml with its references (e.g. '$ref: ./common.yaml#/logging') and its includes
+(e.g. '!include ./common.yaml#/logging') replaced with the values they refer to, i.e. a fully
+inlined yaml.
+
+The path of a reference is relative to the file that refers to it (the current directory for
+the yaml read from stdin) and is followed by an optional JSON Pointer to the value within the
+file, e.g. '#/servers/0'.  References without a path (e.g. '#/definitions/user') refer to the
+same file.  References that refer back to themselves are reported as errors.`,
+			Args: cobra.NoArgs,
+			RunE: subCmd.run,
+			Example: cli.ReplaceProgName(`  $PROG_NAME --file /tmp/foo.yaml bundle --output /tmp/foo-bundled.yaml
+  $PROG_NAME --file /tmp/foo.yaml bundle -o /tmp/foo-bundled.yaml
+  $PROG_NAME --file /tmp/foo.yaml bundle
+
+  cat /tmp/foo.yaml | $PROG_NAME bundle -o /tmp/foo-bundled.yaml
+  cat /tmp/foo.yaml | $PROG_NAME bundle`),
+		}
+
+		cliCmd.Flags().StringVarP(
+			&subCmd.outputFile,
+			_flagOutput, _flagOutputShort, "",
+			"The file to write the inlined yaml to. If not specified, the yaml is printed to stdout",
+		)
+
+		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
+		return subCmd
+	})
+}
+
+func (c *_BundleCommand) run(cmd *cobra.Command, args []string) (err error) {
+	var text string
+
+	if text, err = c.globalOpts.YamlFile().Text(); err != nil {
+		return err
+	}
+
+	if c.outputFile != "" {
+		if err = os.WriteFile(c.outputFile, []byte(text+"\n"), 0644); err != nil {
+			return errors.Wrapf(err, "Problem writing to '%s'", c.outputFile)
+		}
+		return nil
+	}
+	cmd.Println(text)
+	return nil
+}
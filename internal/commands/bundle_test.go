@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/theochva/go-misc/pkg/osext"
+)
+
+// TestBundleCommand - test suite for the bundle command
+func TestBundleCommand(t *testing.T) {
+	RegisterFailHandler(Fail)
+}
+
+var _ = Describe("Command 'bundle' scenarios", func() {
+	var (
+		workDir, workFile string
+		bundledYAML       = strings.TrimSpace(`
+name: app
+# The logging settings
+logging:
+  level: debug
+  format: json
+servers:
+  - host: one.example.com
+`)
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		workDir, err = os.MkdirTemp("", "bundle*")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.Mkdir(filepath.Join(workDir, "shared"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(workDir, "shared", "common.yaml"),
+			[]byte("logging:\n  level: debug\n  format: json\nserver:\n  $ref: servers.yaml#/primary\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(workDir, "shared", "servers.yaml"),
+			[]byte("primary:\n  host: one.example.com\n"), 0644)).To(Succeed())
+
+		workFile = filepath.Join(workDir, "app.yaml")
+		Expect(os.WriteFile(workFile, []byte(strings.TrimSpace(`
+name: app
+# The logging settings
+logging:
+  $ref: ./shared/common.yaml#/logging
+servers:
+  - !include shared/common.yaml#/server
+`)), 0644)).To(Succeed())
+	})
+	AfterEach(func() {
+		os.RemoveAll(workDir)
+	})
+	It("prints out help for the 'bundle' command", func() {
+		// goyaml bundle --help
+		out, err := runCommand("", "bundle", "--help")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(getHelpTextForCommand("bundle")))
+	})
+	It("prints out the inlined yaml", func() {
+		// goyaml -f file.yaml bundle
+		out, err := runCommand("", "-f", workFile, "bundle")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(bundledYAML))
+
+		contents, err := osext.ReadFileAsString(workFile, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contents).To(ContainSubstring("$ref: ./shared/common.yaml#/logging"))
+	})
+	It("writes the inlined yaml to the output file", func() {
+		// goyaml -f file.yaml bundle -o bundled.yaml
+		outFile := filepath.Join(workDir, "bundled.yaml")
+		out, err := runCommand("", "-f", workFile, "bundle", "-o", outFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(BeEmpty())
+
+		contents, err := osext.ReadFileAsString(outFile, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contents).To(Equal(bundledYAML))
+	})
+	It("prints an error message for circular references", func() {
+		// goyaml -f file.yaml bundle
+		Expect(os.WriteFile(filepath.Join(workDir, "shared", "servers.yaml"),
+			[]byte("primary:\n  $ref: common.yaml#/server\n"), 0644)).To(Succeed())
+
+		out, err := runCommand("", "-f", workFile, "bundle")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error:"))
+		Expect(out).To(ContainSubstring(
+			"Circular reference: shared/common.yaml#/server -> shared/servers.yaml#/primary -> shared/common.yaml#/server"))
+	})
+	It("prints an error message when the references expand beyond the max size", func() {
+		// goyaml -f file.yaml --max-size 2000 bundle
+		lines := []string{"l0: [a, a, a, a, a, a, a, a, a, a]"}
+		for level := 1; level <= 5; level++ {
+			ref := fmt.Sprintf("{$ref: '#/l%d'}", level-1)
+			lines = append(lines, fmt.Sprintf("l%d: [%s]", level, strings.TrimSuffix(strings.Repeat(ref+", ", 10), ", ")))
+		}
+		Expect(os.WriteFile(workFile, []byte(strings.Join(lines, "\n")), 0644)).To(Succeed())
+
+		out, err := runCommand("", "-f", workFile, "--max-size", "2000", "bundle")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error:"))
+		Expect(out).To(ContainSubstring("The yaml exceeds the max alias expansion limit of 2000"))
+	})
+	It("resolves the references of the yaml read with '--resolve-refs'", func() {
+		// goyaml -f file.yaml --resolve-refs get key
+		out, err := runCommand("", "-f", workFile, "--resolve-refs", "get", "logging.level")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("debug"))
+	})
+	It("prints an error message with '--resolve-refs' for commands modifying the yaml file", func() {
+		// goyaml -f file.yaml --resolve-refs set key value
+		out, err := runCommand("", "-f", workFile, "--resolve-refs", "set", "name", "other")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error: the '--resolve-refs' flag cannot be used with commands modifying the yaml file"))
+	})
+})
@@ -17,6 +17,9 @@ func (a *App) GetRootCommand() AppRootCommand { return a.rootCommand }
 
 // Execute - run the app
 func (a *App) Execute() error {
+	if cleaner, ok := a.rootCommand.(AppCleaner); ok {
+		defer cleaner.Cleanup()
+	}
 	return a.rootCommand.GetCliCommand().Execute()
 	// if err := a.rootCommand.GetCliCommand().Execute(); err != nil {
 	// 	fmt.Println(err)
@@ -24,6 +27,14 @@ func (a *App) Execute() error {
 	// }
 }
 
+// ExitCode - the code to exit with once the app is executed
+func (a *App) ExitCode() int {
+	if exitCoder, ok := a.rootCommand.(AppExitCoder); ok {
+		return exitCoder.ExitCode()
+	}
+	return 0
+}
+
 // if err := globalOpts.rootCmd.Execute(); err != nil {
 // 	if _, isValidationErr := err.(validationError); !isValidationErr {
 // 		fmt.Println(err)
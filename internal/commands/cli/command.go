@@ -48,6 +48,18 @@ type AppRootCommand interface {
 	AddSubCommands(appSubCmds ...AppSubCommand)
 }
 
+// AppCleaner - implemented by root commands that need to release resources once executed
+type AppCleaner interface {
+	// Cleanup - release any resources held while executing
+	Cleanup()
+}
+
+// AppExitCoder - implemented by root commands that exit with a specific code once executed
+type AppExitCoder interface {
+	// ExitCode - the code to exit with
+	ExitCode() int
+}
+
 // AppRootCommandBase - base root command
 type AppRootCommandBase struct {
 	AppCommand
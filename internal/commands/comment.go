@@ -0,0 +1,181 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/theochva/goyaml/internal/commands/cli"
+	"github.com/theochva/goyaml/pkg/yamldoc"
+)
+
+const (
+	_flagLine = "line"
+	_flagFoot = "foot"
+)
+
+type _CommentCommand struct {
+	cli.AppSubCommand
+
+	globalOpts      GlobalOptions
+	outputFormat    string
+	line            string
+	foot            string
+	changedExitCode int
+}
+
+func init() {
+	registerCommand(func(globalOpts GlobalOptions) cli.AppSubCommand {
+		subCmd := &_CommentCommand{
+			globalOpts: globalOpts,
+		}
+
+		cliCmd := &cobra.Command{
+			Use:                   "comment <get|set> <key> ...",
+			DisableFlagsInUseLine: true,
+			Short:                 "Read or write the comments of a key in the yaml",
+			Long: `Read or write the comments of a key in the yaml: the comment on the lines before the key (head),
+the comment at the end of the line of the key (line) and the comment on the lines after its value (foot).
+
+The comments are read and written without their '#' markers, with the lines of multi-line comments
+separated by new lines.  An empty key ("") refers to the comments of the whole document, e.g. for a
+banner at the top of the yaml.`,
+			Example: cli.ReplaceProgName(`  $PROG_NAME -f /tmp/foo.yaml comment get first.second.third
+  $PROG_NAME -f /tmp/foo.yaml comment set first.second.third "The third value"
+  $PROG_NAME -f /tmp/foo.yaml comment set "" "managed by goyaml; do not edit"`),
+		}
+
+		getCmd := &cobra.Command{
+			Use:                   fmt.Sprintf("get <key> [-o|--output %s]", strings.Join(outputFormatValues, "|")),
+			DisableFlagsInUseLine: true,
+			Annotations:           map[string]string{_CmdOptKeepNodes: _CmdOptValueTrue},
+			Short:                 "Read the comments of a key in the yaml",
+			Long: `Read the comments of a key in the yaml.  The head, line and foot comments that the key has are
+printed in that order, unless an output format is specified.`,
+			Args: func(cmd *cobra.Command, args []string) error {
+				if len(args) != 1 {
+					return fmt.Errorf("requires the 'key' to read the comments of")
+				}
+				return nil
+			},
+			ArgAliases: []string{"key"},
+			PreRunE: func(cmd *cobra.Command, args []string) error {
+				return validateEnumValues(subCmd.outputFormat, "Invalid output format specified", outputFormatValues)
+			},
+			RunE: subCmd.runGet,
+			Example: cli.ReplaceProgName(`  $PROG_NAME -f /tmp/foo.yaml comment get first.second.third
+  $PROG_NAME -f /tmp/foo.yaml comment get first.second.third -o json
+
+  cat /tmp/foo.yaml | $PROG_NAME comment get first.second.third`),
+		}
+		getCmd.Flags().StringVarP(
+			&subCmd.outputFormat,
+			_flagOutput, _flagOutputShort, "",
+			fmt.Sprintf("the output format for the comments. Support formats are: %s", strings.Join(outputFormatValues, ", ")))
+
+		setCmd := &cobra.Command{
+			Use:                   fmt.Sprintf("set <key> [<head-comment>] [--%s <comment>] [--%s <comment>]", _flagLine, _flagFoot),
+			DisableFlagsInUseLine: true,
+			Annotations: map[string]string{
+				_CmdOptMutating:  _CmdOptValueTrue,
+				_CmdOptKeepNodes: _CmdOptValueTrue,
+			},
+			Short: "Write the comments of a key in the yaml",
+			Long: `Write the comments of a key in the yaml.  Only the comments specified are written, while an empty
+comment ("") removes the existing one.
+
+If reading from stdin, it outputs the updated YAML.  If reading from a file, the file is updated.`,
+			Args: func(cmd *cobra.Command, args []string) error {
+				if len(args) < 1 || len(args) > 2 {
+					return fmt.Errorf("requires the 'key' to write the comments of and optionally its head comment")
+				}
+				return nil
+			},
+			ArgAliases: []string{"key", "head-comment"},
+			RunE:       subCmd.runSet,
+			Example: cli.ReplaceProgName(`  $PROG_NAME -f /tmp/foo.yaml comment set first.second.third "The third value"
+  $PROG_NAME -f /tmp/foo.yaml comment set first.second.third --line "in seconds"
+  $PROG_NAME -f /tmp/foo.yaml comment set first.second.third "" --foot ""
+  $PROG_NAME -f /tmp/foo.yaml comment set "" "managed by goyaml; do not edit"
+
+  cat /tmp/foo.yaml | $PROG_NAME comment set first.second.third "The third value"`),
+		}
+		setCmd.Flags().StringVar(
+			&subCmd.line,
+			_flagLine, "",
+			"the comment at the end of the line of the key",
+		)
+		setCmd.Flags().StringVar(
+			&subCmd.foot,
+			_flagFoot, "",
+			"the comment on the lines after the value of the key",
+		)
+		addChangedExitCodeFlag(setCmd, &subCmd.changedExitCode)
+
+		cliCmd.AddCommand(getCmd, setCmd)
+
+		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
+		return subCmd
+	})
+}
+
+func (c *_CommentCommand) runGet(cmd *cobra.Command, args []string) (err error) {
+	comment, err := c.globalOpts.YamlFile().GetComment(args[0])
+	if err != nil {
+		return err
+	}
+
+	if c.outputFormat != "" {
+		var bytes []byte
+
+		if bytes, err = marshalValue(map[string]interface{}{
+			"head": comment.Head,
+			"line": comment.Line,
+			"foot": comment.Foot,
+		}, c.outputFormat); err != nil {
+			return err
+		}
+		cmd.Println(strings.TrimSpace(string(bytes)))
+		return nil
+	}
+	for _, text := range []string{comment.Head, comment.Line, comment.Foot} {
+		if text != "" {
+			cmd.Println(text)
+		}
+	}
+	return nil
+}
+
+func (c *_CommentCommand) runSet(cmd *cobra.Command, args []string) (err error) {
+	var (
+		key      = args[0]
+		yamlFile = c.globalOpts.YamlFile()
+		comment  yamldoc.Comment
+	)
+	if comment, err = yamlFile.GetComment(key); err != nil {
+		return err
+	}
+	if len(args) > 1 {
+		comment.Head = args[1]
+	}
+	if cmd.Flags().Changed(_flagLine) {
+		comment.Line = c.line
+	}
+	if cmd.Flags().Changed(_flagFoot) {
+		comment.Foot = c.foot
+	}
+	if err = yamlFile.SetComment(key, comment.Head, comment.Line, comment.Foot); err != nil {
+		return err
+	}
+
+	// If YAML read from stdin, then "Save" will output result
+	if yamlFile.IsDirty() {
+		return saveIfChanged(c.globalOpts, c.changedExitCode)
+	}
+	// Else, if not changed, then nothing printed, so dump the YAML
+	if c.globalOpts.IsPipe() {
+		err = yamlFile.Save()
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/theochva/go-misc/pkg/osext"
+)
+
+// TestCommentCommand - test suite for the comment command
+func TestCommentCommand(t *testing.T) {
+	RegisterFailHandler(Fail)
+}
+
+var _ = Describe("Command 'comment' scenarios", func() {
+	var commentedYAML = strings.TrimSpace(`
+# The name of the service
+name: service-a # unique
+settings:
+  timeout: 10
+`)
+
+	It("prints out help", func() {
+		out, err := runCommand("", "comment", "--help")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(getHelpTextForCommand("comment")))
+	})
+	It("prints the comments of a key", func() {
+		// cat file.yaml | goyaml comment get key
+		out, err := runCommand(commentedYAML, "comment", "get", "name")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("The name of the service\nunique"))
+
+		testApp = createTestApp()
+		// cat file.yaml | goyaml comment get key -o json
+		out, err = runCommand(commentedYAML, "comment", "get", "name", "-o", "json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(MatchJSON(`{"head": "The name of the service", "line": "unique", "foot": ""}`))
+	})
+	It("sets the comments of a key", func() {
+		// cat file.yaml | goyaml comment set key text --line text
+		out, err := runCommand(commentedYAML, "comment", "set", "settings.timeout", "In seconds", "--line", "default")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(strings.Replace(commentedYAML, "  timeout: 10", "  # In seconds\n  timeout: 10 # default", 1)))
+	})
+	It("removes only the comments specified", func() {
+		// cat file.yaml | goyaml comment set key --line ""
+		out, err := runCommand(commentedYAML, "comment", "set", "name", "--line", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(strings.Replace(commentedYAML, " # unique", "", 1)))
+	})
+	It("prints an error message for a key that is not in the yaml", func() {
+		// cat file.yaml | goyaml comment get key
+		out, err := runCommand(commentedYAML, "comment", "get", "settings.retries")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error: The key was not found in the yaml"))
+	})
+	When("The yaml is in a file", func() {
+		var workDir, workFile string
+
+		BeforeEach(func() {
+			var err error
+
+			workDir, err = os.MkdirTemp("", "comment*")
+			Expect(err).ToNot(HaveOccurred())
+			workFile = filepath.Join(workDir, "test.yaml")
+			Expect(os.WriteFile(workFile, []byte(commentedYAML), 0644)).To(Succeed())
+		})
+		AfterEach(func() {
+			os.RemoveAll(workDir)
+		})
+		It("adds a banner to the file", func() {
+			// goyaml -f file.yaml comment set "" text
+			out, err := runCommand("", "-f", workFile, "comment", "set", "", "managed by goyaml; do not edit")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEmpty())
+
+			contents, err := osext.ReadFileAsString(workFile, true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal("# managed by goyaml; do not edit\n\n" + commentedYAML))
+		})
+	})
+})
@@ -3,10 +3,17 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
+
+	"github.com/theochva/goyaml/pkg/yamldoc"
+	"github.com/theochva/goyaml/pkg/yamlfile"
 )
 
 const (
@@ -28,11 +35,48 @@ const (
 	_flagType            = "type"
 	_flagTypeShort       = "t"
 	_flagStdin           = "stdin"
+	_flagLockTimeout     = "lock-timeout"
+	_flagShowOrigin      = "show-origin"
+	_flagChangedExitCode = "changed-exit-code"
+	_flagBackup          = "backup"
+	_flagHistory         = "history"
+	_flagInputEncoding   = "input-encoding"
+	_flagOutputEncoding  = "output-encoding"
+	_flagKeepEncoding    = "keep-encoding"
+	_flagDir             = "dir"
+	_flagDirShort        = "d"
+	_flagMaxSize         = "max-size"
+	_flagMaxDepth        = "max-depth"
+	_flagKeepAnchors     = "keep-anchors"
+	_flagResolveTags     = "resolve-tags"
+	_flagInterpolateEnv  = "interpolate-env"
+	_flagResolveRefs     = "resolve-refs"
+	_flagBefore          = "before"
+	_flagAfter           = "after"
+	_flagRecursive       = "recursive"
+	_flagRecursiveShort  = "r"
+	_flagBy              = "by"
+	_flagPath            = "path"
+	_flagRegex           = "regex"
+	_flagKeyFile         = "key-file"
+	_flagKey             = "key"
+	_flagValue           = "value"
+	_flagStrategy        = "strategy"
+	_flagNoDefaults      = "no-defaults"
+	_flagDatetimes       = "datetimes"
+	_flagPrefix          = "prefix"
+	_flagKeyCase         = "key-case"
+	_flagSeparator       = "separator"
+	_flagIndexStyle      = "index-style"
 )
 
 const (
 	_CmdOptValidationAware = "CmdOptValidationAware"
 	_CmdOptSkipParsing     = "CmdOptSkipParsing"
+	_CmdOptMutating        = "CmdOptMutating"
+	_CmdOptKeepNodes       = "CmdOptKeepNodes"
+	_CmdOptKeepNodesFlags  = "CmdOptKeepNodesFlags"
+	_CmdOptResolveRefs     = "CmdOptResolveRefs"
 	_CmdOptValueTrue       = "true"
 	_CmdOptValueFalse      = "false"
 )
@@ -44,12 +88,47 @@ const (
 	_FormatHTML = "html"
 )
 
+// defaultLockTimeout - how long mutating commands wait for the lock of the YAML file
+const defaultLockTimeout = 10 * time.Second
+
 var (
 	// ErrUnsupportedOutputFormat - when an unknown output format was specified
 	ErrUnsupportedOutputFormat = fmt.Errorf("unsupport output format.  Supported values are: %s", strings.Join(outputFormatValues, ", "))
 	outputFormatValues         = []string{_FormatJSON, _FormatYAML}
 )
 
+// addChangedExitCodeFlag - add the flag for the exit code of mutating commands that changed the yaml
+func addChangedExitCodeFlag(cmd *cobra.Command, changedExitCode *int) {
+	cmd.Flags().IntVar(
+		changedExitCode,
+		_flagChangedExitCode, 0,
+		"The exit code when the yaml is changed, so that scripts can detect changes",
+	)
+}
+
+// addDirFlag - add the flag for reading/writing the yaml files in a directory (e.g. conf.d) as one yaml.
+// It is not a global flag, since its shorthand is used by other commands.
+func addDirFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP(
+		_flagDir, _flagDirShort, "",
+		fmt.Sprintf("The directory of yaml files (matching '%s') to read/write merged together in lexical order, "+
+			"instead of a single file. Changed values are saved to the files they came from", yamlfile.DefaultDirPattern),
+	)
+}
+
+// saveIfChanged - save the yaml (if changed) and exit with the "changed" exit code if it was changed
+func saveIfChanged(globalOpts GlobalOptions, changedExitCode int) (err error) {
+	var saved bool
+
+	if saved, err = globalOpts.YamlFile().SaveIfChanged(); err != nil {
+		return err
+	}
+	if saved {
+		globalOpts.SetExitCode(changedExitCode)
+	}
+	return nil
+}
+
 func validateEnumValues(userValue, errPrefix string, validValues []string) error {
 	if userValue != "" {
 		valid := false
@@ -115,3 +194,122 @@ func convertFileValue(filename string, valueType string) (actualValue interface{
 
 	return convertBytes(bytes, valueType)
 }
+
+const (
+	_IndexStyleBrackets = "brackets"
+	_IndexStyleKey      = "key"
+)
+
+var (
+	indexStyles = []string{_IndexStyleBrackets, _IndexStyleKey}
+	keyCases    = func() (cases []string) {
+		for _, keyCase := range yamldoc.KeyCases {
+			cases = append(cases, string(keyCase))
+		}
+		return cases
+	}()
+)
+
+// _FlattenFlags - the flags of the commands converting the yaml to/from flattened keys and values,
+// e.g. ".properties" or ".env" files
+type _FlattenFlags struct {
+	prefix     string
+	keyCase    string
+	separator  string
+	indexStyle string
+}
+
+// addFlattenFlags - add the flags for flattening the yaml, with the defaults of the format
+func addFlattenFlags(cmd *cobra.Command, flags *_FlattenFlags, defaults yamldoc.FlattenOptions) {
+	var (
+		keyCase    = defaults.KeyCase
+		indexStyle = _IndexStyleKey
+	)
+	if keyCase == "" {
+		keyCase = yamldoc.KeyCaseAsIs
+	}
+	if defaults.Brackets {
+		indexStyle = _IndexStyleBrackets
+	}
+
+	cmd.Flags().StringVar(
+		&flags.prefix,
+		_flagPrefix, defaults.Prefix,
+		"the prefix of the keys, e.g. 'APP_'. When converting to yaml, the keys without the prefix are skipped",
+	)
+	cmd.Flags().StringVar(
+		&flags.keyCase,
+		_flagKeyCase, string(keyCase),
+		"how the yaml keys are transformed, e.g. 'apiKey' to 'API_KEY' with upper-snake. Valid values are: "+strings.Join(keyCases, ", "),
+	)
+	cmd.Flags().StringVar(
+		&flags.separator,
+		_flagSeparator, defaults.Separator,
+		"the separator of the keys of the nested values",
+	)
+	cmd.Flags().StringVar(
+		&flags.indexStyle,
+		_flagIndexStyle, indexStyle,
+		"how the indexes of the arrays are written, e.g. 'servers[0]' (brackets) or 'servers.0' (key). Valid values are: "+
+			strings.Join(indexStyles, ", "),
+	)
+}
+
+// options - the options for flattening the yaml from the flags
+func (f *_FlattenFlags) options() (opts yamldoc.FlattenOptions, err error) {
+	if err = validateEnumValues(f.keyCase, "Invalid key case", keyCases); err != nil {
+		return
+	}
+	if err = validateEnumValues(f.indexStyle, "Invalid index style", indexStyles); err != nil {
+		return
+	}
+	if f.separator == "" {
+		return opts, fmt.Errorf("the '--%s' flag cannot be empty", _flagSeparator)
+	}
+	return yamldoc.FlattenOptions{
+		Separator: f.separator,
+		Prefix:    f.prefix,
+		KeyCase:   yamldoc.KeyCase(f.keyCase),
+		Brackets:  f.indexStyle == _IndexStyleBrackets,
+	}, nil
+}
+
+// writeEncoded - encode the yaml with the codec and write it to the output file or, if not
+// specified, print it to stdout
+func writeEncoded(cmd *cobra.Command, globalOpts GlobalOptions, codec yamlfile.Codec, outputFile, format string) (err error) {
+	var bytes []byte
+
+	if bytes, err = codec.Encode(globalOpts.YamlFile().Data()); err != nil {
+		return errors.Wrapf(err, "Problem converting YAML to %s", format)
+	}
+	if outputFile != "" {
+		if err = os.WriteFile(outputFile, bytes, 0644); err != nil {
+			return errors.Wrapf(err, "Problem writing to '%s'", outputFile)
+		}
+		return nil
+	}
+	cmd.Print(string(bytes))
+	return nil
+}
+
+// readDecoded - read the input file or, if not specified, stdin and decode it with the codec as
+// the yaml
+func readDecoded(cmd *cobra.Command, globalOpts GlobalOptions, codec yamlfile.Codec, inputFile, format string) (err error) {
+	var (
+		bytes []byte
+		data  map[string]interface{}
+	)
+
+	if inputFile == "" {
+		if bytes, err = ioutil.ReadAll(cmd.InOrStdin()); err != nil {
+			return err
+		}
+	} else if bytes, err = os.ReadFile(inputFile); err != nil {
+		return err
+	}
+	if data, err = codec.Decode(bytes); err != nil {
+		return errors.Wrapf(err, "Problem parsing the input %s", format)
+	}
+	globalOpts.YamlFile().SetData(data)
+	return nil
+}
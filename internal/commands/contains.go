@@ -43,6 +43,8 @@ func init() {
   cat /tmp/foo.yaml | $PROG_NAME c first.second.third`),
 		}
 
+		addDirFlag(cliCmd)
+
 		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
 		return subCmd
 	})
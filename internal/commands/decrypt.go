@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/theochva/goyaml/internal/commands/cli"
+)
+
+type _DecryptCommand struct {
+	cli.AppSubCommand
+
+	globalOpts      GlobalOptions
+	keyFile         string
+	changedExitCode int
+}
+
+func init() {
+	registerCommand(func(globalOpts GlobalOptions) cli.AppSubCommand {
+		subCmd := &_DecryptCommand{
+			globalOpts: globalOpts,
+		}
+
+		cliCmd := &cobra.Command{
+			Use:                   fmt.Sprintf("decrypt [--%s <keyfile>]", _flagKeyFile),
+			DisableFlagsInUseLine: true,
+			Annotations: map[string]string{
+				_CmdOptMutating:  _CmdOptValueTrue,
+				_CmdOptKeepNodes: _CmdOptValueTrue,
+			},
+			Short: "Decrypt the encrypted values of the yaml",
+			Long: `Decrypt the values of the yaml encrypted with the 'encrypt' command and remove the metadata of
+the encryption.
+
+The key is read from the keyfile or, if no keyfile is specified, from the '` + _EncryptionKeyEnvVar + `'
+environment variable.  It must be the key the values were encrypted with.
+
+If reading from stdin, it outputs the updated YAML.  If reading from a file, the file is updated.`,
+			Args: cobra.NoArgs,
+			RunE: subCmd.run,
+			Example: cli.ReplaceProgName(`  $PROG_NAME -f /tmp/foo.yaml decrypt --key-file ~/.goyaml.key
+  $PROG_NAME -f /tmp/foo.yaml decrypt
+
+  cat /tmp/foo.yaml | $PROG_NAME decrypt --key-file ~/.goyaml.key`),
+		}
+
+		addKeyFileFlag(cliCmd, &subCmd.keyFile)
+		addChangedExitCodeFlag(cliCmd, &subCmd.changedExitCode)
+
+		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
+		return subCmd
+	})
+}
+
+func (c *_DecryptCommand) run(cmd *cobra.Command, args []string) (err error) {
+	var (
+		key      []byte
+		yamlFile = c.globalOpts.YamlFile()
+	)
+
+	if key, err = readEncryptionKey(c.keyFile); err != nil {
+		return err
+	}
+	if _, err = yamlFile.Decrypt(key); err != nil {
+		return err
+	}
+	// If YAML read from stdin, then "Save" will output result
+	if yamlFile.IsDirty() {
+		return saveIfChanged(c.globalOpts, c.changedExitCode)
+	}
+	// Else, if not changed, then nothing printed, so dump the YAML
+	if c.globalOpts.IsPipe() {
+		err = yamlFile.Save()
+	}
+	return err
+}
@@ -11,7 +11,8 @@ import (
 type _DeleteCommand struct {
 	cli.AppSubCommand
 
-	globalOpts GlobalOptions
+	globalOpts      GlobalOptions
+	changedExitCode int
 }
 
 func init() {
@@ -24,6 +25,7 @@ func init() {
 			Use:                   "delete <key>",
 			DisableFlagsInUseLine: true,
 			Aliases:               []string{"d", "del", "remove", "rm"},
+			Annotations:           map[string]string{_CmdOptMutating: _CmdOptValueTrue},
 			Short:                 "Delete a value from the yaml",
 			Long: `Delete a value from the yaml. If reading from stdin, it outputs the updated YAML. If reading
 from a file, it simply outputs 'true' or 'false' to indicate whether the value was deleted.`,
@@ -49,6 +51,9 @@ from a file, it simply outputs 'true' or 'false' to indicate whether the value w
     cat /tmp/foo.yaml | $PROG_NAME rm first.second.third`),
 		}
 
+		addChangedExitCodeFlag(cliCmd, &subCmd.changedExitCode)
+		addDirFlag(cliCmd)
+
 		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
 		return subCmd
 	})
@@ -56,9 +61,8 @@ from a file, it simply outputs 'true' or 'false' to indicate whether the value w
 
 func (c *_DeleteCommand) run(cmd *cobra.Command, args []string) (err error) {
 	var (
-		key      = args[0]
-		yamlText string
-		deleted  bool
+		key     = args[0]
+		deleted bool
 	)
 
 	if deleted, err = c.globalOpts.YamlFile().Delete(key); err != nil {
@@ -67,7 +71,7 @@ func (c *_DeleteCommand) run(cmd *cobra.Command, args []string) (err error) {
 
 	if deleted {
 		// If YAML read from stdin, then "Save" will output result
-		if err = c.globalOpts.YamlFile().Save(); err != nil {
+		if err = saveIfChanged(c.globalOpts, c.changedExitCode); err != nil {
 			return err
 		}
 	}
@@ -79,10 +83,7 @@ func (c *_DeleteCommand) run(cmd *cobra.Command, args []string) (err error) {
 		// Else, YAML read from stdin. If not deleted,
 		// then nothing printed, so dump the YAML
 		if !deleted {
-			if yamlText, err = c.globalOpts.YamlFile().Text(); err != nil {
-				return err
-			}
-			cmd.Println(yamlText)
+			err = c.globalOpts.YamlFile().Save()
 		}
 	}
 	return
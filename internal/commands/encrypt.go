@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/theochva/goyaml/internal/commands/cli"
+	"github.com/theochva/goyaml/pkg/yamldoc"
+)
+
+// _EncryptionKeyEnvVar - the environment variable with the encryption key, when no keyfile is specified
+const _EncryptionKeyEnvVar = "GOYAML_ENCRYPTION_KEY"
+
+type _EncryptCommand struct {
+	cli.AppSubCommand
+
+	globalOpts      GlobalOptions
+	paths           []string
+	regex           string
+	keyFile         string
+	changedExitCode int
+}
+
+func init() {
+	registerCommand(func(globalOpts GlobalOptions) cli.AppSubCommand {
+		subCmd := &_EncryptCommand{
+			globalOpts: globalOpts,
+		}
+
+		cliCmd := &cobra.Command{
+			Use:                   fmt.Sprintf("encrypt [--%s <patterns>] [--%s <regex>] [--%s <keyfile>]", _flagPath, _flagRegex, _flagKeyFile),
+			DisableFlagsInUseLine: true,
+			Annotations: map[string]string{
+				_CmdOptMutating:  _CmdOptValueTrue,
+				_CmdOptKeepNodes: _CmdOptValueTrue,
+			},
+			Short: "Encrypt the values of the yaml matching path patterns or a regex",
+			Long: `Encrypt the values of the yaml matching the path patterns (e.g. 'db.password' or '**.token') or the
+regex (e.g. '(password|token)$') with AES-GCM, so that the keys stay readable, e.g.
+'password: ENC[AES256_GCM,data:...,iv:...,type:str]'.
+
+The 32 bytes key is read (as base64 or hex) from the keyfile or, if no keyfile is specified, from
+the '` + _EncryptionKeyEnvVar + `' environment variable.  The metadata of the encryption (e.g. the
+paths and regex) is stored under the reserved top-level key '` + yamldoc.EncryptionMetadataKey + `', so
+that encrypting again without any paths or regex encrypts the values added since.  The anchored
+values are encrypted once, at the paths of their anchors, so values matching only at the paths of
+their aliases (e.g. 'prod.password' for 'prod: *base') cannot be encrypted.
+
+If reading from stdin, it outputs the updated YAML.  If reading from a file, the file is updated.`,
+			Args: cobra.NoArgs,
+			RunE: subCmd.run,
+			Example: cli.ReplaceProgName(`  $PROG_NAME -f /tmp/foo.yaml encrypt --path db.password,api.token --key-file ~/.goyaml.key
+  $PROG_NAME -f /tmp/foo.yaml encrypt --path "**.password" --regex "(token|secret)$"
+  $PROG_NAME -f /tmp/foo.yaml encrypt
+
+  cat /tmp/foo.yaml | $PROG_NAME encrypt --regex "password$" --key-file ~/.goyaml.key`),
+		}
+
+		cliCmd.Flags().StringSliceVar(
+			&subCmd.paths,
+			_flagPath, []string{},
+			"the path patterns (comma separated) of the values to encrypt. A '*' matches any key and a '**' any number of keys",
+		)
+		cliCmd.Flags().StringVar(
+			&subCmd.regex,
+			_flagRegex, "",
+			"the regex matching the paths of the values to encrypt",
+		)
+		addKeyFileFlag(cliCmd, &subCmd.keyFile)
+		addChangedExitCodeFlag(cliCmd, &subCmd.changedExitCode)
+
+		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
+		return subCmd
+	})
+}
+
+func (c *_EncryptCommand) run(cmd *cobra.Command, args []string) (err error) {
+	var (
+		key      []byte
+		yamlFile = c.globalOpts.YamlFile()
+	)
+
+	if key, err = readEncryptionKey(c.keyFile); err != nil {
+		return err
+	}
+	if _, err = yamlFile.Encrypt(key, yamldoc.EncryptOptions{Paths: c.paths, Regex: c.regex}); err != nil {
+		return err
+	}
+	// If YAML read from stdin, then "Save" will output result
+	if yamlFile.IsDirty() {
+		return saveIfChanged(c.globalOpts, c.changedExitCode)
+	}
+	// Else, if not changed, then nothing printed, so dump the YAML
+	if c.globalOpts.IsPipe() {
+		err = yamlFile.Save()
+	}
+	return err
+}
+
+// addKeyFileFlag - add the flag for the keyfile of the encryption key
+func addKeyFileFlag(cmd *cobra.Command, keyFile *string) {
+	cmd.Flags().StringVar(
+		keyFile,
+		_flagKeyFile, "",
+		"the file with the encryption key (32 bytes as base64 or hex). If not specified, the key is read from the '"+
+			_EncryptionKeyEnvVar+"' environment variable",
+	)
+}
+
+// readEncryptionKey - read the encryption key from the keyfile or, if not specified, from the environment variable
+func readEncryptionKey(keyFile string) (key []byte, err error) {
+	var contents []byte
+
+	if keyFile != "" {
+		if contents, err = os.ReadFile(keyFile); err != nil {
+			return nil, errors.Wrapf(err, "Problem reading the key from '%s'", keyFile)
+		}
+	} else if value, found := os.LookupEnv(_EncryptionKeyEnvVar); found {
+		contents = []byte(value)
+	} else {
+		return nil, fmt.Errorf("no encryption key: specify the '--%s' flag or set the '%s' environment variable", _flagKeyFile, _EncryptionKeyEnvVar)
+	}
+	return yamldoc.ParseEncryptionKey(contents)
+}
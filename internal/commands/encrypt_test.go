@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/theochva/go-misc/pkg/osext"
+)
+
+// TestEncryptCommand - test suite for the encrypt and decrypt commands
+func TestEncryptCommand(t *testing.T) {
+	RegisterFailHandler(Fail)
+}
+
+var _ = Describe("Commands 'encrypt' and 'decrypt' scenarios", func() {
+	var (
+		secretsYAML = strings.TrimSpace(`
+db:
+  host: db.example.com
+  password: s3cr3t
+api:
+  token: abc123
+`)
+		key              = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32))
+		workDir, keyFile string
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		workDir, err = os.MkdirTemp("", "encrypt*")
+		Expect(err).ToNot(HaveOccurred())
+		keyFile = filepath.Join(workDir, "goyaml.key")
+		Expect(os.WriteFile(keyFile, []byte(key+"\n"), 0600)).To(Succeed())
+	})
+	AfterEach(func() {
+		os.RemoveAll(workDir)
+	})
+	It("prints out help for the 'encrypt' command", func() {
+		out, err := runCommand("", "encrypt", "--help")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(getHelpTextForCommand("encrypt")))
+	})
+	It("prints out help for the 'decrypt' command", func() {
+		out, err := runCommand("", "decrypt", "--help")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(getHelpTextForCommand("decrypt")))
+	})
+	It("encrypts and decrypts the values with the key of the keyfile", func() {
+		// cat file.yaml | goyaml encrypt --path db.password --regex token$ --key-file file.key
+		out, err := runCommand(secretsYAML, "encrypt", "--path", "db.password", "--regex", "token$", "--key-file", keyFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("db:\n  host: db.example.com\n  password: ENC[AES256_GCM,data:"))
+		Expect(out).To(ContainSubstring("token: ENC[AES256_GCM,data:"))
+		Expect(out).ToNot(ContainSubstring("s3cr3t"))
+
+		// cat file.yaml | goyaml decrypt --key-file file.key
+		testApp = createTestApp()
+		out, err = runCommand(out, "decrypt", "--key-file", keyFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(secretsYAML))
+	})
+	It("encrypts the values of the file with the key of the environment variable", func() {
+		// goyaml -f file.yaml encrypt --path db.password
+		Expect(os.Setenv(_EncryptionKeyEnvVar, key)).To(Succeed())
+		defer os.Unsetenv(_EncryptionKeyEnvVar)
+
+		workFile := filepath.Join(workDir, "secrets.yaml")
+		Expect(os.WriteFile(workFile, []byte(secretsYAML), 0644)).To(Succeed())
+		out, err := runCommand("", "-f", workFile, "encrypt", "--path", "db.password", "--changed-exit-code", "3")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(BeEmpty())
+		Expect(testApp.ExitCode()).To(Equal(3))
+
+		contents, err := osext.ReadFileAsString(workFile, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contents).To(ContainSubstring("password: ENC[AES256_GCM,data:"))
+		Expect(contents).To(ContainSubstring("goyaml_encryption:"))
+	})
+	It("prints an error message without a key", func() {
+		// cat file.yaml | goyaml encrypt --path db.password
+		out, err := runCommand(secretsYAML, "encrypt", "--path", "db.password")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error: no encryption key"))
+	})
+	It("prints an error message when decrypting with another key", func() {
+		out, err := runCommand(secretsYAML, "encrypt", "--path", "db.password", "--key-file", keyFile)
+		Expect(err).ToNot(HaveOccurred())
+
+		otherKeyFile := filepath.Join(workDir, "other.key")
+		Expect(os.WriteFile(otherKeyFile, []byte(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{8}, 32))), 0600)).To(Succeed())
+		testApp = createTestApp()
+		out, err = runCommand(out, "decrypt", "--key-file", otherKeyFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error: The key is not the key the values were encrypted with"))
+	})
+})
@@ -172,5 +172,10 @@ func (c *_ExpandCommand) run(cmd *cobra.Command, args []string) (err error) {
 	if fileCount == 0 && c.templateText == "" {
 		return fmt.Errorf("no matching file(s)")
 	}
-	return tmpl.Execute(cmd.OutOrStdout(), c.globalOpts.YamlFile().Data())
+	// The values with custom tags are resolved (see the '--resolve-tags' flag)
+	values, err := c.globalOpts.YamlFile().ResolvedData()
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(cmd.OutOrStdout(), values)
 }
@@ -277,6 +277,41 @@ END file: simple.tmpl
 				})
 			}
 		})
+		When("A valid values YAML (from STDIN) and expanded with the 'toToml' function", func() {
+			It("prints out the values as TOML", func() {
+				// cat values.yaml | goyaml expand --text "{{ toToml . }}"
+				values := strings.Join([]string{
+					"title: x",
+					"created: 2001-12-14t21:59:43.10-05:00",
+					"db: {host: h, ports: [1, 2], nested: {k: v}}",
+					"servers: [{name: one}, {name: two}]",
+				}, "\n")
+				out, err := runCommand(values, "expand", "--text", "{{ toToml . }}")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(Equal(strings.Join([]string{
+					`created = 2001-12-14T21:59:43.1-05:00`,
+					`title = "x"`,
+					``,
+					`[db]`,
+					`  host = "h"`,
+					`  ports = [1, 2]`,
+					`  [db.nested]`,
+					`    k = "v"`,
+					``,
+					`[[servers]]`,
+					`  name = "one"`,
+					``,
+					`[[servers]]`,
+					`  name = "two"`,
+				}, "\n")))
+			})
+			It("prints out an error message for values that TOML cannot represent with 'mustToToml'", func() {
+				// cat values.yaml | goyaml expand --text "{{ mustToToml .a }}"
+				out, err := runCommand("a: null", "expand", "--text", "{{ mustToToml .a }}")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(HavePrefix("Error: "))
+			})
+		})
 		When("A valid values YAML (from STDIN) and expanded with an invalid inline template", func() {
 			It("prints out an error message related to invalid template", func() {
 				// cat values.yaml | goyaml expand --text "{{.template}}"
@@ -426,4 +461,36 @@ END file: simple.tmpl
 			})
 		})
 	})
+	Context("Expanding inline template with values that have custom tags", func() {
+		const taggedValues = "one: !env SAMPLE_TAGGED_VAR\ntwo: !base64 dmFsdWVUd28=\n"
+
+		BeforeEach(func() {
+			os.Setenv("SAMPLE_TAGGED_VAR", "valueOne")
+		})
+		AfterEach(func() {
+			os.Unsetenv("SAMPLE_TAGGED_VAR")
+		})
+		When("The '--resolve-tags' flag is specified", func() {
+			It("expands the template with the resolved values", func() {
+				// cat values.yaml | goyaml --resolve-tags expand --text "{{.one}} {{.two}}"
+				out, err := runCommand(taggedValues, "--resolve-tags", "expand", "--text", "{{.one}} {{.two}}")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(Equal("valueOne valueTwo"))
+			})
+			It("prints an error message when a value cannot be resolved", func() {
+				os.Unsetenv("SAMPLE_TAGGED_VAR")
+				out, err := runCommand(taggedValues, "--resolve-tags", "expand", "--text", "{{.one}} {{.two}}")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(HavePrefix("Error: Failed to resolve '!env SAMPLE_TAGGED_VAR'"))
+			})
+		})
+		When("The '--resolve-tags' flag is not specified", func() {
+			It("expands the template with the values as they are", func() {
+				// cat values.yaml | goyaml expand --text "{{.one}} {{.two}}"
+				out, err := runCommand(taggedValues, "expand", "--text", "{{.one}} {{.two}}")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(Equal("SAMPLE_TAGGED_VAR dmFsdWVUd28="))
+			})
+		})
+	})
 })
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/theochva/goyaml/internal/commands/cli"
+)
+
+type _FlattenAliasesCommand struct {
+	cli.AppSubCommand
+
+	globalOpts      GlobalOptions
+	changedExitCode int
+}
+
+func init() {
+	registerCommand(func(globalOpts GlobalOptions) cli.AppSubCommand {
+		subCmd := &_FlattenAliasesCommand{
+			globalOpts: globalOpts,
+		}
+
+		cliCmd := &cobra.Command{
+			Use:                   "flatten-aliases",
+			DisableFlagsInUseLine: true,
+			Annotations: map[string]string{
+				_CmdOptMutating:  _CmdOptValueTrue,
+				_CmdOptKeepNodes: _CmdOptValueTrue,
+			},
+			Short: "Replace the aliases of the yaml with the values they refer to",
+			Long: `Replace the aliases of the yaml (e.g. '*defaults') with copies of the anchored values they refer to,
+merge the maps of the merge keys (e.g. '<<: *defaults') into the maps they are merged into and
+remove the anchors (e.g. '&defaults').  The values of the yaml do not change.
+
+When the yaml is read from stdin, the result is printed to stdout.  When the yaml is read from
+a file, the file is updated (only if it had any aliases, anchors or merge keys).`,
+			Args: cobra.NoArgs,
+			RunE: subCmd.run,
+			Example: cli.ReplaceProgName(`  $PROG_NAME -f /tmp/foo.yaml flatten-aliases
+
+  cat /tmp/foo.yaml | $PROG_NAME flatten-aliases`),
+		}
+
+		addChangedExitCodeFlag(cliCmd, &subCmd.changedExitCode)
+
+		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
+		return subCmd
+	})
+}
+
+func (c *_FlattenAliasesCommand) run(cmd *cobra.Command, args []string) (err error) {
+	yamlFile := c.globalOpts.YamlFile()
+
+	yamlFile.FlattenAliases()
+
+	// If YAML read from stdin, then "Save" will output result
+	if yamlFile.IsDirty() {
+		return saveIfChanged(c.globalOpts, c.changedExitCode)
+	}
+	// Else, if not changed, then nothing printed, so dump the YAML
+	if c.globalOpts.IsPipe() {
+		err = yamlFile.Save()
+	}
+	return err
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/theochva/goyaml/internal/commands/cli"
+	"github.com/theochva/goyaml/pkg/yamldoc"
+	"github.com/theochva/goyaml/pkg/yamlfile"
+)
+
+type _FromEnvCommand struct {
+	cli.AppSubCommand
+
+	globalOpts      GlobalOptions
+	flattenFlags    _FlattenFlags
+	inputFile       string
+	changedExitCode int
+}
+
+func init() {
+	registerCommand(func(globalOpts GlobalOptions) cli.AppSubCommand {
+		subCmd := &_FromEnvCommand{
+			globalOpts: globalOpts,
+		}
+
+		cliCmd := &cobra.Command{
+			Use:                   "from-env [-i|--input <input-env-file>] [--prefix <prefix>] [--key-case <case>] [--separator <separator>]",
+			DisableFlagsInUseLine: true,
+			Annotations: map[string]string{
+				_CmdOptSkipParsing: _CmdOptValueTrue,
+				_CmdOptMutating:    _CmdOptValueTrue,
+			},
+			Aliases: []string{"fe", "fromenv"},
+			Short:   "Convert a dotenv file to YAML",
+			Args:    cobra.NoArgs,
+			RunE:    subCmd.run,
+			Long: `Convert a dotenv ('.env') file (either from stdin or a file) to YAML.  The variables are split with
+the separator into the keys of nested values, e.g. 'DB__HOST=localhost', and the indexes (e.g.
+'SERVERS__0') into arrays.  The lines may start with 'export' and the values may be single quoted
+(as they are) or double quoted (escaped).
+
+Note:
+  With the upper-snake key case, the keys are converted to lower case, so keys such as 'apiKey'
+  or 'api-key', which are written out as 'API_KEY', are converted to 'api_key'.  The values are
+  typed, e.g. '10' is a number and 'true' a boolean.`,
+			Example: cli.ReplaceProgName(`  Convert an input file (.env) to /tmp/foo.yaml:
+    $PROG_NAME --file /tmp/foo.yaml from-env --input .env
+    $PROG_NAME --file /tmp/foo.yaml from-env -i .env --prefix APP_
+
+  Convert variables from stdin to YAML and print to stdout:
+    cat /tmp/app.env | $PROG_NAME from-env --prefix APP_`),
+		}
+
+		cliCmd.Flags().StringVarP(
+			&subCmd.inputFile,
+			_flagInput, _flagInputShort, "",
+			"The input dotenv file to convert to YAML",
+		)
+		addFlattenFlags(cliCmd, &subCmd.flattenFlags, _EnvDefaults)
+		addChangedExitCodeFlag(cliCmd, &subCmd.changedExitCode)
+
+		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
+		return subCmd
+	})
+}
+
+func (c *_FromEnvCommand) run(cmd *cobra.Command, args []string) (err error) {
+	var opts yamldoc.FlattenOptions
+
+	if opts, err = c.flattenFlags.options(); err != nil {
+		return err
+	}
+	if err = readDecoded(cmd, c.globalOpts, yamlfile.NewEnvCodec(opts), c.inputFile, "dotenv"); err != nil {
+		return err
+	}
+	return saveIfChanged(c.globalOpts, c.changedExitCode)
+}
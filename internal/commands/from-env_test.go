@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// TestFromEnvCommand - test suite for the from-env command
+func TestFromEnvCommand(t *testing.T) {
+	RegisterFailHandler(Fail)
+}
+
+var _ = Describe("Command 'from-env' scenarios", func() {
+	var sampleEnv = `# The application settings
+export APP_DB__HOST=localhost
+APP_DB__PORT=5432
+APP_DB__PASSWORD="p@\$\$ \"w\""
+APP_API_KEY=abc
+APP_SERVERS__0='one # not a comment'
+APP_SERVERS__1=two # a comment
+OTHER=skipped
+`
+
+	It("prints out the help for the 'from-env' command", func() {
+		// goyaml from-env --help
+		out, err := runCommand("", "from-env", "--help")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(getHelpTextForCommand("from-env")))
+	})
+	It("converts the variables with the prefix from stdin to YAML and prints it to stdout", func() {
+		// cat .env | goyaml from-env --prefix APP_
+		out, err := runCommand(sampleEnv, "from-env", "--prefix", "APP_")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(`api_key: abc
+db:
+  host: localhost
+  password: p@$$ "w"
+  port: 5432
+servers:
+  - 'one # not a comment'
+  - two`))
+	})
+	It("prints an error message for invalid lines", func() {
+		// cat .env | goyaml from-env
+		out, err := runCommand("APP_DB__HOST=localhost\nnot a variable\n", "from-env")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error: Problem parsing the input dotenv: line 2: invalid line 'not a variable'"))
+	})
+})
@@ -12,8 +12,9 @@ import (
 type _FromJSONCommand struct {
 	cli.AppSubCommand
 
-	globalOpts GlobalOptions
-	inputFile  string
+	globalOpts      GlobalOptions
+	inputFile       string
+	changedExitCode int
 }
 
 func init() {
@@ -25,11 +26,14 @@ func init() {
 		cliCmd := &cobra.Command{
 			Use:                   "from-json [-i|--input <input-json-file>]",
 			DisableFlagsInUseLine: true,
-			Annotations:           map[string]string{_CmdOptSkipParsing: _CmdOptValueTrue},
-			Aliases:               []string{"fj", "fromjson"},
-			Short:                 "Convert JSON to YAML",
-			Args:                  cobra.NoArgs,
-			RunE:                  subCmd.run,
+			Annotations: map[string]string{
+				_CmdOptSkipParsing: _CmdOptValueTrue,
+				_CmdOptMutating:    _CmdOptValueTrue,
+			},
+			Aliases: []string{"fj", "fromjson"},
+			Short:   "Convert JSON to YAML",
+			Args:    cobra.NoArgs,
+			RunE:    subCmd.run,
 			Long: `Convert a JSON document (either from stdin or a file) to YAML.
 	
 Note:
@@ -56,6 +60,7 @@ Note:
 			_flagInput, _flagInputShort, "",
 			"The input JSON file to convert to YAML",
 		)
+		addChangedExitCodeFlag(cliCmd, &subCmd.changedExitCode)
 
 		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
 		return subCmd
@@ -104,7 +109,7 @@ func (c *_FromJSONCommand) run(cmd *cobra.Command, args []string) (err error) {
 
 	// Changed made, then save the yaml file.
 	if changed {
-		err = c.globalOpts.YamlFile().Save()
+		err = saveIfChanged(c.globalOpts, c.changedExitCode)
 	}
 	return
 }
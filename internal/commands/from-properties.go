@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/theochva/goyaml/internal/commands/cli"
+	"github.com/theochva/goyaml/pkg/yamldoc"
+	"github.com/theochva/goyaml/pkg/yamlfile"
+)
+
+type _FromPropertiesCommand struct {
+	cli.AppSubCommand
+
+	globalOpts      GlobalOptions
+	flattenFlags    _FlattenFlags
+	inputFile       string
+	changedExitCode int
+}
+
+func init() {
+	registerCommand(func(globalOpts GlobalOptions) cli.AppSubCommand {
+		subCmd := &_FromPropertiesCommand{
+			globalOpts: globalOpts,
+		}
+
+		cliCmd := &cobra.Command{
+			Use:                   "from-properties [-i|--input <input-properties-file>] [--prefix <prefix>] [--key-case <case>] [--separator <separator>]",
+			DisableFlagsInUseLine: true,
+			Annotations: map[string]string{
+				_CmdOptSkipParsing: _CmdOptValueTrue,
+				_CmdOptMutating:    _CmdOptValueTrue,
+			},
+			Aliases: []string{"fp", "fromproperties"},
+			Short:   "Convert Java properties to YAML",
+			Args:    cobra.NoArgs,
+			RunE:    subCmd.run,
+			Long: `Convert a Java '.properties' file (either from stdin or a file) to YAML.  The keys are split with
+the separator into the keys of nested values, e.g. 'db.host=localhost', and the indexes (e.g.
+'servers[0]' or 'servers.0') into arrays.
+
+Note:
+  The values are typed, e.g. '10' is a number and 'true' a boolean, and the keys that are both a
+  value and the parent of other values (e.g. 'db=x' and 'db.host=y') cannot be converted.`,
+			Example: cli.ReplaceProgName(`  Convert an input file (foo.properties) to /tmp/foo.yaml:
+    $PROG_NAME --file /tmp/foo.yaml from-properties --input foo.properties
+    $PROG_NAME --file /tmp/foo.yaml from-properties -i foo.properties --prefix app.
+
+  Convert properties from stdin to YAML and print to stdout:
+    cat /tmp/foo.properties | $PROG_NAME from-properties`),
+		}
+
+		cliCmd.Flags().StringVarP(
+			&subCmd.inputFile,
+			_flagInput, _flagInputShort, "",
+			"The input properties file to convert to YAML",
+		)
+		addFlattenFlags(cliCmd, &subCmd.flattenFlags, _PropertiesDefaults)
+		addChangedExitCodeFlag(cliCmd, &subCmd.changedExitCode)
+
+		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
+		return subCmd
+	})
+}
+
+func (c *_FromPropertiesCommand) run(cmd *cobra.Command, args []string) (err error) {
+	var opts yamldoc.FlattenOptions
+
+	if opts, err = c.flattenFlags.options(); err != nil {
+		return err
+	}
+	if err = readDecoded(cmd, c.globalOpts, yamlfile.NewPropertiesCodec(opts), c.inputFile, "properties"); err != nil {
+		return err
+	}
+	return saveIfChanged(c.globalOpts, c.changedExitCode)
+}
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/theochva/go-misc/pkg/osext"
+)
+
+// TestFromPropertiesCommand - test suite for the from-properties command
+func TestFromPropertiesCommand(t *testing.T) {
+	RegisterFailHandler(Fail)
+}
+
+var _ = Describe("Command 'from-properties' scenarios", func() {
+	var sampleProperties = `# The sample
+calling-birds[0]=huey
+calling-birds[1]=dewey
+calling-birds[2]=louie
+calling-birds[3]=fred
+doe=a deer, a female deer
+french-hens=3
+pi=3.14159
+ray=a drop of golden sun
+xmas=true
+xmas-fifth-day.calling-birds=four
+xmas-fifth-day.french-hens=3
+xmas-fifth-day.golden-rings=5
+xmas-fifth-day.partridges.count=1
+xmas-fifth-day.partridges.location=a pear \
+  tree
+xmas-fifth-day.turtle-doves : two
+`
+
+	It("prints out the help for the 'from-properties' command", func() {
+		// goyaml from-properties --help
+		out, err := runCommand("", "from-properties", "--help")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(getHelpTextForCommand("from-properties")))
+	})
+	It("converts properties from stdin to YAML and prints it to stdout", func() {
+		// cat file.properties | goyaml from-properties
+		out, err := runCommand(sampleProperties, "from-properties")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(_SampleYAML))
+	})
+	It("converts only the properties with the prefix", func() {
+		// cat file.properties | goyaml from-properties --prefix app.
+		out, err := runCommand("app.db.host=localhost\nother.key=value\n", "from-properties", "--prefix", "app.")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("db:\n  host: localhost"))
+	})
+	It("prints an error message for keys that are both values and parents", func() {
+		// cat file.properties | goyaml from-properties
+		out, err := runCommand("db=x\ndb.host=y\n", "from-properties")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error: Problem parsing the input properties: key 'db.host' is the child of a value"))
+	})
+	When("Reading properties from file and target file specified", func() {
+		var inFile, outFile *os.File
+
+		BeforeEach(func() {
+			var err error
+			inFile, err = os.CreateTemp("", "testin*.properties")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(inFile.Name(), []byte(sampleProperties), 0644)).To(Succeed())
+			outFile, err = os.CreateTemp("", "testout*.yaml")
+			Expect(err).ToNot(HaveOccurred())
+		})
+		AfterEach(func() {
+			os.Remove(inFile.Name())
+			os.Remove(outFile.Name())
+		})
+		It("converts the properties file to YAML and writes it to the target file", func() {
+			// goyaml -f target.yaml from-properties -i in.properties
+			out, err := runCommand("", "-f", outFile.Name(), "from-properties", "--input", inFile.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEmpty())
+
+			contents, err := osext.ReadFileAsString(outFile.Name(), true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal(_SampleYAML))
+		})
+	})
+})
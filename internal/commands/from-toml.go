@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/theochva/goyaml/internal/commands/cli"
+	"github.com/theochva/goyaml/pkg/yamlfile"
+)
+
+type _FromTOMLCommand struct {
+	cli.AppSubCommand
+
+	globalOpts      GlobalOptions
+	inputFile       string
+	datetimes       bool
+	changedExitCode int
+}
+
+func init() {
+	registerCommand(func(globalOpts GlobalOptions) cli.AppSubCommand {
+		subCmd := &_FromTOMLCommand{
+			globalOpts: globalOpts,
+		}
+
+		cliCmd := &cobra.Command{
+			Use:                   "from-toml [-i|--input <input-toml-file>] [--datetimes]",
+			DisableFlagsInUseLine: true,
+			Annotations: map[string]string{
+				_CmdOptSkipParsing: _CmdOptValueTrue,
+				_CmdOptMutating:    _CmdOptValueTrue,
+			},
+			Aliases: []string{"ft", "fromtoml"},
+			Short:   "Convert TOML to YAML",
+			Args:    cobra.NoArgs,
+			RunE:    subCmd.run,
+			Long: `Convert a TOML document (either from stdin or a file) to YAML.
+	
+Note:
+  The TOML datetimes (e.g. '1979-05-27T07:32:00Z') are converted to YAML strings, unless
+  '--datetimes' is specified, in which case they are converted to YAML timestamps.  The TOML
+  local datetimes and dates (e.g. '1979-05-27') are kept without an offset, while the TOML local
+  times (e.g. '07:32:00') are always converted to strings, since YAML has no timestamps for them.`,
+			Example: cli.ReplaceProgName(`  Convert an input file (foo.toml) to /tmp/foo.yaml:
+    $PROG_NAME --file /tmp/foo.yaml from-toml --input foo.toml
+    $PROG_NAME --file /tmp/foo.yaml from-toml -i foo.toml --datetimes
+	
+  Convert TOML file to YAML and print to stdout:
+    $PROG_NAME from-toml --input foo.toml
+    $PROG_NAME from-toml -i foo.toml
+	
+  Convert TOML from stdin and write to YAML file:
+    cat /tmp/foo.toml | $PROG_NAME --file /tmp/foo.yaml from-toml
+	
+  Convert TOML from stdin to YAML and print to stdout:
+    cat /tmp/foo.toml | $PROG_NAME from-toml`),
+		}
+
+		cliCmd.Flags().StringVarP(
+			&subCmd.inputFile,
+			_flagInput, _flagInputShort, "",
+			"The input TOML file to convert to YAML",
+		)
+		cliCmd.Flags().BoolVar(
+			&subCmd.datetimes,
+			_flagDatetimes, false,
+			"convert the TOML datetimes to YAML timestamps instead of strings",
+		)
+		addChangedExitCodeFlag(cliCmd, &subCmd.changedExitCode)
+
+		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
+		return subCmd
+	})
+}
+
+func (c *_FromTOMLCommand) run(cmd *cobra.Command, args []string) (err error) {
+	var (
+		bytes []byte
+		data  map[string]interface{}
+	)
+
+	if c.inputFile == "" {
+		// Read TOML from stdin
+		if bytes, err = ioutil.ReadAll(cmd.InOrStdin()); err != nil {
+			return
+		}
+	} else if bytes, err = os.ReadFile(c.inputFile); err != nil {
+		// Read TOML from file
+		return
+	}
+
+	if data, err = yamlfile.NewTOMLCodec(yamlfile.TOMLOptions{Datetimes: c.datetimes}).Decode(bytes); err != nil {
+		return errors.Wrap(err, "Problem parsing the input TOML")
+	}
+	c.globalOpts.YamlFile().SetData(data)
+
+	return saveIfChanged(c.globalOpts, c.changedExitCode)
+}
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/theochva/go-misc/pkg/osext"
+)
+
+// TestFromTOMLCommand - test suite for the from-toml command
+func TestFromTOMLCommand(t *testing.T) {
+	RegisterFailHandler(Fail)
+}
+
+var _ = Describe("Command 'from-toml' scenarios", func() {
+	var sampleTOML = `calling-birds = ["huey", "dewey", "louie", "fred"]
+doe = "a deer, a female deer"
+french-hens = 3
+pi = 3.14159
+ray = "a drop of golden sun"
+xmas = true
+
+[xmas-fifth-day]
+calling-birds = "four"
+french-hens = 3
+golden-rings = 5
+turtle-doves = "two"
+
+[xmas-fifth-day.partridges]
+count = 1
+location = "a pear tree"
+`
+
+	It("prints out the help for the 'from-toml' command", func() {
+		// goyaml from-toml --help
+		out, err := runCommand("", "from-toml", "--help")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(getHelpTextForCommand("from-toml")))
+	})
+	It("converts TOML from stdin to YAML and prints it to stdout", func() {
+		// cat file.toml | goyaml from-toml
+		out, err := runCommand(sampleTOML, "from-toml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(_SampleYAML))
+	})
+	It("converts the TOML datetimes to YAML timestamps with '--datetimes'", func() {
+		// cat file.toml | goyaml from-toml --datetimes
+		out, err := runCommand("created = 1979-05-27T07:32:00Z\n", "from-toml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(`created: "1979-05-27T07:32:00Z"`))
+
+		testApp = createTestApp()
+		out, err = runCommand("created = 1979-05-27T07:32:00Z\n", "from-toml", "--datetimes")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("created: 1979-05-27T07:32:00Z"))
+	})
+	It("keeps the TOML local dates and times as such", func() {
+		// cat file.toml | goyaml from-toml
+		out, err := runCommand("l = 1979-05-27\nt = 07:32:00\n", "from-toml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("l: \"1979-05-27\"\nt: \"07:32:00\""))
+	})
+	It("prints an error message for invalid TOML", func() {
+		// echo "This is a test" | goyaml from-toml
+		out, err := runCommand("This is a test", "from-toml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error: Problem parsing the input TOML"))
+	})
+	When("Reading TOML from file and target file specified", func() {
+		var inFile, outFile *os.File
+
+		BeforeEach(func() {
+			var err error
+			inFile, err = os.CreateTemp("", "testin*.toml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(inFile.Name(), []byte(sampleTOML), 0644)).To(Succeed())
+			outFile, err = os.CreateTemp("", "testout*.yaml")
+			Expect(err).ToNot(HaveOccurred())
+		})
+		AfterEach(func() {
+			os.Remove(inFile.Name())
+			os.Remove(outFile.Name())
+		})
+		It("converts the TOML file to YAML and writes it to the target file", func() {
+			// goyaml -f target.yaml from-toml -i in.toml
+			out, err := runCommand("", "-f", outFile.Name(), "from-toml", "--input", inFile.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEmpty())
+
+			contents, err := osext.ReadFileAsString(outFile.Name(), true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal(_SampleYAML))
+		})
+	})
+})
@@ -14,6 +14,12 @@ type _GetCommand struct {
 
 	globalOpts   GlobalOptions
 	outputFormat string
+	showOrigin   bool
+}
+
+// originAware - implemented by YAML files that know where their values came from
+type originAware interface {
+	Origin(key string) string
 }
 
 func init() {
@@ -23,11 +29,15 @@ func init() {
 		}
 
 		cliCmd := &cobra.Command{
-			Use:                   fmt.Sprintf("get <key> [-o|--output %s]", strings.Join(outputFormatValues, "|")),
+			Use:                   fmt.Sprintf("get <key> [-o|--output %s] [--show-origin]", strings.Join(outputFormatValues, "|")),
 			DisableFlagsInUseLine: true,
 			Aliases:               []string{"g"},
 			Short:                 "Read a value from the yaml",
-			Long:                  "Read a value from the yaml.  You can optionally specify the output format for the retrieved value.",
+			Long: `Read a value from the yaml.  You can optionally specify the output format for the retrieved value.
+
+When multiple files are specified with '-f|--file', the value is read from the files merged together
+and '--show-origin' prints (as a comment before the value) the file the value came from. The same applies
+to the files in a directory specified with '-d|--dir'.`,
 			Args: func(cmd *cobra.Command, args []string) error {
 				if len(args) != 1 {
 					return fmt.Errorf("requires the 'key' to retrieve")
@@ -46,13 +56,25 @@ func init() {
 
   cat /tmp/foo.yaml | $PROG_NAME get first.second.third
   cat /tmp/foo.yaml | $PROG_NAME get first.second.third -o json
-  cat /tmp/foo.yaml | $PROG_NAME get first.second.third --output json`),
+  cat /tmp/foo.yaml | $PROG_NAME get first.second.third --output json
+
+  $PROG_NAME -f defaults.yaml -f env/prod.yaml -f local.yaml get first.second.third
+  $PROG_NAME -f defaults.yaml -f env/prod.yaml -f local.yaml get first.second.third --show-origin
+
+  $PROG_NAME -d conf.d get first.second.third --show-origin`),
 		}
 
 		cliCmd.Flags().StringVarP(
 			&subCmd.outputFormat,
 			_flagOutput, _flagOutputShort, "",
 			fmt.Sprintf("the output format for value retrieved. Support formats are: %s", strings.Join(outputFormatValues, ", ")))
+		cliCmd.Flags().BoolVarP(
+			&subCmd.showOrigin,
+			_flagShowOrigin, "", false,
+			"print the file the value came from before the value",
+		)
+
+		addDirFlag(cliCmd)
 
 		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
 		return subCmd
@@ -71,6 +93,10 @@ func (c *_GetCommand) run(cmd *cobra.Command, args []string) (err error) {
 		return
 	}
 
+	if c.showOrigin {
+		cmd.Printf("# origin: %s\n", c.getOrigin(key))
+	}
+
 	if c.outputFormat != "" {
 		var bytes []byte
 		if bytes, err = marshalValue(value, c.outputFormat); err != nil {
@@ -83,3 +109,13 @@ func (c *_GetCommand) run(cmd *cobra.Command, args []string) (err error) {
 	cmd.Println(value)
 	return
 }
+
+func (c *_GetCommand) getOrigin(key string) string {
+	if c.globalOpts.IsPipe() {
+		return "stdin"
+	}
+	if layered, ok := c.globalOpts.YamlFile().(originAware); ok {
+		return layered.Origin(key)
+	}
+	return c.globalOpts.YamlFile().Filename()
+}
@@ -2,11 +2,15 @@ package commands
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+
+	"github.com/theochva/go-misc/pkg/osext"
 )
 
 // TestGetCommand - test suite for the get command
@@ -73,6 +77,102 @@ var _ = Describe("Command 'get' scenarios", func() {
 			Expect(out).To(HavePrefix("Error:"))
 		})
 	})
+	When("Multiple source YAML files are specified with '-f' option", func() {
+		var overrideFile *os.File
+
+		BeforeEach(func() {
+			var err error
+			overrideFile, err = osext.CreateTempWithContents("", "override*.yaml", []byte("xmas-fifth-day:\n  golden-rings: 6"), 0644)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		AfterEach(func() {
+			if overrideFile != nil {
+				os.Remove(overrideFile.Name())
+			}
+		})
+		It("prints out the values of the files merged together", func() {
+			// goyaml -f file.yaml -f override.yaml get some.existing.key
+			out, err := runCommand("", "-f", testYAMLFile.Name(), "-f", overrideFile.Name(), "get", _SampleYAMLExistingIntKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("6"))
+
+			testApp = createTestApp()
+			out, err = runCommand("", "-f", testYAMLFile.Name(), "-f", overrideFile.Name(), "get", _SampleYAMLExistingKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(_SampleYAMLExistingValue))
+		})
+		It("prints out the file each value came from", func() {
+			// goyaml -f file.yaml -f override.yaml get some.existing.key --show-origin
+			out, err := runCommand("", "-f", testYAMLFile.Name(), "-f", overrideFile.Name(), "get", _SampleYAMLExistingIntKey, "--show-origin")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(fmt.Sprintf("# origin: %s\n6", overrideFile.Name())))
+
+			testApp = createTestApp()
+			out, err = runCommand("", "-f", testYAMLFile.Name(), "-f", overrideFile.Name(), "get", _SampleYAMLExistingKey, "--show-origin")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(fmt.Sprintf("# origin: %s\n%s", testYAMLFile.Name(), _SampleYAMLExistingValue)))
+		})
+		It("prints an error message when one of the files exceeds the max size", func() {
+			// goyaml -f file.yaml -f override.yaml --max-size 100 get some.existing.key
+			out, err := runCommand("", "-f", testYAMLFile.Name(), "-f", overrideFile.Name(), "--max-size", "100", "get", _SampleYAMLExistingIntKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("Error:"))
+			Expect(out).To(ContainSubstring("File '" + testYAMLFile.Name() + "': The yaml exceeds the max bytes limit of 100"))
+		})
+		It("skips files that do not exist", func() {
+			// goyaml -f file.yaml -f missing.yaml get some.existing.key
+			out, err := runCommand("", "-f", testYAMLFile.Name(), "-f", overrideFile.Name()+".missing", "get", _SampleYAMLExistingIntKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(_SampleYAMLExistingIntValue))
+		})
+		It("prints an error message for commands modifying the YAML", func() {
+			// goyaml -f file.yaml -f override.yaml set some.key value
+			out, err := runCommand("", "-f", testYAMLFile.Name(), "-f", overrideFile.Name(), "set", "a", "b")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("Error:"))
+		})
+	})
+	When("A directory of source YAML files is specified with '-d' option", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = os.MkdirTemp("", "conf*.d")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(dir, "10-base.yaml"), []byte(_SampleYAML), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "20-override.yaml"), []byte("xmas-fifth-day:\n  golden-rings: 6"), 0644)).To(Succeed())
+		})
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+		It("prints out the values of the files merged together and the file each value came from", func() {
+			// goyaml -d conf.d get some.existing.key --show-origin
+			out, err := runCommand("", "-d", dir, "get", _SampleYAMLExistingIntKey, "--show-origin")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(fmt.Sprintf("# origin: %s\n6", filepath.Join(dir, "20-override.yaml"))))
+		})
+		It("saves the values to the files they came from", func() {
+			// goyaml -d conf.d set some.existing.key 7 -t int
+			out, err := runCommand("", "-d", dir, "set", _SampleYAMLExistingIntKey, "7", "-t", "int")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("true"))
+
+			contents, err := osext.ReadFileAsString(filepath.Join(dir, "20-override.yaml"), true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal("xmas-fifth-day:\n  golden-rings: 7"))
+
+			testApp = createTestApp()
+			out, err = runCommand("", "-d", dir, "get", _SampleYAMLExistingKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(_SampleYAMLExistingValue))
+		})
+		It("prints an error message when a file is also specified", func() {
+			// goyaml -f file.yaml -d conf.d get some.existing.key
+			out, err := runCommand("", "-f", testYAMLFile.Name(), "-d", dir, "get", _SampleYAMLExistingKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("Error:"))
+		})
+	})
 	When("Source YAML is specified with '-f' option", func() {
 		var (
 			keys = []string{
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"io"
 	"os"
 
 	"github.com/pkg/errors"
@@ -24,6 +25,8 @@ type GlobalOptions interface {
 	ValidationError() error
 	// Load - loads the YAML file and any error is returned and also set in "ValidationError"
 	Load() error
+	// SetExitCode - set the code the app exits with
+	SetExitCode(rc int)
 }
 
 type _GlobalOptions struct {
@@ -31,6 +34,9 @@ type _GlobalOptions struct {
 	yamlFile          yamlfile.YamlFile
 	yamlValidationErr error
 	loaded            bool
+	unlock            func() error
+	exitCode          int
+	output            io.WriteCloser
 }
 
 // YamlFile - get the yaml file we are working with
@@ -61,6 +67,21 @@ func (o *_GlobalOptions) Load() (err error) {
 	return
 }
 
+// SetExitCode - set the code the app exits with
+func (o *_GlobalOptions) SetExitCode(rc int) { o.exitCode = rc }
+
+// release - flush the yaml written to stdout (if encoded) and release the lock of the yaml file (if locked)
+func (o *_GlobalOptions) release() {
+	if o.output != nil {
+		o.output.Close()
+		o.output = nil
+	}
+	if o.unlock != nil {
+		o.unlock()
+		o.unlock = nil
+	}
+}
+
 // NewGoyamlApp - create the goyaml app
 func NewGoyamlApp(version, commit, date string) *cli.App {
 	rootCmd := newRootCommand(version, commit, date)
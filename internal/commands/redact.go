@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/theochva/goyaml/internal/commands/cli"
+	"github.com/theochva/goyaml/pkg/yamldoc"
+)
+
+type _RedactCommand struct {
+	cli.AppSubCommand
+
+	globalOpts GlobalOptions
+	paths      []string
+	keys       []string
+	values     []string
+	strategy   string
+	noDefaults bool
+}
+
+var redactStrategies = func() (strategies []string) {
+	for _, strategy := range yamldoc.RedactStrategies {
+		strategies = append(strategies, string(strategy))
+	}
+	return strategies
+}()
+
+func init() {
+	registerCommand(func(globalOpts GlobalOptions) cli.AppSubCommand {
+		subCmd := &_RedactCommand{
+			globalOpts: globalOpts,
+		}
+
+		cliCmd := &cobra.Command{
+			Use: fmt.Sprintf("redact [--%s <patterns>] [--%s <regex>] [--%s <regex>] [--%s %s] [--%s]",
+				_flagPath, _flagKey, _flagValue, _flagStrategy, strings.Join(redactStrategies, "|"), _flagNoDefaults),
+			DisableFlagsInUseLine: true,
+			Annotations: map[string]string{
+				_CmdOptKeepNodes: _CmdOptValueTrue,
+			},
+			Short: "Print out the yaml with its sensitive values redacted",
+			Long: `Print out the yaml with its sensitive values redacted, e.g. for attaching it to bug reports.  The
+keys, the comments and the structure of the yaml stay the same, so the output is still valid yaml.
+
+By default, the values of the keys with the words 'password', 'token', 'secret' or 'key' (e.g.
+'db_password', 'apiKey' or 'API_KEY', but not 'monkey' or 'keyboard') are redacted.  More values can be redacted by their path patterns (e.g. 'db.url' or
+'**.credentials'), by the regex of their keys or by the regex of their values, in which case only
+the parts of the values matching the regex are redacted (e.g. PEM blocks).
+
+The values are replaced with '` + yamldoc.RedactedMask + `' (mask), the prefix of their SHA-256 hash (hash),
+which still tells equal values apart from different ones, or with as many '*' as their characters
+(keep-length).  The yaml itself is not changed.`,
+			Args: cobra.NoArgs,
+			RunE: subCmd.run,
+			Example: cli.ReplaceProgName(`  $PROG_NAME -f /tmp/foo.yaml redact
+  $PROG_NAME -f /tmp/foo.yaml redact --path db.url,"**.credentials" --strategy hash
+  $PROG_NAME -f /tmp/foo.yaml redact --value "(?s)-----BEGIN [A-Z ]+-----.*?-----END [A-Z ]+-----"
+  $PROG_NAME -f /tmp/foo.yaml redact --key "(?i)pin$" --no-defaults --strategy keep-length
+
+  cat /tmp/foo.yaml | $PROG_NAME redact > /tmp/foo-redacted.yaml`),
+		}
+
+		cliCmd.Flags().StringSliceVar(
+			&subCmd.paths,
+			_flagPath, []string{},
+			"the path patterns (comma separated) of the values to redact. A '*' matches any key and a '**' any number of keys",
+		)
+		cliCmd.Flags().StringArrayVar(
+			&subCmd.keys,
+			_flagKey, []string{},
+			"the regex matching one of the keys of the values to redact. It can be specified more than once",
+		)
+		cliCmd.Flags().StringArrayVar(
+			&subCmd.values,
+			_flagValue, []string{},
+			"the regex matching the (parts of the) values to redact. It can be specified more than once",
+		)
+		cliCmd.Flags().StringVar(
+			&subCmd.strategy,
+			_flagStrategy, string(yamldoc.RedactMask),
+			"how the values are replaced. Valid values are: "+strings.Join(redactStrategies, ", "),
+		)
+		cliCmd.Flags().BoolVar(
+			&subCmd.noDefaults,
+			_flagNoDefaults, false,
+			"do not redact the values of the keys with the words 'password', 'token', 'secret' or 'key'",
+		)
+
+		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
+		return subCmd
+	})
+}
+
+func (c *_RedactCommand) run(cmd *cobra.Command, args []string) (err error) {
+	var (
+		rules    []yamldoc.RedactRule
+		strategy = yamldoc.RedactStrategy(c.strategy)
+		redacted yamldoc.YamlDoc
+		text     string
+	)
+
+	if err = validateEnumValues(c.strategy, "Invalid strategy", redactStrategies); err != nil {
+		return err
+	}
+	if !c.noDefaults {
+		for _, rule := range yamldoc.DefaultRedactRules {
+			rule.Strategy = strategy
+			rules = append(rules, rule)
+		}
+	}
+	for _, path := range c.paths {
+		rules = append(rules, yamldoc.RedactRule{Path: path, Strategy: strategy})
+	}
+	for _, key := range c.keys {
+		rules = append(rules, yamldoc.RedactRule{Key: key, Strategy: strategy})
+	}
+	for _, value := range c.values {
+		rules = append(rules, yamldoc.RedactRule{Value: value, Strategy: strategy})
+	}
+	if len(rules) == 0 {
+		return fmt.Errorf("no values to redact: specify the '--%s', '--%s' or '--%s' flags without '--%s'", _flagPath, _flagKey, _flagValue, _flagNoDefaults)
+	}
+
+	if redacted, err = yamldoc.Redact(c.globalOpts.YamlFile(), rules); err != nil {
+		return err
+	}
+	if text, err = redacted.Text(); err != nil {
+		return err
+	}
+	cmd.Println(text)
+	return nil
+}
@@ -2,20 +2,41 @@ package commands
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/theochva/goyaml/internal/commands/cli"
 	"github.com/theochva/goyaml/internal/commands/utils"
+	"github.com/theochva/goyaml/pkg/yamldoc"
+	"github.com/theochva/goyaml/pkg/yamlfile"
 )
 
 // _GoyamlRootCommand - the root command for the app
 type _GoyamlRootCommand struct {
 	cli.AppRootCommand
 
-	globalOpts *_GlobalOptions
-	file       string
+	globalOpts  *_GlobalOptions
+	files       []string
+	lockTimeout time.Duration
+	backup      string
+	history     int
+
+	inputEncoding  string
+	outputEncoding string
+	keepEncoding   bool
+	encodedCmd     *cobra.Command
+
+	maxSize     int64
+	maxDepth    int
+	keepAnchors bool
+	resolveTags bool
+
+	interpolateEnv []string
+	resolveRefs    bool
 }
 
 // NewRootCommand - create root command
@@ -38,7 +59,7 @@ All actions can be performed using either files or stdin/stdout.
 		
 Primarily intended to be used in scripts or command line.
 	
-RC is always 0 unless there was an error while processing.`,
+RC is always 0 unless there was an error while processing or the yaml was changed by a command run with '--changed-exit-code'.`,
 		Example: cli.ReplaceProgName(`  $PROG_NAME [-f <yaml_file>] <command> [options]
   $PROG_NAME --file <yaml_file> <command> [options]
   $PROG_NAME -f <yaml_file> <command> [options]
@@ -47,15 +68,88 @@ RC is always 0 unless there was an error while processing.`,
 
 	rootCmd := &_GoyamlRootCommand{
 		AppRootCommand: cli.NewAppRootCommandBase(cliCmd),
-		file:           "",
+		files:          []string{},
 		globalOpts:     &_GlobalOptions{},
 	}
 	// Setup options for the global flags
 	cliCmd.PersistentPreRunE = rootCmd.processFlags
-	cliCmd.PersistentFlags().StringVarP(
-		&rootCmd.file,
-		_flagFile, _flagFileShort, "",
-		"The yaml file to read/write. If not specified it reads from stdin",
+	cliCmd.PersistentFlags().StringArrayVarP(
+		&rootCmd.files,
+		_flagFile, _flagFileShort, []string{},
+		"The yaml file to read/write. If not specified it reads from stdin. If specified more than once, "+
+			"the files are merged (read-only) with values in later files overriding values in earlier files",
+	)
+	cliCmd.PersistentFlags().DurationVar(
+		&rootCmd.lockTimeout,
+		_flagLockTimeout, defaultLockTimeout,
+		"How long commands modifying the yaml file wait for its lock to be released by other commands",
+	)
+
+	cliCmd.PersistentFlags().StringVar(
+		&rootCmd.backup,
+		_flagBackup, "",
+		"Before commands modify the yaml file, keep its previous contents in a file named with the suffix appended",
+	)
+	cliCmd.PersistentFlags().Lookup(_flagBackup).NoOptDefVal = yamlfile.DefaultBackupSuffix
+	cliCmd.PersistentFlags().IntVar(
+		&rootCmd.history,
+		_flagHistory, 0,
+		fmt.Sprintf("Before commands modify the yaml file, keep its previous contents in '%s' (up to the number of versions specified), "+
+			"so that the changes can be undone with the 'undo' command", yamlfile.HistoryDir),
+	)
+	cliCmd.PersistentFlags().Lookup(_flagHistory).NoOptDefVal = strconv.Itoa(yamlfile.DefaultHistorySize)
+	cliCmd.PersistentFlags().BoolVar(
+		&rootCmd.keepEncoding,
+		_flagKeepEncoding, false,
+		"Save the yaml file with the character encoding it was read with (e.g. UTF-16 or UTF-8 with BOM), instead of UTF-8",
+	)
+	cliCmd.PersistentFlags().Int64Var(
+		&rootCmd.maxSize,
+		_flagMaxSize, 0,
+		"The maximum size (in bytes) of the yaml read, also limiting how many values its aliases and references can expand to, "+
+			"so that hostile yaml cannot exhaust the memory (0 means unlimited)",
+	)
+	cliCmd.PersistentFlags().IntVar(
+		&rootCmd.maxDepth,
+		_flagMaxDepth, 0,
+		"The maximum nesting depth of maps and arrays in the yaml read (0 means unlimited)",
+	)
+	cliCmd.PersistentFlags().BoolVar(
+		&rootCmd.keepAnchors,
+		_flagKeepAnchors, false,
+		"Keep the anchors, aliases and merge keys (<<) of the yaml, as well as the order of its keys, when modifying it. "+
+			"Otherwise, the aliases are replaced with the values they refer to",
+	)
+	cliCmd.PersistentFlags().BoolVar(
+		&rootCmd.resolveTags,
+		_flagResolveTags, false,
+		"Resolve the values with the custom tags "+strings.Join(yamldoc.RegisteredTags(), ", ")+" (e.g. 'password: !env DB_PASSWORD') "+
+			"when they are read. The tagged values are kept as they are when the yaml is modified",
+	)
+	cliCmd.PersistentFlags().StringSliceVar(
+		&rootCmd.interpolateEnv,
+		_flagInterpolateEnv, []string{},
+		"Interpolate the environment variables in the values of the yaml read, e.g. '${VAR}', '${VAR:-default}' or '${VAR:?error}'. "+
+			"Only the variables starting with one of the (comma separated) prefixes specified are interpolated, e.g. '--interpolate-env=APP_,DB_', "+
+			"while all of them are interpolated only with '--interpolate-env=*'. "+
+			"It cannot be used with commands modifying the yaml file, since the interpolated values would be saved",
+	)
+	cliCmd.PersistentFlags().BoolVar(
+		&rootCmd.resolveRefs,
+		_flagResolveRefs, false,
+		"Resolve the references (e.g. '$ref: ./common.yaml#/logging') and the includes (e.g. '!include ./common.yaml#/logging') "+
+			"of the yaml read, relative to the file that refers to them. "+
+			"It cannot be used with commands modifying the yaml file, since the referenced values would be saved",
+	)
+	cliCmd.PersistentFlags().StringVar(
+		&rootCmd.inputEncoding,
+		_flagInputEncoding, utils.EncodingNone,
+		"The encoding of the yaml read from stdin. Valid values are: "+strings.Join(utils.ValidEncodings, ", "),
+	)
+	cliCmd.PersistentFlags().StringVar(
+		&rootCmd.outputEncoding,
+		_flagOutputEncoding, utils.EncodingNone,
+		"The encoding of the output written to stdout, when reading the yaml from stdin. Valid values are: "+strings.Join(utils.ValidEncodings, ", "),
 	)
 
 	// cli.SetVersionWithAuthor(cliCmd, "") //"Bill Theocharoulas - theochva@gmail.com")
@@ -79,8 +173,76 @@ func (c *_GoyamlRootCommand) processFlags(cmd *cobra.Command, args []string) err
 	}
 
 	// Otherwise, we check the global flags
-	c.globalOpts.pipe = (c.file == "")
-	if c.globalOpts.yamlFile = utils.NewYamlFileWrapper(c.file, cmd.InOrStdin(), cmd.OutOrStdout()); c.globalOpts.yamlFile != nil {
+	if err := validateEnumValues(c.inputEncoding, "Invalid input encoding", utils.ValidEncodings); err != nil {
+		return err
+	}
+	if err := validateEnumValues(c.outputEncoding, "Invalid output encoding", utils.ValidEncodings); err != nil {
+		return err
+	}
+	dir, err := c.dirFlag(cmd)
+	if err != nil {
+		return err
+	}
+	c.globalOpts.pipe = (len(c.files) == 0 && dir == "")
+	if !c.globalOpts.pipe && c.isMutatingCommand(cmd) {
+		for _, flag := range []string{_flagInterpolateEnv, _flagResolveRefs} {
+			if cmd.Flags().Changed(flag) {
+				return fmt.Errorf("the '--%s' flag cannot be used with commands modifying the yaml file", flag)
+			}
+		}
+	}
+	if dir != "" {
+		opts, err := c.fileOptions(cmd)
+		if err != nil {
+			return err
+		}
+		c.globalOpts.yamlFile = utils.NewDirYamlFileWrapper(dir, opts...)
+	} else if len(c.files) > 1 {
+		if c.isMutatingCommand(cmd) {
+			return fmt.Errorf("the '-%s|--%s' flag can only be specified once for commands modifying the yaml", _flagFileShort, _flagFile)
+		}
+		opts, err := c.fileOptions(cmd)
+		if err != nil {
+			return err
+		}
+		c.globalOpts.yamlFile = utils.NewLayeredYamlFileWrapper(c.files, opts...)
+	} else {
+		var (
+			file   string
+			stdin  = cmd.InOrStdin()
+			stdout = cmd.OutOrStdout()
+		)
+
+		if !c.globalOpts.pipe {
+			file = c.files[0]
+		} else {
+			// The encodings only apply to the yaml read from stdin and to all the output written
+			// to stdout (e.g. the values got or the json), but not to the errors
+			stdin = utils.NewDecodingReader(c.inputEncoding, stdin)
+			c.globalOpts.output = utils.NewEncodingWriter(c.outputEncoding, stdout)
+			stdout = c.globalOpts.output
+			if c.outputEncoding != utils.EncodingNone {
+				cmd.SetOut(stdout)
+				c.encodedCmd = cmd
+			}
+		}
+		opts, err := c.fileOptions(cmd)
+		if err != nil {
+			return err
+		}
+		c.globalOpts.yamlFile = utils.NewYamlFileWrapper(file, stdin, stdout, opts...)
+	}
+	if c.globalOpts.yamlFile != nil {
+		// Lock the file before loading it, so that concurrent updates are not lost
+		if !c.globalOpts.pipe && c.isMutatingCommand(cmd) {
+			var (
+				file = c.globalOpts.yamlFile.Filename()
+				err  error
+			)
+			if c.globalOpts.unlock, err = yamlfile.Lock(file, c.lockTimeout); err != nil {
+				return errors.Wrapf(err, "File '%s'", file)
+			}
+		}
 		if !c.isSkipParsingCommand(cmd) {
 			if err := c.globalOpts.Load(); err != nil {
 				if !c.isValidationErrAwareCommand(cmd) {
@@ -93,6 +255,86 @@ func (c *_GoyamlRootCommand) processFlags(cmd *cobra.Command, args []string) err
 	return nil
 }
 
+// dirFlag - get the directory of yaml files, for the commands that support the '-d|--dir' flag
+func (c *_GoyamlRootCommand) dirFlag(cmd *cobra.Command) (dir string, err error) {
+	flag := cmd.Flags().Lookup(_flagDir)
+	if flag == nil || !flag.Changed {
+		return "", nil
+	}
+	if len(c.files) > 0 {
+		return "", fmt.Errorf("only one of the '-%s|--%s' and '-%s|--%s' flags can be specified", _flagFileShort, _flagFile, _flagDirShort, _flagDir)
+	}
+	if flag.Value.String() == "" {
+		return "", fmt.Errorf("the '-%s|--%s' flag requires a directory", _flagDirShort, _flagDir)
+	}
+	return flag.Value.String(), nil
+}
+
+// _allEnvVars - the value of the '--interpolate-env' flag for interpolating all the environment variables
+const _allEnvVars = "*"
+
+// fileOptions - get the options for the yaml file from the global flags
+func (c *_GoyamlRootCommand) fileOptions(cmd *cobra.Command) (opts []yamlfile.Option, err error) {
+	var (
+		backupSet  = cmd.Flags().Changed(_flagBackup)
+		historySet = cmd.Flags().Changed(_flagHistory)
+	)
+	switch {
+	case backupSet && historySet:
+		return nil, fmt.Errorf("only one of the '--%s' and '--%s' flags can be specified", _flagBackup, _flagHistory)
+	case backupSet:
+		opts = append(opts, yamlfile.WithBackup(yamlfile.BackupSuffix(c.backup)))
+	case historySet:
+		if c.history <= 0 {
+			return nil, fmt.Errorf("the '--%s' flag must be a positive number of versions", _flagHistory)
+		}
+		opts = append(opts, yamlfile.WithBackup(yamlfile.BackupHistory(c.history)))
+	}
+	if c.keepEncoding {
+		opts = append(opts, yamlfile.KeepEncoding())
+	}
+	if c.keepAnchors || c.isKeepNodesCommand(cmd) {
+		opts = append(opts, yamlfile.WithLoadOptions(yamldoc.KeepNodes()))
+	}
+	if c.resolveTags {
+		opts = append(opts, yamlfile.WithLoadOptions(yamldoc.ResolveTagsLazily()))
+	}
+	if cmd.Flags().Changed(_flagInterpolateEnv) {
+		var (
+			prefixes []string
+			all      bool
+		)
+		for _, prefix := range c.interpolateEnv {
+			if prefix == _allEnvVars {
+				all = true
+			} else if prefix != "" {
+				prefixes = append(prefixes, prefix)
+			}
+		}
+		// Without any prefixes, all the variables would be interpolated, which must be explicit
+		if all {
+			prefixes = nil
+		} else if len(prefixes) == 0 {
+			return nil, fmt.Errorf("the '--%s' flag requires the prefixes of the variables to interpolate, e.g. '--%s=APP_' (or '--%s=%s' for all of them)",
+				_flagInterpolateEnv, _flagInterpolateEnv, _flagInterpolateEnv, _allEnvVars)
+		}
+		opts = append(opts, yamlfile.WithLoadOptions(yamldoc.InterpolateEnv(prefixes...)))
+	}
+	if c.resolveRefs || c.isResolveRefsCommand(cmd) {
+		opts = append(opts, yamlfile.WithLoadOptions(yamldoc.ResolveRefs()))
+	}
+	if c.maxSize > 0 || c.maxDepth > 0 {
+		// A yaml without aliases cannot have more values than bytes, so the aliases of a
+		// yaml within the size limit cannot expand to more values than that either
+		opts = append(opts, yamlfile.WithLoadOptions(yamldoc.WithLimits(yamldoc.Limits{
+			MaxBytes:          c.maxSize,
+			MaxDepth:          c.maxDepth,
+			MaxAliasExpansion: int(c.maxSize),
+		})))
+	}
+	return opts, nil
+}
+
 func (c *_GoyamlRootCommand) isValidationErrAwareCommand(cmd *cobra.Command) bool {
 	if len(cmd.Annotations) > 0 {
 		if value, contains := cmd.Annotations[_CmdOptValidationAware]; contains && value == _CmdOptValueTrue {
@@ -102,6 +344,55 @@ func (c *_GoyamlRootCommand) isValidationErrAwareCommand(cmd *cobra.Command) boo
 	return false
 }
 
+func (c *_GoyamlRootCommand) isKeepNodesCommand(cmd *cobra.Command) bool {
+	if len(cmd.Annotations) > 0 {
+		if value, contains := cmd.Annotations[_CmdOptKeepNodes]; contains && value == _CmdOptValueTrue {
+			return true
+		}
+		if flags, contains := cmd.Annotations[_CmdOptKeepNodesFlags]; contains {
+			for _, flag := range strings.Split(flags, ",") {
+				if cmd.Flags().Changed(flag) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (c *_GoyamlRootCommand) isResolveRefsCommand(cmd *cobra.Command) bool {
+	if len(cmd.Annotations) > 0 {
+		if value, contains := cmd.Annotations[_CmdOptResolveRefs]; contains && value == _CmdOptValueTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *_GoyamlRootCommand) isMutatingCommand(cmd *cobra.Command) bool {
+	if len(cmd.Annotations) > 0 {
+		if value, contains := cmd.Annotations[_CmdOptMutating]; contains && value == _CmdOptValueTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// ExitCode - the code the app exits with
+func (c *_GoyamlRootCommand) ExitCode() int {
+	return c.globalOpts.exitCode
+}
+
+// Cleanup - flush the yaml written to stdout and release the lock of the yaml file (if any)
+// once the command is done
+func (c *_GoyamlRootCommand) Cleanup() {
+	c.globalOpts.release()
+	if c.encodedCmd != nil {
+		c.encodedCmd.SetOut(nil)
+		c.encodedCmd = nil
+	}
+}
+
 func (c *_GoyamlRootCommand) isSkipParsingCommand(cmd *cobra.Command) bool {
 	if len(cmd.Annotations) > 0 {
 		if value, contains := cmd.Annotations[_CmdOptSkipParsing]; contains && value == _CmdOptValueTrue {
@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"encoding/base64"
+	"os"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
@@ -20,4 +22,126 @@ var _ = Describe("Root command scenarios", func() {
 			Expect(out).To(Equal(getHelpTextForCommand("")))
 		})
 	})
+	When("The yaml read is limited", func() {
+		It("reads yaml within the limits", func() {
+			// cat file.yaml | goyaml --max-size 1000 --max-depth 3 get key
+			out, err := runCommand(_SampleYAML, "--max-size", "1000", "--max-depth", "3", "get", _SampleYAMLExistingKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(_SampleYAMLExistingValue))
+		})
+		It("prints an error message when the yaml exceeds the max size", func() {
+			// cat file.yaml | goyaml --max-size 10 get key
+			out, err := runCommand(_SampleYAML, "--max-size", "10", "get", _SampleYAMLExistingKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("Error:"))
+			Expect(out).To(ContainSubstring("max bytes"))
+		})
+		It("prints an error message when the yaml exceeds the max depth", func() {
+			// goyaml -f file.yaml --max-depth 1 get key
+			out, err := runCommand("", "-f", testYAMLFile.Name(), "--max-depth", "1", "get", _SampleYAMLExistingKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("Error:"))
+			Expect(out).To(ContainSubstring("max depth"))
+		})
+		It("prints an error message when the aliases of the yaml expand beyond the max size", func() {
+			// cat file.yaml | goyaml --max-size 1000 get key
+			laughs := "a: &a [lol, lol, lol, lol, lol, lol, lol, lol, lol, lol]\n" +
+				"b: &b [*a, *a, *a, *a, *a, *a, *a, *a, *a, *a]\n" +
+				"c: &c [*b, *b, *b, *b, *b, *b, *b, *b, *b, *b]\n"
+			out, err := runCommand(laughs, "--max-size", "1000", "get", "a")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("Error:"))
+			Expect(out).To(ContainSubstring("max alias expansion"))
+		})
+	})
+	When("The environment variables are interpolated", func() {
+		const interpolatedYAML = "host: ${APP_HOST}\nport: ${APP_PORT:-8080}\npath: ${OTHER_PATH}\n"
+
+		BeforeEach(func() {
+			os.Setenv("APP_HOST", "example.com")
+			os.Setenv("OTHER_PATH", "api")
+		})
+		AfterEach(func() {
+			os.Unsetenv("APP_HOST")
+			os.Unsetenv("OTHER_PATH")
+		})
+		It("interpolates all the variables only when asked explicitly", func() {
+			// cat file.yaml | goyaml --interpolate-env='*' to-json
+			out, err := runCommand(interpolatedYAML, "--interpolate-env=*", "to-json")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(MatchJSON(`{"host": "example.com", "port": 8080, "path": "api"}`))
+		})
+		It("prints an error message when no prefixes are specified", func() {
+			// cat file.yaml | goyaml --interpolate-env= to-json
+			out, err := runCommand(interpolatedYAML, "--interpolate-env=", "to-json")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("Error: the '--interpolate-env' flag requires the prefixes of the variables to interpolate"))
+		})
+		It("interpolates only the variables with the allowed prefixes", func() {
+			// cat file.yaml | goyaml --interpolate-env=APP_ get key
+			out, err := runCommand(interpolatedYAML, "--interpolate-env=APP_", "get", "host")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("example.com"))
+
+			testApp = createTestApp()
+			out, err = runCommand(interpolatedYAML, "--interpolate-env=APP_", "get", "path")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("${OTHER_PATH}"))
+		})
+		It("prints an error message for commands modifying the yaml file", func() {
+			// goyaml -f file.yaml --interpolate-env=APP_ set key value
+			out, err := runCommand("", "-f", testYAMLFile.Name(), "--interpolate-env=APP_", "set", _SampleYAMLExistingKey, "value")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("Error: the '--interpolate-env' flag cannot be used with commands modifying the yaml file"))
+		})
+	})
+	When("The yaml in stdin/stdout is encoded", func() {
+		It("decodes the yaml read from stdin", func() {
+			// cat file.yaml | base64 | goyaml --input-encoding base64 get key
+			encoded := base64.StdEncoding.EncodeToString([]byte(_SampleYAML))
+			out, err := runCommand(encoded, "--input-encoding", "base64", "get", _SampleYAMLExistingKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(_SampleYAMLExistingValue))
+		})
+		It("encodes the yaml written to stdout", func() {
+			// cat file.yaml | base64 | goyaml --input-encoding base64 --output-encoding base64 set key value
+			encoded := base64.StdEncoding.EncodeToString([]byte(_SampleYAML))
+			out, err := runCommand(encoded, "--input-encoding", "base64", "--output-encoding", "base64", "set", "a", "value-a")
+			Expect(err).ToNot(HaveOccurred())
+
+			decoded, err := base64.StdEncoding.DecodeString(out)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(decoded)).To(HavePrefix("a: value-a\n"))
+			Expect(string(decoded)).To(ContainSubstring(_SampleYAMLExistingValue))
+		})
+		It("encodes all the output written to stdout", func() {
+			// cat file.yaml | goyaml --output-encoding base64 get key
+			out, err := runCommand(_SampleYAML, "--output-encoding", "base64", "get", _SampleYAMLExistingKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(base64.StdEncoding.EncodeToString([]byte(_SampleYAMLExistingValue + "\n"))))
+
+			// cat file.yaml | goyaml --output-encoding base64 to-json
+			out, err = runCommand(_SampleYAML, "--output-encoding", "base64", "to-json")
+			Expect(err).ToNot(HaveOccurred())
+			decoded, err := base64.StdEncoding.DecodeString(out)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(decoded)).To(HavePrefix("{"))
+
+			// The output of the next commands is not encoded
+			testApp = createTestApp()
+			out, err = runCommand(_SampleYAML, "get", _SampleYAMLExistingKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(_SampleYAMLExistingValue))
+		})
+		It("prints an error message for an invalid encoding", func() {
+			out, err := runCommand(_SampleYAML, "--input-encoding", "base32", "get", _SampleYAMLExistingKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("Error:"))
+		})
+		It("prints an error message when stdin is not encoded", func() {
+			out, err := runCommand(_SampleYAML, "--input-encoding", "base64", "get", _SampleYAMLExistingKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("Error:"))
+		})
+	})
 })
@@ -10,6 +10,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/theochva/goyaml/internal/commands/cli"
+	"github.com/theochva/goyaml/pkg/yamldoc"
 	"gopkg.in/yaml.v3"
 )
 
@@ -21,6 +22,10 @@ type _SetCommand struct {
 	inputFile   string
 	readStdin   bool
 	valueSource string
+	before      string
+	after       string
+
+	changedExitCode int
 }
 
 const (
@@ -52,12 +57,18 @@ func init() {
 			Annotations: map[string]string{
 				_CmdOptValidationAware: _CmdOptValueTrue,
 				_CmdOptSkipParsing:     _CmdOptValueTrue,
+				_CmdOptMutating:        _CmdOptValueTrue,
+				_CmdOptKeepNodesFlags:  _flagBefore + "," + _flagAfter,
 			},
 			Aliases: []string{"s"},
 			Short:   "Set a value in a YAML document",
 			Long: `Set a value in a YAML document. There are multiple ways you can set values in a YAML document:
   - Set a value in a YAML file with a value specified, read from a file or read from stdin  
-  - Update a value in a YAML document read from stdin with a value specified or read from a file and print result to stdout`,
+  - Update a value in a YAML document read from stdin with a value specified or read from a file and print result to stdout
+
+New keys are added at the end of their map, unless '--before' or '--after' another key of the same map is
+specified, which also moves existing keys.  With these flags, the anchors, comments and order of the keys
+of the yaml are kept (see '--keep-anchors').`,
 			Args: func(cmd *cobra.Command, args []string) error {
 				if len(args) < 1 {
 					return fmt.Errorf("requires the 'key' for the value to be set")
@@ -85,6 +96,13 @@ func init() {
     $PROG_NAME -f /tmp/foo.yaml set first.second.third -i /tmp/bar.yaml -t yaml
     $PROG_NAME -f /tmp/foo.yaml set first.second.privateKey -i .ssh/id_rsa_priv
 	
+  Update a YAML file placing the key before or after another key of the same map:
+    $PROG_NAME -f /tmp/foo.yaml set first.second.strProp "someValue" --before intProp
+    $PROG_NAME -f /tmp/foo.yaml set first.second.strProp "someValue" --after boolProp
+	
+  Update a directory of YAML files (the value is saved to the file it came from):
+    $PROG_NAME -d conf.d set first.second.strProp "someValue"
+	
   Update YAML read from stdin with a value specified as a parameter and print result to stdout:
     cat /tmp/foo.yaml | $PROG_NAME set first.second.strProp "someValue"
     cat /tmp/foo.yaml | $PROG_NAME set first.second.intProp 10 -t int
@@ -122,6 +140,18 @@ func init() {
 			_flagInput, _flagInputShort, "",
 			"the file containing the value to set",
 		)
+		cliCmd.Flags().StringVar(
+			&subCmd.before,
+			_flagBefore, "",
+			"the key (of the same map) to place the key before",
+		)
+		cliCmd.Flags().StringVar(
+			&subCmd.after,
+			_flagAfter, "",
+			"the key (of the same map) to place the key after",
+		)
+		addChangedExitCodeFlag(cliCmd, &subCmd.changedExitCode)
+		addDirFlag(cliCmd)
 
 		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
 		return subCmd
@@ -176,12 +206,14 @@ func (c *_SetCommand) run(cmd *cobra.Command, args []string) (err error) {
 	}
 
 	if value != nil {
-		if valueSet, err = c.globalOpts.YamlFile().Set(key, value); err != nil {
+		opts := yamldoc.SetOptions{Before: c.before, After: c.after}
+		if valueSet, err = c.globalOpts.YamlFile().SetWithOptions(key, value, opts); err != nil {
 			return
 		}
 
+		// Setting the value it already has does not rewrite the file
 		if valueSet {
-			err = c.globalOpts.YamlFile().Save()
+			err = saveIfChanged(c.globalOpts, c.changedExitCode)
 		}
 	}
 
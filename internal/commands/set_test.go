@@ -3,13 +3,16 @@ package commands
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"github.com/theochva/go-misc/pkg/osext"
+	"github.com/theochva/goyaml/pkg/yamlfile"
 )
 
 // TestSetCommand - test suite for the set command
@@ -44,6 +47,134 @@ a:
 - value2
 - value3
 `)
+	When("The YAML file is locked by another command", func() {
+		var (
+			workFile *os.File
+			unlock   func() error
+		)
+		BeforeEach(func() {
+			var err error
+			workFile, err = osext.CreateTempWithContents("", "workfile*.yaml", []byte(_SampleYAML), 0644)
+			Expect(err).ToNot(HaveOccurred())
+			unlock, err = yamlfile.Lock(workFile.Name(), 0)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		AfterEach(func() {
+			unlock()
+			if workFile != nil {
+				os.Remove(workFile.Name())
+				os.Remove(filepath.Join(filepath.Dir(workFile.Name()), "."+filepath.Base(workFile.Name())+".lock"))
+			}
+		})
+		It("Prints an error message when the lock is not released in time", func() {
+			// goyaml -f file.yaml --lock-timeout 100ms set key value
+			out, err := runCommand("", "-f", workFile.Name(), "--lock-timeout", "100ms", "set", extraSetStringKey, extraSetStringValue)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(HavePrefix("Error:"))
+			Expect(out).To(ContainSubstring(yamlfile.ErrLockTimeout.Error()))
+
+			updatedContent, err := osext.ReadFileAsString(workFile.Name(), true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updatedContent).To(Equal(_SampleYAML))
+		})
+		It("Sets the value once the lock is released", func() {
+			release := unlock
+			go func() {
+				time.Sleep(100 * time.Millisecond)
+				release()
+			}()
+			// goyaml -f file.yaml set key value
+			out, err := runCommand("", "-f", workFile.Name(), "set", extraSetStringKey, extraSetStringValue)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("true"))
+
+			// Once done, the command releases the lock
+			unlock, err = yamlfile.Lock(workFile.Name(), 0)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+	When("The file is a JSON or TOML file", func() {
+		var workFile *os.File
+		AfterEach(func() {
+			if workFile != nil {
+				os.Remove(workFile.Name())
+			}
+		})
+		It("Sets the value in a JSON file", func() {
+			var err error
+			workFile, err = osext.CreateTempWithContents("", "workfile*.json", []byte(`{"a": {"c": "x"}}`), 0644)
+			Expect(err).ToNot(HaveOccurred())
+
+			// goyaml -f config.json set a.b 1 -t int
+			out, err := runCommand("", "-f", workFile.Name(), "set", "a.b", "1", "-t", "int")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("true"))
+
+			updatedContent, err := osext.ReadFileAsString(workFile.Name(), true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updatedContent).To(MatchJSON(`{"a": {"b": 1, "c": "x"}}`))
+		})
+		It("Sets the value in a TOML file", func() {
+			var err error
+			workFile, err = osext.CreateTempWithContents("", "workfile*.toml", []byte("[a]\nc = \"x\"\n"), 0644)
+			Expect(err).ToNot(HaveOccurred())
+
+			// goyaml -f config.toml set a.b 1 -t int
+			out, err := runCommand("", "-f", workFile.Name(), "set", "a.b", "1", "-t", "int")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("true"))
+
+			testApp = createTestApp()
+			out, err = runCommand("", "-f", workFile.Name(), "get", "a", "-o", "yaml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("b: 1\nc: x"))
+		})
+	})
+	When("The exit code for changes is specified", func() {
+		var workFile *os.File
+		BeforeEach(func() {
+			var err error
+			workFile, err = osext.CreateTempWithContents("", "workfile*.yaml", []byte(_SampleYAML), 0644)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		AfterEach(func() {
+			if workFile != nil {
+				os.Remove(workFile.Name())
+			}
+		})
+		It("Exits with the code when the value changed", func() {
+			// goyaml -f file.yaml set key value --changed-exit-code 3
+			out, err := runCommand("", "-f", workFile.Name(), "set", _SampleYAMLExistingKey, "changed", "--changed-exit-code", "3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("true"))
+			Expect(testApp.ExitCode()).To(Equal(3))
+		})
+		It("Does not rewrite the file when the value is the same", func() {
+			earlier := time.Now().Add(-time.Hour).Truncate(time.Second)
+			Expect(os.Chtimes(workFile.Name(), earlier, earlier)).To(Succeed())
+
+			// goyaml -f file.yaml set key same-value --changed-exit-code 3
+			out, err := runCommand("", "-f", workFile.Name(), "set", _SampleYAMLExistingKey, _SampleYAMLExistingValue, "--changed-exit-code", "3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("true"))
+			Expect(testApp.ExitCode()).To(Equal(0))
+
+			info, err := os.Stat(workFile.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.ModTime()).To(Equal(earlier))
+		})
+		It("Exits with the code when piping and the value changed", func() {
+			// cat file.yaml | goyaml set key value --changed-exit-code 3
+			_, err := runCommand(_SampleYAML, "set", _SampleYAMLExistingKey, _SampleYAMLExistingValue, "--changed-exit-code", "3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(testApp.ExitCode()).To(Equal(0))
+
+			testApp = createTestApp()
+			_, err = runCommand(_SampleYAML, "set", _SampleYAMLExistingKey, "changed", "--changed-exit-code", "3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(testApp.ExitCode()).To(Equal(3))
+		})
+	})
 	When("No params specified", func() {
 		It("prints out help", func() {
 
@@ -382,5 +513,27 @@ a:
 				Expect(out).To(HavePrefix("Error:"))
 			})
 		})
+		When("The key is placed before or after another key", func() {
+			const orderedYAML = "name: service-a\nport: 8080\n"
+
+			It("inserts the key before the other key", func() {
+				// cat file.yaml | goyaml set key value --before other
+				out, err := runCommand(orderedYAML, "set", "host", "localhost", "--before", "port")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(Equal("name: service-a\nhost: localhost\nport: 8080"))
+			})
+			It("moves the key after the other key", func() {
+				// cat file.yaml | goyaml set key value --after other
+				out, err := runCommand(orderedYAML, "set", "name", "service-b", "--after", "port")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(Equal("port: 8080\nname: service-b"))
+			})
+			It("prints an error message when the other key is not in the map", func() {
+				// cat file.yaml | goyaml set key value --before missing
+				out, err := runCommand(orderedYAML, "set", "host", "localhost", "--before", "missing")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(HavePrefix("Error: Key 'missing'"))
+			})
+		})
 	})
 })
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/theochva/goyaml/internal/commands/cli"
+	"github.com/theochva/goyaml/pkg/yamldoc"
+)
+
+type _SortCommand struct {
+	cli.AppSubCommand
+
+	globalOpts      GlobalOptions
+	recursive       bool
+	by              string
+	changedExitCode int
+}
+
+func init() {
+	registerCommand(func(globalOpts GlobalOptions) cli.AppSubCommand {
+		subCmd := &_SortCommand{
+			globalOpts: globalOpts,
+		}
+
+		cliCmd := &cobra.Command{
+			Use:                   fmt.Sprintf("sort [<key>] [-%s|--%s] [--%s <key>]", _flagRecursiveShort, _flagRecursive, _flagBy),
+			DisableFlagsInUseLine: true,
+			Annotations: map[string]string{
+				_CmdOptMutating:  _CmdOptValueTrue,
+				_CmdOptKeepNodes: _CmdOptValueTrue,
+			},
+			Short: "Sort the keys of a map or the items of an array in the yaml",
+			Long: `Sort the keys of the map at the key (or of the whole yaml if no key is specified) or the items of
+the array at the key.  Numbers (including the unquoted number keys, e.g. '10: x') are sorted first by their
+value and anything else by its text.  The maps merged with '<<' stay first.
+
+Arrays of maps (e.g. lists of objects) are sorted by the value of the key specified with '--by'.  With
+'-r|--recursive', the maps within are sorted too, as well as the arrays of maps within when '--by' is specified.
+
+If reading from stdin, it outputs the updated YAML.  If reading from a file, the file is updated.`,
+			Args: func(cmd *cobra.Command, args []string) error {
+				if len(args) > 1 {
+					return fmt.Errorf("too many arguments")
+				}
+				return nil
+			},
+			ArgAliases: []string{"key"},
+			RunE:       subCmd.run,
+			Example: cli.ReplaceProgName(`  $PROG_NAME -f /tmp/foo.yaml sort
+  $PROG_NAME -f /tmp/foo.yaml sort first.second
+  $PROG_NAME -f /tmp/foo.yaml sort --recursive
+  $PROG_NAME -f /tmp/foo.yaml sort first.users --by name
+
+  cat /tmp/foo.yaml | $PROG_NAME sort -r`),
+		}
+
+		cliCmd.Flags().BoolVarP(
+			&subCmd.recursive,
+			_flagRecursive, _flagRecursiveShort, false,
+			"sort the maps within too (and the arrays of maps within, when '--by' is specified)",
+		)
+		cliCmd.Flags().StringVar(
+			&subCmd.by,
+			_flagBy, "",
+			"the key of the maps of the arrays to sort them by",
+		)
+		addChangedExitCodeFlag(cliCmd, &subCmd.changedExitCode)
+
+		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
+		return subCmd
+	})
+}
+
+func (c *_SortCommand) run(cmd *cobra.Command, args []string) (err error) {
+	var (
+		key      string
+		yamlFile = c.globalOpts.YamlFile()
+	)
+	if len(args) > 0 {
+		key = args[0]
+	}
+
+	if err = yamlFile.SortKeys(key, nil, yamldoc.SortOptions{Recursive: c.recursive, By: c.by}); err != nil {
+		return err
+	}
+
+	// If YAML read from stdin, then "Save" will output result
+	if yamlFile.IsDirty() {
+		return saveIfChanged(c.globalOpts, c.changedExitCode)
+	}
+	// Else, if not changed, then nothing printed, so dump the YAML
+	if c.globalOpts.IsPipe() {
+		err = yamlFile.Save()
+	}
+	return err
+}
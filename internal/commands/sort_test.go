@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/theochva/go-misc/pkg/osext"
+)
+
+// TestSortCommand - test suite for the sort command
+func TestSortCommand(t *testing.T) {
+	RegisterFailHandler(Fail)
+}
+
+var _ = Describe("Command 'sort' scenarios", func() {
+	var unsortedYAML = strings.TrimSpace(`
+name: service-a
+settings:
+  timeout: 10
+  retries: 3
+users:
+  - name: mary
+    id: 2
+  - name: bob
+    id: 10
+`)
+
+	It("prints out help", func() {
+		out, err := runCommand("", "sort", "--help")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(getHelpTextForCommand("sort")))
+	})
+	It("sorts the keys of a map", func() {
+		// cat file.yaml | goyaml sort key
+		out, err := runCommand(unsortedYAML, "sort", "settings")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(strings.Replace(unsortedYAML, "timeout: 10\n  retries: 3", "retries: 3\n  timeout: 10", 1)))
+	})
+	It("sorts an array of maps by the value of a key", func() {
+		// cat file.yaml | goyaml sort key --by name
+		out, err := runCommand(unsortedYAML, "sort", "users", "--by", "name")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HaveSuffix("users:\n  - name: bob\n    id: 10\n  - name: mary\n    id: 2"))
+	})
+	It("sorts the whole yaml recursively", func() {
+		// cat file.yaml | goyaml sort -r --by id
+		out, err := runCommand(unsortedYAML, "sort", "-r", "--by", "id")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(strings.TrimSpace(`
+name: service-a
+settings:
+  retries: 3
+  timeout: 10
+users:
+  - id: 2
+    name: mary
+  - id: 10
+    name: bob
+`)))
+	})
+	It("prints an error message for a value that cannot be sorted", func() {
+		// cat file.yaml | goyaml sort name
+		out, err := runCommand(unsortedYAML, "sort", "name")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error: key 'name' is not a map or an array"))
+	})
+	When("The yaml is in a file", func() {
+		var workDir, workFile string
+
+		BeforeEach(func() {
+			var err error
+
+			workDir, err = os.MkdirTemp("", "sort*")
+			Expect(err).ToNot(HaveOccurred())
+			workFile = filepath.Join(workDir, "test.yaml")
+			Expect(os.WriteFile(workFile, []byte(unsortedYAML), 0644)).To(Succeed())
+		})
+		AfterEach(func() {
+			os.RemoveAll(workDir)
+		})
+		It("sorts the keys in the file and exits with the 'changed' exit code", func() {
+			// goyaml -f file.yaml sort settings --changed-exit-code 3
+			out, err := runCommand("", "-f", workFile, "sort", "settings", "--changed-exit-code", "3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEmpty())
+			Expect(testApp.ExitCode()).To(Equal(3))
+
+			contents, err := osext.ReadFileAsString(workFile, true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(ContainSubstring("retries: 3\n  timeout: 10"))
+		})
+	})
+})
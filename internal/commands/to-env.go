@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/theochva/goyaml/internal/commands/cli"
+	"github.com/theochva/goyaml/pkg/yamldoc"
+	"github.com/theochva/goyaml/pkg/yamlfile"
+)
+
+// _EnvDefaults - the default options of the '.env' files, e.g. 'SERVERS__0__NAME=one'
+var _EnvDefaults = yamldoc.FlattenOptions{Separator: "__", KeyCase: yamldoc.KeyCaseUpperSnake}
+
+type _ToEnvCommand struct {
+	cli.AppSubCommand
+
+	globalOpts   GlobalOptions
+	flattenFlags _FlattenFlags
+	outputFile   string
+}
+
+func init() {
+	registerCommand(func(globalOpts GlobalOptions) cli.AppSubCommand {
+		subCmd := &_ToEnvCommand{
+			globalOpts: globalOpts,
+		}
+		cliCmd := &cobra.Command{
+			Use:                   "to-env [-o|--output <output-env-file>] [--prefix <prefix>] [--key-case <case>] [--separator <separator>] [--index-style <style>]",
+			DisableFlagsInUseLine: true,
+			Aliases:               []string{"te", "toenv", "env"},
+			Short:                 "Convert YAML to a dotenv file",
+			Args:                  cobra.NoArgs,
+			RunE:                  subCmd.run,
+			Long: `Convert a YAML document to a dotenv ('.env') file, with one variable for each value and the keys of
+the nested values joined with the separator, e.g. 'DB__HOST=localhost' and 'SERVERS__0__NAME=one'.
+
+The values are double quoted (and escaped, including '$') when needed.  Null values are written as
+empty values.  The keys must be valid environment variable names once transformed and must not
+contain the separator, e.g. 'apiKey' is written as 'API_KEY', which cannot be told apart from
+nested keys with the '_' separator.`,
+			Example: cli.ReplaceProgName(`  $PROG_NAME --file /tmp/foo.yaml to-env --output .env
+  $PROG_NAME --file /tmp/foo.yaml to-env -o .env --prefix APP_
+  $PROG_NAME --file /tmp/foo.yaml to-env --key-case as-is
+
+  cat /tmp/foo.yaml | $PROG_NAME to-env -o .env
+  cat /tmp/foo.yaml | $PROG_NAME to-env`),
+		}
+
+		addFlattenFlags(cliCmd, &subCmd.flattenFlags, _EnvDefaults)
+		cliCmd.Flags().StringVarP(
+			&subCmd.outputFile,
+			_flagOutput, _flagOutputShort, "",
+			"The file to write the variables to. If not specified, the output is printed to stdout",
+		)
+
+		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
+		return subCmd
+	})
+}
+
+func (c *_ToEnvCommand) run(cmd *cobra.Command, args []string) (err error) {
+	var opts yamldoc.FlattenOptions
+
+	if opts, err = c.flattenFlags.options(); err != nil {
+		return err
+	}
+	return writeEncoded(cmd, c.globalOpts, yamlfile.NewEnvCodec(opts), c.outputFile, "dotenv")
+}
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// TestToEnvCommand - test suite for the to-env command
+func TestToEnvCommand(t *testing.T) {
+	RegisterFailHandler(Fail)
+}
+
+var _ = Describe("Command 'to-env' scenarios", func() {
+	It("prints out help for the 'to-env' command", func() {
+		// goyaml to-env --help
+		out, err := runCommand("", "to-env", "--help")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(getHelpTextForCommand("to-env")))
+	})
+	It("converts YAML from stdin to variables and prints to stdout", func() {
+		// cat file.yaml | goyaml to-env
+		out, err := runCommand(_SampleYAML, "to-env")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("CALLING_BIRDS__0=huey\nCALLING_BIRDS__1=dewey\n"))
+		Expect(out).To(ContainSubstring("\nDOE=\"a deer, a female deer\"\n"))
+		Expect(out).To(HaveSuffix("\nXMAS_FIFTH_DAY__PARTRIDGES__LOCATION=\"a pear tree\"\nXMAS_FIFTH_DAY__TURTLE_DOVES=two"))
+	})
+	It("converts YAML with the prefix and the separator", func() {
+		// cat file.yaml | goyaml to-env --prefix APP_ --separator _
+		out, err := runCommand("db:\n  password: p@$$ \"w\"\n  port: 5432\n", "to-env", "--prefix", "APP_", "--separator", "_")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(strings.Join([]string{
+			`APP_DB_PASSWORD="p@\$\$ \"w\""`,
+			`APP_DB_PORT=5432`,
+		}, "\n")))
+	})
+	It("prints an error message for keys containing the separator", func() {
+		// cat file.yaml | goyaml to-env --separator _
+		out, err := runCommand("db:\n  apiKey: abc\n", "to-env", "--separator", "_")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error: Problem converting YAML to dotenv: key 'DB_API_KEY': cannot flatten keys containing the separator '_' or '['"))
+	})
+	It("prints an error message for keys that are not valid variable names", func() {
+		// cat file.yaml | goyaml to-env --key-case as-is
+		out, err := runCommand(_SampleYAML, "to-env", "--key-case", "as-is")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error: Problem converting YAML to dotenv: key 'calling-birds__0' is not a valid environment variable name"))
+	})
+})
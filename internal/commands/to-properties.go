@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/theochva/goyaml/internal/commands/cli"
+	"github.com/theochva/goyaml/pkg/yamldoc"
+	"github.com/theochva/goyaml/pkg/yamlfile"
+)
+
+// _PropertiesDefaults - the default options of the '.properties' files, e.g. 'servers[0].name=one'
+var _PropertiesDefaults = yamldoc.FlattenOptions{Separator: ".", Brackets: true}
+
+type _ToPropertiesCommand struct {
+	cli.AppSubCommand
+
+	globalOpts   GlobalOptions
+	flattenFlags _FlattenFlags
+	outputFile   string
+}
+
+func init() {
+	registerCommand(func(globalOpts GlobalOptions) cli.AppSubCommand {
+		subCmd := &_ToPropertiesCommand{
+			globalOpts: globalOpts,
+		}
+		cliCmd := &cobra.Command{
+			Use:                   "to-properties [-o|--output <output-properties-file>] [--prefix <prefix>] [--key-case <case>] [--separator <separator>] [--index-style <style>]",
+			DisableFlagsInUseLine: true,
+			Aliases:               []string{"tp", "toproperties", "properties"},
+			Short:                 "Convert YAML to Java properties",
+			Args:                  cobra.NoArgs,
+			RunE:                  subCmd.run,
+			Long: `Convert a YAML document to a Java '.properties' file, with one line for each value and the keys of
+the nested values joined with the separator, e.g. 'db.host=localhost' and 'servers[0].name=one'.
+
+The keys and values are escaped as Java expects, including the characters other than ASCII
+(e.g. 'é' as '\u00e9').  Null values are written as empty values.`,
+			Example: cli.ReplaceProgName(`  $PROG_NAME --file /tmp/foo.yaml to-properties --output foo.properties
+  $PROG_NAME --file /tmp/foo.yaml to-properties -o foo.properties --prefix app. --index-style key
+  $PROG_NAME --file /tmp/foo.yaml to-properties
+
+  cat /tmp/foo.yaml | $PROG_NAME to-properties -o foo.properties
+  cat /tmp/foo.yaml | $PROG_NAME to-properties`),
+		}
+
+		addFlattenFlags(cliCmd, &subCmd.flattenFlags, _PropertiesDefaults)
+		cliCmd.Flags().StringVarP(
+			&subCmd.outputFile,
+			_flagOutput, _flagOutputShort, "",
+			"The file to write the properties to. If not specified, the output is printed to stdout",
+		)
+
+		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
+		return subCmd
+	})
+}
+
+func (c *_ToPropertiesCommand) run(cmd *cobra.Command, args []string) (err error) {
+	var opts yamldoc.FlattenOptions
+
+	if opts, err = c.flattenFlags.options(); err != nil {
+		return err
+	}
+	return writeEncoded(cmd, c.globalOpts, yamlfile.NewPropertiesCodec(opts), c.outputFile, "properties")
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/theochva/go-misc/pkg/osext"
+)
+
+// TestToPropertiesCommand - test suite for the to-properties command
+func TestToPropertiesCommand(t *testing.T) {
+	RegisterFailHandler(Fail)
+}
+
+var _ = Describe("Command 'to-properties' scenarios", func() {
+	var sampleProperties = `calling-birds[0]=huey
+calling-birds[1]=dewey
+calling-birds[2]=louie
+calling-birds[3]=fred
+doe=a deer, a female deer
+french-hens=3
+pi=3.14159
+ray=a drop of golden sun
+xmas=true
+xmas-fifth-day.calling-birds=four
+xmas-fifth-day.french-hens=3
+xmas-fifth-day.golden-rings=5
+xmas-fifth-day.partridges.count=1
+xmas-fifth-day.partridges.location=a pear tree
+xmas-fifth-day.turtle-doves=two
+`
+
+	It("prints out help for the 'to-properties' command", func() {
+		// goyaml to-properties --help
+		out, err := runCommand("", "to-properties", "--help")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(getHelpTextForCommand("to-properties")))
+	})
+	It("converts YAML from stdin to properties and prints to stdout", func() {
+		// cat file.yaml | goyaml to-properties
+		out, err := runCommand(_SampleYAML, "to-properties")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out + "\n").To(Equal(sampleProperties))
+	})
+	It("converts YAML with the key transforms and the index style", func() {
+		// cat file.yaml | goyaml to-properties --prefix app. --key-case upper-snake --index-style key
+		out, err := runCommand("db:\n  apiKey: a=b\nservers: [one]\n", "to-properties",
+			"--prefix", "app.", "--key-case", "upper-snake", "--index-style", "key")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("app.DB.API_KEY=a=b\napp.SERVERS.0=one"))
+	})
+	It("prints an error message for an invalid key case", func() {
+		// cat file.yaml | goyaml to-properties --key-case lower
+		out, err := runCommand(_SampleYAML, "to-properties", "--key-case", "lower")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error: Invalid key case"))
+	})
+	When("Output target file specified", func() {
+		var outFile *os.File
+
+		BeforeEach(func() {
+			var err error
+			outFile, err = os.CreateTemp("", "testout*.properties")
+			Expect(err).ToNot(HaveOccurred())
+		})
+		AfterEach(func() {
+			if outFile != nil {
+				os.Remove(outFile.Name())
+			}
+		})
+		It("converts the YAML file to properties and writes it to the target file", func() {
+			// goyaml -f file.yaml to-properties -o out.properties
+			out, err := runCommand("", "-f", testYAMLFile.Name(), "to-properties", "-o", outFile.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEmpty())
+
+			contents, err := osext.ReadFileAsString(outFile.Name(), false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal(sampleProperties))
+		})
+	})
+})
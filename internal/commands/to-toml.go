@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/theochva/goyaml/internal/commands/cli"
+	"github.com/theochva/goyaml/pkg/yamlfile"
+)
+
+type _ToTOMLCommand struct {
+	cli.AppSubCommand
+
+	globalOpts GlobalOptions
+	datetimes  bool
+	outputFile string
+}
+
+func init() {
+	registerCommand(func(globalOpts GlobalOptions) cli.AppSubCommand {
+		subCmd := &_ToTOMLCommand{
+			globalOpts: globalOpts,
+		}
+		cliCmd := &cobra.Command{
+			Use:                   "to-toml [-o|--output <output-toml-file>] [--datetimes]",
+			DisableFlagsInUseLine: true,
+			Aliases:               []string{"tt", "totoml", "toml"},
+			Short:                 "Convert YAML to TOML",
+			Args:                  cobra.NoArgs,
+			RunE:                  subCmd.run,
+			Long: `Convert a YAML document to TOML. 
+
+Note:
+  TOML cannot represent null values or arrays mixing values of different types (e.g. strings
+  and numbers), so YAML documents containing them cannot be converted.  The YAML timestamps
+  (e.g. '1979-05-27T07:32:00Z', but not quoted strings such as '"1979-05-27"') are converted to
+  TOML strings, unless '--datetimes' is specified, in which case they are converted to TOML
+  datetimes, keeping their fractional seconds and offsets.`,
+			Example: cli.ReplaceProgName(`  $PROG_NAME --file /tmp/foo.yaml to-toml --output foo.toml
+  $PROG_NAME --file /tmp/foo.yaml to-toml -o foo.toml --datetimes
+  $PROG_NAME --file /tmp/foo.yaml to-toml
+
+  cat /tmp/foo.yaml | $PROG_NAME to-toml -o foo.toml
+  cat /tmp/foo.yaml | $PROG_NAME to-toml`),
+		}
+
+		cliCmd.Flags().BoolVar(
+			&subCmd.datetimes,
+			_flagDatetimes, false,
+			"convert the YAML timestamps to TOML datetimes instead of strings",
+		)
+		cliCmd.Flags().StringVarP(
+			&subCmd.outputFile,
+			_flagOutput, _flagOutputShort, "",
+			"The file to write the TOML output to. If not specified, the output is printed to stdout",
+		)
+
+		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
+		return subCmd
+	})
+}
+
+func (c *_ToTOMLCommand) run(cmd *cobra.Command, args []string) (err error) {
+	if mapData := c.globalOpts.YamlFile().Data(); mapData != nil {
+		var bytes []byte
+
+		if bytes, err = yamlfile.NewTOMLCodec(yamlfile.TOMLOptions{Datetimes: c.datetimes}).Encode(mapData); err != nil {
+			return errors.Wrap(err, "Problem converting YAML to TOML")
+		}
+
+		if c.outputFile != "" {
+			if err = os.WriteFile(c.outputFile, bytes, 0644); err != nil {
+				return errors.Wrapf(err, "Problem writing to '%s'", c.outputFile)
+			}
+		} else {
+			cmd.Print(string(bytes))
+		}
+		return
+	}
+
+	if c.globalOpts.IsPipe() {
+		return fmt.Errorf("unable to convert YAML from stdin to TOML")
+	}
+	return fmt.Errorf("unable to convert YAML file '%s' to TOML", c.globalOpts.YamlFile().Filename())
+}
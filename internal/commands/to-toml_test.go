@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/theochva/go-misc/pkg/osext"
+)
+
+// TestToTOMLCommand - test suite for the to-toml command
+func TestToTOMLCommand(t *testing.T) {
+	RegisterFailHandler(Fail)
+}
+
+var _ = Describe("Command 'to-toml' scenarios", func() {
+	var sampleTOML = `calling-birds = ["huey", "dewey", "louie", "fred"]
+doe = "a deer, a female deer"
+french-hens = 3
+pi = 3.14159
+ray = "a drop of golden sun"
+xmas = true
+
+[xmas-fifth-day]
+  calling-birds = "four"
+  french-hens = 3
+  golden-rings = 5
+  turtle-doves = "two"
+  [xmas-fifth-day.partridges]
+    count = 1
+    location = "a pear tree"
+`
+
+	It("prints out help for the 'to-toml' command", func() {
+		// goyaml to-toml --help
+		out, err := runCommand("", "to-toml", "--help")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(getHelpTextForCommand("to-toml")))
+	})
+	It("converts YAML from stdin to TOML and prints to stdout", func() {
+		// cat file.yaml | goyaml to-toml
+		out, err := runCommand(_SampleYAML, "to-toml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out + "\n").To(Equal(sampleTOML))
+	})
+	It("converts the YAML timestamps to TOML datetimes with '--datetimes'", func() {
+		// cat file.yaml | goyaml to-toml --datetimes
+		out, err := runCommand("created: 1979-05-27T07:32:00Z\n", "to-toml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(`created = "1979-05-27T07:32:00Z"`))
+
+		testApp = createTestApp()
+		out, err = runCommand("created: 1979-05-27T07:32:00Z\n", "to-toml", "--datetimes")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("created = 1979-05-27T07:32:00Z"))
+	})
+	It("converts only the unquoted YAML timestamps with '--datetimes', keeping their offsets", func() {
+		// cat file.yaml | goyaml to-toml --datetimes
+		out, err := runCommand("s: \"2020-01-02\"\nd: 2001-12-14t21:59:43.10-05:00\n", "to-toml", "--datetimes")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("d = 2001-12-14T21:59:43.1-05:00\ns = \"2020-01-02\""))
+	})
+	It("prints an error message for YAML that TOML cannot represent", func() {
+		// cat file.yaml | goyaml to-toml
+		out, err := runCommand("a:\n  b: null\n", "to-toml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error: Problem converting YAML to TOML: key 'a.b': TOML cannot represent null values"))
+
+		testApp = createTestApp()
+		out, err = runCommand("a: [one, 2]\n", "to-toml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error: Problem converting YAML to TOML: key 'a': TOML cannot represent arrays mixing string and integer values"))
+	})
+	When("Output target file specified", func() {
+		var outFile *os.File
+
+		BeforeEach(func() {
+			var err error
+			outFile, err = os.CreateTemp("", "testout*.toml")
+			Expect(err).ToNot(HaveOccurred())
+		})
+		AfterEach(func() {
+			if outFile != nil {
+				os.Remove(outFile.Name())
+			}
+		})
+		It("converts the YAML file to TOML and writes it to the target file", func() {
+			// goyaml -f file.yaml to-toml -o out.toml
+			out, err := runCommand("", "-f", testYAMLFile.Name(), "to-toml", "-o", outFile.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEmpty())
+
+			contents, err := osext.ReadFileAsString(outFile.Name(), false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal(sampleTOML))
+		})
+	})
+})
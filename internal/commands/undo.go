@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/theochva/goyaml/internal/commands/cli"
+	"github.com/theochva/goyaml/pkg/yamlfile"
+)
+
+type _UndoCommand struct {
+	cli.AppSubCommand
+
+	globalOpts      GlobalOptions
+	changedExitCode int
+}
+
+func init() {
+	registerCommand(func(globalOpts GlobalOptions) cli.AppSubCommand {
+		subCmd := &_UndoCommand{
+			globalOpts: globalOpts,
+		}
+
+		cliCmd := &cobra.Command{
+			Use:                   "undo",
+			DisableFlagsInUseLine: true,
+			Annotations: map[string]string{
+				_CmdOptSkipParsing: _CmdOptValueTrue,
+				_CmdOptMutating:    _CmdOptValueTrue,
+			},
+			Short: "Undo the last change made to the yaml file",
+			Long: fmt.Sprintf(`Undo the last change made to the yaml file, by restoring its previous version from the
+history.  The history is kept in the '%s' directory next to the file, by the commands
+modifying the file with the '--%s' flag.  Undoing again restores the version before it.`, yamlfile.HistoryDir, _flagHistory),
+			Args: cobra.NoArgs,
+			RunE: subCmd.run,
+			Example: cli.ReplaceProgName(`  $PROG_NAME -f /tmp/foo.yaml --history set first.second.third "someValue"
+  $PROG_NAME -f /tmp/foo.yaml undo`),
+		}
+
+		addChangedExitCodeFlag(cliCmd, &subCmd.changedExitCode)
+
+		subCmd.AppSubCommand = cli.NewAppSubCommandBase(cliCmd)
+		return subCmd
+	})
+}
+
+func (c *_UndoCommand) run(cmd *cobra.Command, args []string) (err error) {
+	if c.globalOpts.IsPipe() {
+		return fmt.Errorf("the yaml file to undo must be specified with the '-%s|--%s' flag", _flagFileShort, _flagFile)
+	}
+
+	filename := c.globalOpts.YamlFile().Filename()
+	if err = yamlfile.Undo(filename); err != nil {
+		return errors.Wrapf(err, "File '%s'", filename)
+	}
+	c.globalOpts.SetExitCode(c.changedExitCode)
+
+	cmd.Println(true)
+	return nil
+}
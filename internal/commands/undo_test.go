@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/theochva/go-misc/pkg/osext"
+	"github.com/theochva/goyaml/pkg/yamlfile"
+)
+
+// TestUndoCommand - test suite for the undo command
+func TestUndoCommand(t *testing.T) {
+	RegisterFailHandler(Fail)
+}
+
+var _ = Describe("Command 'undo' scenarios", func() {
+	var (
+		workDir  string
+		workFile string
+	)
+	BeforeEach(func() {
+		var err error
+
+		workDir, err = os.MkdirTemp("", "undo*")
+		Expect(err).ToNot(HaveOccurred())
+		workFile = filepath.Join(workDir, "test.yaml")
+		Expect(os.WriteFile(workFile, []byte(_SampleYAML), 0644)).To(Succeed())
+	})
+	AfterEach(func() {
+		os.RemoveAll(workDir)
+	})
+	It("Restores the versions kept in the history", func() {
+		// goyaml -f file.yaml --history set key value
+		out, err := runCommand("", "-f", workFile, "--history", "set", _SampleYAMLExistingKey, "first")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("true"))
+
+		testApp = createTestApp()
+		out, err = runCommand("", "-f", workFile, "--history=5", "set", _SampleYAMLExistingKey, "second")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("true"))
+
+		// goyaml -f file.yaml undo
+		testApp = createTestApp()
+		out, err = runCommand("", "-f", workFile, "undo", "--changed-exit-code", "3")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("true"))
+		Expect(testApp.ExitCode()).To(Equal(3))
+
+		testApp = createTestApp()
+		out, err = runCommand("", "-f", workFile, "get", _SampleYAMLExistingKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("first"))
+
+		testApp = createTestApp()
+		out, err = runCommand("", "-f", workFile, "undo")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("true"))
+
+		contents, err := osext.ReadFileAsString(workFile, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contents).To(Equal(_SampleYAML))
+
+		// Nothing left to undo
+		testApp = createTestApp()
+		out, err = runCommand("", "-f", workFile, "undo")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error:"))
+		Expect(out).To(ContainSubstring(yamlfile.ErrNoHistory.Error()))
+	})
+	It("Keeps a backup file", func() {
+		// goyaml -f file.yaml --backup set key value
+		out, err := runCommand("", "-f", workFile, "--backup", "set", _SampleYAMLExistingKey, "changed")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("true"))
+
+		contents, err := osext.ReadFileAsString(workFile+yamlfile.DefaultBackupSuffix, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contents).To(Equal(_SampleYAML))
+
+		// goyaml -f file.yaml --backup=.orig delete key
+		testApp = createTestApp()
+		out, err = runCommand("", "-f", workFile, "--backup=.orig", "delete", _SampleYAMLExistingKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("true"))
+		Expect(osext.FileExists(workFile + ".orig")).To(BeTrue())
+	})
+	It("Prints an error message when reading from stdin", func() {
+		out, err := runCommand(_SampleYAML, "undo")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error:"))
+	})
+	It("Prints an error message when both backup flags are specified", func() {
+		out, err := runCommand("", "-f", workFile, "--backup", "--history", "set", _SampleYAMLExistingKey, "changed")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("Error:"))
+	})
+})
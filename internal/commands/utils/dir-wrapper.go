@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/theochva/go-misc/pkg/osext"
+	"github.com/theochva/goyaml/pkg/yamldoc"
+	"github.com/theochva/goyaml/pkg/yamlfile"
+)
+
+// DirYamlFileWrapper - simple wrapper that reads the fragment files in a directory (e.g. conf.d)
+// merged together as one YAML file and saves the values back to the fragments they came from
+type DirYamlFileWrapper struct {
+	yamlfile.YamlFile
+	dir  string
+	opts []yamlfile.Option
+	doc  yamlfile.DirDoc
+}
+
+// NewDirYamlFileWrapper - create new directory YamlFile wrapper.  The options are used for the fragments.
+func NewDirYamlFileWrapper(dir string, opts ...yamlfile.Option) yamlfile.YamlFile {
+	return &DirYamlFileWrapper{
+		YamlFile: yamlfile.New(dir, opts...),
+		dir:      dir,
+		opts:     opts,
+	}
+}
+
+// Exists - override
+func (y *DirYamlFileWrapper) Exists() bool {
+	return osext.IsDirectory(y.dir)
+}
+
+// Filename - override
+func (y *DirYamlFileWrapper) Filename() string {
+	return y.dir
+}
+
+// Origin - get the fragment file that supplied the value at key
+func (y *DirYamlFileWrapper) Origin(key string) string {
+	if y.doc == nil {
+		return ""
+	}
+	return y.doc.Owner(key)
+}
+
+// Load - override
+func (y *DirYamlFileWrapper) Load() (loaded bool, err error) {
+	if y.doc, err = yamlfile.LoadDir(y.dir, "", y.opts...); err != nil {
+		return false, err
+	}
+	y.YamlFile.SetData(y.doc.Data())
+	y.YamlFile.MarkClean()
+
+	return len(y.doc.Fragments()) > 0, nil
+}
+
+// LoadReader - override
+func (y *DirYamlFileWrapper) LoadReader(reader io.Reader) (loaded bool, err error) {
+	return false, errors.New("Cannot read a directory of yaml files from a reader")
+}
+
+// Save - override
+func (y *DirYamlFileWrapper) Save() (err error) {
+	return y.save(func() error { return y.doc.Save() })
+}
+
+// SaveForce - override
+func (y *DirYamlFileWrapper) SaveForce() (err error) {
+	return y.Save()
+}
+
+// SaveWithBackup - override.  Each fragment is backed up before it is saved.
+func (y *DirYamlFileWrapper) SaveWithBackup(backup yamlfile.Backup) (err error) {
+	return y.save(func() error { return y.doc.SaveWithBackup(backup) })
+}
+
+// SaveIfChanged - override
+func (y *DirYamlFileWrapper) SaveIfChanged() (saved bool, err error) {
+	if !y.IsDirty() {
+		return false, nil
+	}
+	if err = y.Save(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Reload - override
+func (y *DirYamlFileWrapper) Reload() (loaded bool, err error) {
+	return y.Load()
+}
+
+// ReloadAndApply - override
+func (y *DirYamlFileWrapper) ReloadAndApply(changes func(doc yamldoc.YamlDoc) error) (err error) {
+	if _, err = y.Load(); err != nil {
+		return err
+	}
+	if err = changes(y); err != nil {
+		return err
+	}
+	return y.Save()
+}
+
+// save - save the values to the fragments with saveDoc
+func (y *DirYamlFileWrapper) save(saveDoc func() error) (err error) {
+	if y.doc == nil {
+		if _, err = y.Load(); err != nil {
+			return err
+		}
+	}
+	y.doc.SetData(y.YamlFile.Data())
+	if err = saveDoc(); err != nil {
+		return err
+	}
+	y.YamlFile.MarkClean()
+	return nil
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+const (
+	// EncodingNone - the contents are not encoded
+	EncodingNone = ""
+	// EncodingBase64 - the contents are base64 encoded
+	EncodingBase64 = "base64"
+)
+
+// ValidEncodings - the encodings supported for stdin/stdout
+var ValidEncodings = []string{EncodingBase64}
+
+// NewDecodingReader - create a reader decoding the contents read from the reader
+func NewDecodingReader(encoding string, reader io.Reader) io.Reader {
+	switch encoding {
+	case EncodingBase64:
+		return base64.NewDecoder(base64.StdEncoding, reader)
+	}
+	return reader
+}
+
+// NewEncodingWriter - create a writer encoding the contents written to the writer.  The
+// encoded contents are only complete once the writer is closed, which does not close the
+// underlying writer.
+func NewEncodingWriter(encoding string, writer io.Writer) io.WriteCloser {
+	switch encoding {
+	case EncodingBase64:
+		return &base64Writer{
+			WriteCloser: base64.NewEncoder(base64.StdEncoding, writer),
+			writer:      writer,
+		}
+	}
+	return nopWriteCloser{writer}
+}
+
+type base64Writer struct {
+	io.WriteCloser
+	writer  io.Writer
+	written bool
+}
+
+func (w *base64Writer) Write(p []byte) (int, error) {
+	w.written = true
+	return w.WriteCloser.Write(p)
+}
+
+// Close - flush the encoded contents and end them with a new line
+func (w *base64Writer) Close() error {
+	if err := w.WriteCloser.Close(); err != nil {
+		return err
+	}
+	if w.written {
+		_, err := fmt.Fprintln(w.writer)
+		return err
+	}
+	return nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
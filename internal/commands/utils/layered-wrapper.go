@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/theochva/go-misc/pkg/osext"
+	"github.com/theochva/goyaml/pkg/yamldoc"
+	"github.com/theochva/goyaml/pkg/yamlfile"
+)
+
+// errLayeredReadOnly - error when trying to save/reload multiple layered files
+var errLayeredReadOnly = errors.New("Cannot save multiple yaml files merged together")
+
+// LayeredYamlFileWrapper - simple wrapper that reads several yaml files merged
+// together as one (read-only) YAML file
+type LayeredYamlFileWrapper struct {
+	yamlfile.YamlFile
+	filenames []string
+	opts      []yamlfile.Option
+	layers    yamlfile.LayeredDoc
+}
+
+// NewLayeredYamlFileWrapper - create new layered YamlFile wrapper
+func NewLayeredYamlFileWrapper(filenames []string, opts ...yamlfile.Option) yamlfile.YamlFile {
+	return &LayeredYamlFileWrapper{
+		YamlFile:  yamlfile.New(filenames[len(filenames)-1], opts...),
+		filenames: filenames,
+		opts:      opts,
+	}
+}
+
+// Exists - override. It exists if any of the files exists
+func (y *LayeredYamlFileWrapper) Exists() bool {
+	for _, filename := range y.filenames {
+		if osext.FileExists(filename) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filename - override
+func (y *LayeredYamlFileWrapper) Filename() string {
+	return strings.Join(y.filenames, ", ")
+}
+
+// Origin - get the file that supplied the value at key
+func (y *LayeredYamlFileWrapper) Origin(key string) string {
+	if y.layers == nil {
+		return ""
+	}
+	return y.layers.Origin(key)
+}
+
+// Load - override
+func (y *LayeredYamlFileWrapper) Load() (loaded bool, err error) {
+	if y.layers, err = yamlfile.LoadLayers(y.filenames, y.opts...); err != nil {
+		return false, err
+	}
+	y.YamlFile.SetData(y.layers.Data())
+	y.YamlFile.MarkClean()
+
+	return len(y.layers.Layers()) > 0, nil
+}
+
+// Save - override
+func (y *LayeredYamlFileWrapper) Save() (err error) {
+	return errLayeredReadOnly
+}
+
+// SaveForce - override
+func (y *LayeredYamlFileWrapper) SaveForce() (err error) {
+	return errLayeredReadOnly
+}
+
+// SaveWithBackup - override
+func (y *LayeredYamlFileWrapper) SaveWithBackup(backup yamlfile.Backup) (err error) {
+	return errLayeredReadOnly
+}
+
+// SaveIfChanged - override
+func (y *LayeredYamlFileWrapper) SaveIfChanged() (saved bool, err error) {
+	return false, errLayeredReadOnly
+}
+
+// Reload - override
+func (y *LayeredYamlFileWrapper) Reload() (loaded bool, err error) {
+	return y.Load()
+}
+
+// ReloadAndApply - override
+func (y *LayeredYamlFileWrapper) ReloadAndApply(changes func(doc yamldoc.YamlDoc) error) (err error) {
+	return errLayeredReadOnly
+}
@@ -18,8 +18,8 @@ type YamlFileWrapper struct {
 	stdout   io.Writer
 }
 
-// NewYamlFileWrapper - create new YamlFile wrapper
-func NewYamlFileWrapper(filename string, stdin io.Reader, stdout io.Writer) yamlfile.YamlFile {
+// NewYamlFileWrapper - create new YamlFile wrapper.  The options are used for the file (if any).
+func NewYamlFileWrapper(filename string, stdin io.Reader, stdout io.Writer, opts ...yamlfile.Option) yamlfile.YamlFile {
 	var wrapperFilename = filename
 
 	if filename == "" {
@@ -27,7 +27,7 @@ func NewYamlFileWrapper(filename string, stdin io.Reader, stdout io.Writer) yaml
 	}
 	return &YamlFileWrapper{
 		pipeMode: (filename == ""),
-		YamlFile: yamlfile.New(wrapperFilename),
+		YamlFile: yamlfile.New(wrapperFilename, opts...),
 		stdin:    stdin,
 		stdout:   stdout,
 	}
@@ -52,13 +52,10 @@ func (y *YamlFileWrapper) Filename() string {
 // Load - override
 func (y *YamlFileWrapper) Load() (loaded bool, err error) {
 	if y.pipeMode {
-		var yaml yamldoc.YamlDoc
-
-		if yaml, err = yamldoc.New(y.stdin); err != nil {
+		if _, err = y.YamlFile.LoadReader(y.stdin); err != nil {
 			err = errors.Wrap(err, "Failed to read/parse yaml from stdin")
 			return
 		}
-		y.YamlFile.SetData(yaml.Data())
 	}
 	return y.YamlFile.Load()
 }
@@ -66,13 +63,10 @@ func (y *YamlFileWrapper) Load() (loaded bool, err error) {
 // LoadReader - override
 func (y *YamlFileWrapper) LoadReader(reader io.Reader) (loaded bool, err error) {
 	if y.pipeMode {
-		var yaml yamldoc.YamlDoc
-
-		if yaml, err = yamldoc.New(reader); err != nil {
+		if _, err = y.YamlFile.LoadReader(reader); err != nil {
 			err = errors.Wrap(err, "Failed to read/parse yaml from stdin")
 			return
 		}
-		y.YamlFile.SetData(yaml.Data())
 	}
 	return y.YamlFile.Load()
 }
@@ -89,3 +83,49 @@ func (y *YamlFileWrapper) Save() (err error) {
 	}
 	return y.YamlFile.Save()
 }
+
+// SaveForce - override
+func (y *YamlFileWrapper) SaveForce() (err error) {
+	if y.pipeMode {
+		return y.Save()
+	}
+	return y.YamlFile.SaveForce()
+}
+
+// SaveWithBackup - override
+func (y *YamlFileWrapper) SaveWithBackup(backup yamlfile.Backup) (err error) {
+	if y.pipeMode {
+		return y.Save()
+	}
+	return y.YamlFile.SaveWithBackup(backup)
+}
+
+// SaveIfChanged - override.  The yaml read from stdin is always written to stdout, even if
+// it was not changed, so that it is passed on in pipes.
+func (y *YamlFileWrapper) SaveIfChanged() (saved bool, err error) {
+	if y.pipeMode {
+		saved = y.IsDirty()
+		if err = y.Save(); err != nil {
+			return false, err
+		}
+		y.MarkClean()
+		return saved, nil
+	}
+	return y.YamlFile.SaveIfChanged()
+}
+
+// Reload - override
+func (y *YamlFileWrapper) Reload() (loaded bool, err error) {
+	if y.pipeMode {
+		return false, errors.New("Cannot reload yaml read from stdin")
+	}
+	return y.YamlFile.Reload()
+}
+
+// ReloadAndApply - override
+func (y *YamlFileWrapper) ReloadAndApply(changes func(doc yamldoc.YamlDoc) error) (err error) {
+	if y.pipeMode {
+		return errors.New("Cannot reload yaml read from stdin")
+	}
+	return y.YamlFile.ReloadAndApply(changes)
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+
 	"github.com/theochva/goyaml/internal/commands"
 )
 
@@ -11,5 +13,7 @@ var (
 )
 
 func main() {
-	commands.NewGoyamlApp(version, commit, date).Execute()
+	app := commands.NewGoyamlApp(version, commit, date)
+	app.Execute()
+	os.Exit(app.ExitCode())
 }
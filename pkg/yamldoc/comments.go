@@ -0,0 +1,157 @@
+package yamldoc
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrNodesNotKept - error generated when reading or writing the comments of yaml whose nodes
+	// are not kept (see KeepNodes)
+	ErrNodesNotKept = errors.New("The nodes of the yaml are not kept, so its comments are not available")
+	// ErrKeyNotFound - error generated when the key is not in the yaml
+	ErrKeyNotFound = errors.New("The key was not found in the yaml")
+)
+
+// Comment - the comments of a key in the yaml.  The comments are without their "#" markers and
+// comments of several lines are separated by "\n".
+type Comment struct {
+	// Head - the comment on the lines before the key
+	Head string
+	// Line - the comment at the end of the line of the key
+	Line string
+	// Foot - the comment on the lines after the value of the key
+	Foot string
+}
+
+// GetComment - get the comments of the key (or of the whole document when the key is empty).
+// The nodes of the yaml must be kept (see KeepNodes), otherwise ErrNodesNotKept is returned.
+func (y *yamlDoc) GetComment(key string) (comment Comment, err error) {
+	if y.doc == nil {
+		return comment, ErrNodesNotKept
+	}
+	if key == "" {
+		return Comment{
+			Head: fromComment(y.doc.HeadComment),
+			Line: fromComment(y.doc.LineComment),
+			Foot: fromComment(y.doc.FootComment),
+		}, nil
+	}
+
+	keyNode, valueNode := findKeyNodes(y.rootMapping(), strings.Split(key, "."))
+	if keyNode == nil {
+		return comment, ErrKeyNotFound
+	}
+	comment = Comment{
+		Head: fromComment(keyNode.HeadComment),
+		Line: fromComment(keyNode.LineComment),
+		Foot: fromComment(keyNode.FootComment),
+	}
+	// The comment at the end of the line is with the value, unless the value is on the lines below
+	if valueNode.LineComment != "" {
+		comment.Line = fromComment(valueNode.LineComment)
+	}
+	return comment, nil
+}
+
+// SetComment - set the comments of the key (or of the whole document when the key is empty, e.g.
+// for a "managed by" banner), replacing any comments it already has.  Empty comments remove
+// the existing ones.  The comments of a key merged from another map (with "<<") are set in
+// that map.  The nodes of the yaml must be kept (see KeepNodes), otherwise ErrNodesNotKept is
+// returned.
+func (y *yamlDoc) SetComment(key, head, line, foot string) error {
+	if y.doc == nil {
+		return ErrNodesNotKept
+	}
+	if key == "" {
+		y.setComment(&y.doc.HeadComment, head)
+		y.setComment(&y.doc.LineComment, line)
+		y.setComment(&y.doc.FootComment, foot)
+		return nil
+	}
+
+	keyNode, valueNode := findKeyNodes(y.rootMapping(), strings.Split(key, "."))
+	if keyNode == nil {
+		return ErrKeyNotFound
+	}
+	y.setComment(&keyNode.HeadComment, head)
+	y.setComment(&keyNode.FootComment, foot)
+	// Only scalar values are on the line of the key
+	if valueNode.Kind == yaml.ScalarNode || valueNode.Kind == yaml.AliasNode {
+		y.setComment(&keyNode.LineComment, "")
+		y.setComment(&valueNode.LineComment, line)
+	} else {
+		y.setComment(&keyNode.LineComment, line)
+		y.setComment(&valueNode.LineComment, "")
+	}
+	return nil
+}
+
+// setComment - set a comment of a node, tracking whether it changed (see IsDirty)
+func (y *yamlDoc) setComment(comment *string, text string) {
+	if text = toComment(text); *comment != text {
+		*comment = text
+		y.nodesChanged = true
+	}
+}
+
+// findKeyNodes - get the key and value nodes of the key path, looking up the keys merged from
+// other maps too
+func findKeyNodes(root *yaml.Node, keys []string) (keyNode, valueNode *yaml.Node) {
+	mapping := root
+	for index, key := range keys {
+		if mapping = resolveAlias(mapping); mapping.Kind != yaml.MappingNode {
+			return nil, nil
+		}
+		keyNode, valueNode = findKeyNode(mapping, key)
+		if keyNode == nil {
+			return nil, nil
+		}
+		if index < len(keys)-1 {
+			mapping = valueNode
+		}
+	}
+	return keyNode, valueNode
+}
+
+// findKeyNode - get the key and value nodes of a key in a map (see findKey)
+func findKeyNode(mapping *yaml.Node, key string) (keyNode, valueNode *yaml.Node) {
+	if index := ownKeyIndex(mapping, key); index >= 0 {
+		return mapping.Content[index], mapping.Content[index+1]
+	}
+	for _, source := range mergedMappings(mapping) {
+		if keyNode, valueNode = findKeyNode(source, key); keyNode != nil {
+			return keyNode, valueNode
+		}
+	}
+	return nil, nil
+}
+
+// toComment - add the "#" markers to the lines of a comment
+func toComment(text string) string {
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	for index, line := range lines {
+		if !strings.HasPrefix(line, "#") {
+			lines[index] = strings.TrimRight("# "+line, " ")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fromComment - remove the "#" markers from the lines of a comment
+func fromComment(comment string) string {
+	if comment == "" {
+		return ""
+	}
+	lines := strings.Split(comment, "\n")
+	for index, line := range lines {
+		line = strings.TrimPrefix(strings.TrimSpace(line), "#")
+		lines[index] = strings.TrimPrefix(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
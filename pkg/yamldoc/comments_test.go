@@ -0,0 +1,86 @@
+package yamldoc
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Yaml comments", func() {
+	const commentedYaml = `
+# The settings of the service
+
+# The name of the service
+name: service-a # unique
+settings:
+  # In seconds
+  timeout: 10
+`
+	var (
+		yaml     YamlDoc
+		yamlText = strings.TrimSpace(commentedYaml)
+	)
+
+	BeforeEach(func() {
+		var err error
+		yaml, err = FromString(yamlText, KeepNodes())
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("gets the comments of the keys and of the document", func() {
+		Expect(yaml.GetComment("name")).To(Equal(Comment{Head: "The name of the service", Line: "unique"}))
+		Expect(yaml.GetComment("settings.timeout")).To(Equal(Comment{Head: "In seconds"}))
+		Expect(yaml.GetComment("settings")).To(Equal(Comment{}))
+		Expect(yaml.GetComment("")).To(Equal(Comment{Head: "The settings of the service"}))
+	})
+	It("sets the comments of the keys", func() {
+		Expect(yaml.SetComment("name", "", "", "")).To(Succeed())
+		Expect(yaml.SetComment("settings", "The settings", "of the service", "")).To(Succeed())
+		Expect(yaml.SetComment("settings.timeout", "In seconds\nor minutes", "timeout", "end")).To(Succeed())
+		Expect(yaml.IsDirty()).To(BeTrue())
+		checkText(yaml, `# The settings of the service
+
+name: service-a
+# The settings
+settings: # of the service
+  # In seconds
+  # or minutes
+  timeout: 10 # timeout
+  # end`)
+		Expect(yaml.GetComment("settings.timeout")).To(Equal(Comment{Head: "In seconds\nor minutes", Line: "timeout", Foot: "end"}))
+	})
+	It("sets the comments of the document", func() {
+		yaml, err := FromString("a: 1", KeepNodes())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(yaml.SetComment("", "managed by goyaml; do not edit", "", "")).To(Succeed())
+		checkText(yaml, "# managed by goyaml; do not edit\n\na: 1")
+	})
+	It("keeps the comments when setting values", func() {
+		checkSetValue(yaml, "settings.timeout", 20)
+		checkText(yaml, strings.Replace(yamlText, "10", "20", 1))
+	})
+	It("does not change the yaml when setting the same comments", func() {
+		Expect(yaml.SetComment("name", "The name of the service", "unique", "")).To(Succeed())
+		Expect(yaml.IsDirty()).To(BeFalse())
+	})
+	It("rolls back the comments with the snapshot", func() {
+		snapshot := yaml.Snapshot()
+		Expect(yaml.SetComment("name", "changed", "", "")).To(Succeed())
+		Expect(yaml.Restore(snapshot)).To(Succeed())
+		Expect(yaml.IsDirty()).To(BeFalse())
+		checkText(yaml, yamlText)
+	})
+	It("fails for keys that are not in the yaml", func() {
+		_, err := yaml.GetComment("settings.retries")
+		Expect(err).To(Equal(ErrKeyNotFound))
+		Expect(yaml.SetComment("name.first", "", "", "")).To(Equal(ErrKeyNotFound))
+	})
+	It("fails when the nodes are not kept", func() {
+		yaml, err := FromString(yamlText)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = yaml.GetComment("name")
+		Expect(err).To(Equal(ErrNodesNotKept))
+		Expect(yaml.SetComment("name", "", "", "")).To(Equal(ErrNodesNotKept))
+	})
+})
@@ -0,0 +1,29 @@
+package yamldoc
+
+import (
+	"reflect"
+)
+
+// IsDirty - check if the yaml was changed since it was loaded (or marked as clean).  Values
+// that were changed and then changed back, or that were set to the value they already had,
+// do not make the yaml dirty.
+func (y *yamlDoc) IsDirty() bool {
+	return y.nodesChanged || !reflect.DeepEqual(y.baseline, y.data)
+}
+
+// ChangedPaths - get the key paths (in "dot" notation) of the values that were added, updated
+// or deleted since the yaml was loaded (or marked as clean).  As with OnChange, maps are
+// compared key by key, so the paths are those of the values within them.
+func (y *yamlDoc) ChangedPaths() []string {
+	paths := []string{}
+	for _, ev := range diffValues("", y.baseline, true, y.data, true, nil) {
+		paths = append(paths, ev.Path)
+	}
+	return paths
+}
+
+// MarkClean - mark the current state of the yaml as unchanged, e.g. once it is saved
+func (y *yamlDoc) MarkClean() {
+	y.baseline = deepCopy(y.data).(map[string]interface{})
+	y.nodesChanged = false
+}
@@ -0,0 +1,60 @@
+package yamldoc
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Yaml dirty tracking functions", func() {
+	var (
+		yaml     YamlDoc
+		yamlText = strings.TrimSpace(_SampleYaml)
+	)
+
+	BeforeEach(func() {
+		var err error
+		yaml, err = FromString(yamlText)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(yaml).ToNot(BeNil())
+	})
+
+	It("is clean when loaded", func() {
+		Expect(yaml.IsDirty()).To(BeFalse())
+		Expect(yaml.ChangedPaths()).To(BeEmpty())
+	})
+	It("is clean when a value is set to the value it already has", func() {
+		checkSetValue(yaml, "a.b.c", "value-c")
+		Expect(yaml.IsDirty()).To(BeFalse())
+		Expect(yaml.ChangedPaths()).To(BeEmpty())
+	})
+	It("tracks the paths of the changed values", func() {
+		checkSetValue(yaml, "a.b.c", "changed")
+		checkSetValue(yaml, "g", "value-g")
+		checkDeleteValue(yaml, "a.d", true)
+
+		Expect(yaml.IsDirty()).To(BeTrue())
+		Expect(yaml.ChangedPaths()).To(Equal([]string{"a.b.c", "a.d.e", "a.d.f", "g"}))
+	})
+	It("is clean when the changes are reverted", func() {
+		snapshot := yaml.Snapshot()
+		checkSetValue(yaml, "a.b.c", "changed")
+		Expect(yaml.IsDirty()).To(BeTrue())
+
+		Expect(yaml.Restore(snapshot)).To(Succeed())
+		Expect(yaml.IsDirty()).To(BeFalse())
+	})
+	It("is clean once marked as clean", func() {
+		checkSetValue(yaml, "a.b.c", "changed")
+		yaml.MarkClean()
+		Expect(yaml.IsDirty()).To(BeFalse())
+
+		checkSetValue(yaml, "a.b.c", "value-c")
+		Expect(yaml.ChangedPaths()).To(Equal([]string{"a.b.c"}))
+	})
+	It("tracks changes made directly to the data", func() {
+		yaml.Data()["g"] = "value-g"
+		Expect(yaml.ChangedPaths()).To(Equal([]string{"g"}))
+	})
+})
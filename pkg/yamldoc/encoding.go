@@ -0,0 +1,178 @@
+package yamldoc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Charset - the Unicode encoding of yaml contents
+type Charset string
+
+const (
+	// UTF8 - UTF-8 (the default)
+	UTF8 Charset = "UTF-8"
+	// UTF16LE - UTF-16 little endian
+	UTF16LE Charset = "UTF-16LE"
+	// UTF16BE - UTF-16 big endian
+	UTF16BE Charset = "UTF-16BE"
+	// UTF32LE - UTF-32 little endian
+	UTF32LE Charset = "UTF-32LE"
+	// UTF32BE - UTF-32 big endian
+	UTF32BE Charset = "UTF-32BE"
+)
+
+// Encoding - the encoding of yaml contents, i.e. the charset and whether the contents start
+// with a byte order mark (BOM)
+type Encoding struct {
+	Charset Charset
+	BOM     bool
+}
+
+// DefaultEncoding - UTF-8 without a BOM
+var DefaultEncoding = Encoding{Charset: UTF8}
+
+// String - get the encoding as text, e.g. "UTF-16LE with BOM"
+func (e Encoding) String() string {
+	charset := e.Charset
+	if charset == "" {
+		charset = UTF8
+	}
+	if e.BOM {
+		return string(charset) + " with BOM"
+	}
+	return string(charset)
+}
+
+// byte order marks of the charsets, where UTF-32LE must be checked before UTF-16LE
+var boms = []struct {
+	charset Charset
+	bom     []byte
+}{
+	{UTF32BE, []byte{0x00, 0x00, 0xfe, 0xff}},
+	{UTF32LE, []byte{0xff, 0xfe, 0x00, 0x00}},
+	{UTF8, []byte{0xef, 0xbb, 0xbf}},
+	{UTF16BE, []byte{0xfe, 0xff}},
+	{UTF16LE, []byte{0xff, 0xfe}},
+}
+
+// DetectEncoding - detect the encoding of yaml contents.  The encoding is detected by the
+// byte order mark or, if there is none, by the position of the null bytes around the first
+// (ASCII) character, as described in the YAML spec.
+func DetectEncoding(contents []byte) Encoding {
+	for _, b := range boms {
+		if bytes.HasPrefix(contents, b.bom) {
+			return Encoding{Charset: b.charset, BOM: true}
+		}
+	}
+
+	switch {
+	case len(contents) >= 4 && contents[0] == 0 && contents[1] == 0 && contents[2] == 0 && contents[3] != 0:
+		return Encoding{Charset: UTF32BE}
+	case len(contents) >= 4 && contents[0] != 0 && contents[1] == 0 && contents[2] == 0 && contents[3] == 0:
+		return Encoding{Charset: UTF32LE}
+	case len(contents) >= 2 && contents[0] == 0 && contents[1] != 0:
+		return Encoding{Charset: UTF16BE}
+	case len(contents) >= 2 && contents[0] != 0 && contents[1] == 0:
+		return Encoding{Charset: UTF16LE}
+	}
+	return DefaultEncoding
+}
+
+// ToUTF8 - detect the encoding of yaml contents (see DetectEncoding) and transcode them to
+// UTF-8 without a BOM
+func ToUTF8(contents []byte) (utf8Contents []byte, encoding Encoding, err error) {
+	encoding = DetectEncoding(contents)
+	if encoding.BOM {
+		for _, b := range boms {
+			if b.charset == encoding.Charset {
+				contents = contents[len(b.bom):]
+				break
+			}
+		}
+	}
+
+	switch encoding.Charset {
+	case UTF16LE, UTF16BE:
+		utf8Contents, err = decodeUTF16(contents, byteOrder(encoding.Charset))
+	case UTF32LE, UTF32BE:
+		utf8Contents, err = decodeUTF32(contents, byteOrder(encoding.Charset))
+	default:
+		utf8Contents = contents
+	}
+	if err != nil {
+		return nil, encoding, fmt.Errorf("invalid %s contents: %v", encoding.Charset, err)
+	}
+	return utf8Contents, encoding, nil
+}
+
+// FromUTF8 - transcode UTF-8 yaml contents to the encoding
+func FromUTF8(contents []byte, encoding Encoding) (encoded []byte, err error) {
+	var buf bytes.Buffer
+
+	if encoding.BOM {
+		for _, b := range boms {
+			if b.charset == encoding.Charset || (encoding.Charset == "" && b.charset == UTF8) {
+				buf.Write(b.bom)
+				break
+			}
+		}
+	}
+
+	switch encoding.Charset {
+	case UTF16LE, UTF16BE:
+		order := byteOrder(encoding.Charset)
+		for _, unit := range utf16.Encode(bytes.Runes(contents)) {
+			var b [2]byte
+			order.PutUint16(b[:], unit)
+			buf.Write(b[:])
+		}
+	case UTF32LE, UTF32BE:
+		order := byteOrder(encoding.Charset)
+		for _, r := range bytes.Runes(contents) {
+			var b [4]byte
+			order.PutUint32(b[:], uint32(r))
+			buf.Write(b[:])
+		}
+	default:
+		buf.Write(contents)
+	}
+	return buf.Bytes(), nil
+}
+
+func byteOrder(charset Charset) binary.ByteOrder {
+	if charset == UTF16BE || charset == UTF32BE {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+func decodeUTF16(contents []byte, order binary.ByteOrder) ([]byte, error) {
+	if len(contents)%2 != 0 {
+		return nil, fmt.Errorf("odd number of bytes")
+	}
+
+	units := make([]uint16, len(contents)/2)
+	for index := range units {
+		units[index] = order.Uint16(contents[index*2:])
+	}
+	return []byte(string(utf16.Decode(units))), nil
+}
+
+func decodeUTF32(contents []byte, order binary.ByteOrder) ([]byte, error) {
+	if len(contents)%4 != 0 {
+		return nil, fmt.Errorf("number of bytes is not a multiple of 4")
+	}
+
+	var buf bytes.Buffer
+	for index := 0; index < len(contents); index += 4 {
+		r := rune(order.Uint32(contents[index:]))
+		if !utf8.ValidRune(r) {
+			return nil, fmt.Errorf("invalid code point %#x", uint32(r))
+		}
+		buf.WriteRune(r)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,57 @@
+package yamldoc
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Yaml character encodings", func() {
+	const yamlText = "a: välue-ä\nb: 😀"
+
+	var encodings = []struct {
+		encoding Encoding
+		prefix   []byte
+	}{
+		{DefaultEncoding, []byte("a")},
+		{Encoding{Charset: UTF8, BOM: true}, []byte{0xef, 0xbb, 0xbf, 'a'}},
+		{Encoding{Charset: UTF16LE, BOM: true}, []byte{0xff, 0xfe, 'a', 0}},
+		{Encoding{Charset: UTF16BE, BOM: true}, []byte{0xfe, 0xff, 0, 'a'}},
+		{Encoding{Charset: UTF16LE}, []byte{'a', 0}},
+		{Encoding{Charset: UTF16BE}, []byte{0, 'a'}},
+		{Encoding{Charset: UTF32LE, BOM: true}, []byte{0xff, 0xfe, 0, 0, 'a', 0, 0, 0}},
+		{Encoding{Charset: UTF32BE, BOM: true}, []byte{0, 0, 0xfe, 0xff, 0, 0, 0, 'a'}},
+		{Encoding{Charset: UTF32LE}, []byte{'a', 0, 0, 0}},
+		{Encoding{Charset: UTF32BE}, []byte{0, 0, 0, 'a'}},
+	}
+	for _, test := range encodings {
+		encoding, prefix := test.encoding, test.prefix
+
+		It("round trips "+encoding.String(), func() {
+			encoded, err := FromUTF8([]byte(yamlText), encoding)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(encoded).To(HavePrefix(string(prefix)))
+			Expect(DetectEncoding(encoded)).To(Equal(encoding))
+
+			decoded, detected, err := ToUTF8(encoded)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(detected).To(Equal(encoding))
+			Expect(string(decoded)).To(Equal(yamlText))
+
+			// and the yaml is parsed from the encoded contents
+			yaml, err := FromBytes(encoded)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(yaml.GetString("a")).To(Equal("välue-ä"))
+			Expect(yaml.GetString("b")).To(Equal("😀"))
+		})
+	}
+	It("describes the encoding", func() {
+		Expect(DefaultEncoding.String()).To(Equal("UTF-8"))
+		Expect(Encoding{Charset: UTF16LE, BOM: true}.String()).To(Equal("UTF-16LE with BOM"))
+	})
+	It("fails on truncated contents", func() {
+		_, _, err := ToUTF8([]byte{0xff, 0xfe, 'a', 0, 'b'})
+		Expect(err).To(HaveOccurred())
+		_, _, err = ToUTF8([]byte{0xff, 0xfe, 0, 0, 'a', 0, 0})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,537 @@
+package yamldoc
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// EncryptionMetadataKey - the reserved top-level key of the metadata of the encrypted values
+	EncryptionMetadataKey = "goyaml_encryption"
+	// EncryptionCipher - the cipher of the encrypted values
+	EncryptionCipher = "AES256_GCM"
+	// EncryptionKeySize - the size (in bytes) of the encryption keys
+	EncryptionKeySize = 32
+
+	encryptedPrefix = "ENC[" + EncryptionCipher + ","
+	encryptedSuffix = "]"
+)
+
+var (
+	// ErrNoEncryptionRules - error generated when encrypting without paths or regex, unless the yaml
+	// is already encrypted (and so the rules in its metadata are used)
+	ErrNoEncryptionRules = errors.New("No paths or regex specified for the values to encrypt")
+	// ErrWrongEncryptionKey - error generated when decrypting with another key than the one the
+	// values were encrypted with
+	ErrWrongEncryptionKey = errors.New("The key is not the key the values were encrypted with")
+	// ErrAliasedValue - error generated when encrypting a value that matches only at the path of
+	// one of its aliases (or of a map merging it with "<<"), since the anchored values are
+	// encrypted at the paths of their anchors
+	ErrAliasedValue = errors.New("The value is anchored at a path that does not match, so it cannot be encrypted through an alias")
+)
+
+// EncryptOptions - which values to encrypt
+type EncryptOptions struct {
+	// Paths - the path patterns of the values to encrypt, e.g. "db.password" or "**.token".  As with
+	// OnChange, a "*" segment matches any single key, a "**" segment any number of keys and all the
+	// values nested under a matching path are encrypted too.
+	Paths []string
+	// Regex - the regular expression matching the paths of the values to encrypt, e.g. "(password|token)$"
+	Regex string
+}
+
+// ParseEncryptionKey - parse the contents of a keyfile (or of an environment variable) into a
+// key: the 32 bytes of the key encoded as base64 or hex, or the 32 bytes themselves
+func ParseEncryptionKey(contents []byte) ([]byte, error) {
+	text := strings.TrimSpace(string(contents))
+
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == EncryptionKeySize {
+		return key, nil
+	}
+	if key, err := hex.DecodeString(text); err == nil && len(key) == EncryptionKeySize {
+		return key, nil
+	}
+	if len(contents) == EncryptionKeySize {
+		return contents, nil
+	}
+	return nil, fmt.Errorf("invalid encryption key: expected %d bytes, encoded as base64 or hex", EncryptionKeySize)
+}
+
+// DecryptWith - decrypt the encrypted values (see Encrypt) transparently when they are read with
+// Get (and the functions based on it, e.g. GetString).  The values are kept encrypted otherwise,
+// e.g. when the yaml is written out.
+func DecryptWith(key []byte) LoadOption {
+	return func(opts *loadOptions) {
+		opts.decryptionKey = key
+	}
+}
+
+// IsEncrypted - check if the value is an encrypted value (see Encrypt)
+func IsEncrypted(value interface{}) bool {
+	text, ok := value.(string)
+	return ok && strings.HasPrefix(text, encryptedPrefix) && strings.HasSuffix(text, encryptedSuffix)
+}
+
+// Encrypt - encrypt the values matching the path patterns or the regex with AES-GCM, e.g.
+// "password: ENC[AES256_GCM,data:...,iv:...,type:str]", so that the keys stay readable.  The
+// paths are authenticated too, so encrypted values cannot be moved to other keys.
+//
+// The key, the cipher and the rules are stored in the metadata under the reserved top-level key
+// (see EncryptionMetadataKey), so that encrypting without any paths or regex encrypts the
+// values matching the rules of the metadata, e.g. values added since.  Values that are already
+// encrypted (as well as null values and maps) are skipped.  It returns the number of values
+// encrypted.
+//
+// When the nodes are kept (see KeepNodes), each anchored value is encrypted once, at the path of
+// its anchor, and so it is decrypted at that path, even when read through its aliases.  Values
+// that match only at the paths of their aliases (or of the maps merging them with "<<") fail with
+// ErrAliasedValue.
+func (y *yamlDoc) Encrypt(key []byte, opts EncryptOptions) (encrypted int, err error) {
+	var aead cipher.AEAD
+
+	if aead, err = newAEAD(key); err != nil {
+		return 0, err
+	}
+	meta, _ := y.data[EncryptionMetadataKey].(map[string]interface{})
+	if meta != nil {
+		if meta["key_id"] != keyID(key) {
+			return 0, ErrWrongEncryptionKey
+		}
+		if len(opts.Paths) == 0 && opts.Regex == "" {
+			opts = encryptOptionsFromMeta(meta)
+		}
+	}
+	if len(opts.Paths) == 0 && opts.Regex == "" {
+		return 0, ErrNoEncryptionRules
+	}
+
+	var (
+		patterns [][]string
+		regex    *regexp.Regexp
+	)
+	for _, path := range opts.Paths {
+		patterns = append(patterns, strings.Split(path, "."))
+	}
+	if opts.Regex != "" {
+		if regex, err = regexp.Compile(opts.Regex); err != nil {
+			return 0, errors.Wrap(err, "Invalid regex")
+		}
+	}
+	matches := func(path []string) bool {
+		for _, pattern := range patterns {
+			if matchPath(pattern, path) {
+				return true
+			}
+		}
+		return regex != nil && regex.MatchString(strings.Join(path, "."))
+	}
+
+	err = y.Transaction(func(doc YamlDoc) error {
+		if encrypted, err = y.transformAnchoredValues(func(path []string, value interface{}) (interface{}, bool, error) {
+			if value == nil || IsEncrypted(value) || !matches(path) {
+				return value, false, nil
+			}
+			result, err := encryptValue(aead, path, value)
+			return result, err == nil, err
+		}); err != nil {
+			return err
+		}
+		if y.doc != nil {
+			// The values are encrypted at the paths of their anchors, so the values matching only
+			// at the paths of their aliases (e.g. "prod.password" for "prod: *base") are left as
+			// they are
+			if _, err = y.transformValues(func(path []string, value interface{}) (interface{}, bool, error) {
+				if value == nil || IsEncrypted(value) || !matches(path) {
+					return value, false, nil
+				}
+				return nil, false, ErrAliasedValue
+			}); err != nil {
+				return err
+			}
+		}
+		_, err := y.Set(EncryptionMetadataKey, encryptionMeta(key, opts))
+		return err
+	})
+	return encrypted, err
+}
+
+// Decrypt - decrypt the encrypted values (see Encrypt) and remove the encryption metadata.  It
+// returns the number of values decrypted.
+func (y *yamlDoc) Decrypt(key []byte) (decrypted int, err error) {
+	var aead cipher.AEAD
+
+	if aead, err = newAEAD(key); err != nil {
+		return 0, err
+	}
+	if meta, _ := y.data[EncryptionMetadataKey].(map[string]interface{}); meta != nil && meta["key_id"] != keyID(key) {
+		return 0, ErrWrongEncryptionKey
+	}
+
+	err = y.Transaction(func(doc YamlDoc) error {
+		if decrypted, err = y.transformAnchoredValues(func(path []string, value interface{}) (interface{}, bool, error) {
+			if !IsEncrypted(value) {
+				return value, false, nil
+			}
+			result, err := decryptValue(aead, path, value.(string))
+			return result, err == nil, err
+		}); err != nil {
+			return err
+		}
+		_, err := y.Delete(EncryptionMetadataKey)
+		return err
+	})
+	return decrypted, err
+}
+
+// transformAnchoredValues - transform the values like transformValues, except that when the
+// nodes are kept, the values of the nodes are transformed at their own paths rather than at the
+// paths of their aliases, so that each anchored value is transformed once, at the path of its
+// anchor.  It returns the number of values changed.
+func (y *yamlDoc) transformAnchoredValues(transform func(path []string, value interface{}) (interface{}, bool, error)) (count int, err error) {
+	if y.doc == nil {
+		return y.transformValues(transform)
+	}
+
+	err = y.applyToNodes(func(root *yaml.Node) (err error) {
+		walkNodes(root, "", func(node *yaml.Node, path string) {
+			var value interface{}
+
+			keys := strings.Split(path, ".")
+			if err != nil || node.Kind != yaml.ScalarNode || keys[0] == EncryptionMetadataKey {
+				return
+			}
+			if err = node.Decode(&value); err != nil {
+				err = errors.Wrapf(err, "Key '%s'", path)
+				return
+			}
+			newValue, changed, transformErr := transform(keys, value)
+			if transformErr != nil {
+				err = errors.Wrapf(transformErr, "Key '%s'", path)
+				return
+			}
+			if changed {
+				valueNode := &yaml.Node{}
+				if err = valueNode.Encode(newValue); err != nil {
+					return
+				}
+				replaceNode(node, valueNode)
+				count++
+			}
+		})
+		return err
+	})
+	return count, err
+}
+
+// anchoredPath - get the path of the node of the value at the path, i.e. the path of its anchor
+// when the value is read through an alias (or a merge key), which is the path its encrypted
+// value was encrypted at (see transformAnchoredValues)
+func (y *yamlDoc) anchoredPath(path []string) []string {
+	if y.doc == nil {
+		return path
+	}
+
+	target := y.rootMapping()
+	for _, key := range path {
+		switch target = resolveAlias(target); target.Kind {
+		case yaml.MappingNode:
+			if target, _ = findKey(target, key); target == nil {
+				return path
+			}
+		case yaml.SequenceNode:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(target.Content) {
+				return path
+			}
+			target = target.Content[index]
+		default:
+			return path
+		}
+	}
+	target = resolveAlias(target)
+
+	anchored := path
+	walkNodes(y.rootMapping(), "", func(node *yaml.Node, nodePath string) {
+		if node == target {
+			anchored = strings.Split(nodePath, ".")
+		}
+	})
+	return anchored
+}
+
+// transformValues - transform the values (other than maps) of the yaml, except for the
+// encryption metadata, and set the ones changed.  It returns the number of values changed.
+func (y *yamlDoc) transformValues(transform func(path []string, value interface{}) (interface{}, bool, error)) (int, error) {
+	var (
+		count   int
+		changes = map[string]interface{}{}
+		keys    []string
+		walk    func(path []string, value interface{}) (interface{}, bool, error)
+	)
+
+	walk = func(path []string, value interface{}) (interface{}, bool, error) {
+		switch typedValue := value.(type) {
+		case map[string]interface{}:
+			var result map[string]interface{}
+			for key, mapValue := range typedValue {
+				newValue, changed, err := walk(append(path[:len(path):len(path)], key), mapValue)
+				if err != nil {
+					return nil, false, err
+				}
+				if changed {
+					if result == nil {
+						result = deepCopy(typedValue).(map[string]interface{})
+					}
+					result[key] = newValue
+				}
+			}
+			return result, result != nil, nil
+		case []interface{}:
+			var result []interface{}
+			for index, item := range typedValue {
+				newValue, changed, err := walk(append(path[:len(path):len(path)], strconv.Itoa(index)), item)
+				if err != nil {
+					return nil, false, err
+				}
+				if changed {
+					if result == nil {
+						result = append([]interface{}{}, typedValue...)
+					}
+					result[index] = newValue
+				}
+			}
+			return result, result != nil, nil
+		}
+		newValue, changed, err := transform(path, value)
+		if changed {
+			count++
+		}
+		return newValue, changed, err
+	}
+
+	// The values are set per key (rather than the whole yaml), so that the comments of the
+	// other values are kept
+	var collect func(path []string, data map[string]interface{}) error
+	collect = func(path []string, data map[string]interface{}) error {
+		for key, value := range data {
+			keyPath := append(path[:len(path):len(path)], key)
+			if len(path) == 0 && key == EncryptionMetadataKey {
+				continue
+			}
+			if mapValue, ok := value.(map[string]interface{}); ok {
+				if err := collect(keyPath, mapValue); err != nil {
+					return err
+				}
+				continue
+			}
+			newValue, changed, err := walk(keyPath, value)
+			if err != nil {
+				return errors.Wrapf(err, "Key '%s'", strings.Join(keyPath, "."))
+			}
+			if changed {
+				changes[strings.Join(keyPath, ".")] = newValue
+				keys = append(keys, strings.Join(keyPath, "."))
+			}
+		}
+		return nil
+	}
+	if err := collect(nil, y.data); err != nil {
+		return 0, err
+	}
+	for _, key := range keys {
+		if _, err := y.Set(key, changes[key]); err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// decryptValues - decrypt the encrypted values within the value read with Get (see DecryptWith)
+func (y *yamlDoc) decryptValues(key string, value interface{}) (interface{}, error) {
+	if y.decryptionKey == nil {
+		return value, nil
+	}
+	aead, err := newAEAD(y.decryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var decrypt func(path []string, value interface{}) (interface{}, error)
+	decrypt = func(path []string, value interface{}) (interface{}, error) {
+		switch typedValue := value.(type) {
+		case map[string]interface{}:
+			result := make(map[string]interface{}, len(typedValue))
+			for mapKey, mapValue := range typedValue {
+				if result[mapKey], err = decrypt(append(path[:len(path):len(path)], mapKey), mapValue); err != nil {
+					return nil, err
+				}
+			}
+			return result, nil
+		case []interface{}:
+			result := make([]interface{}, len(typedValue))
+			for index, item := range typedValue {
+				if result[index], err = decrypt(append(path[:len(path):len(path)], strconv.Itoa(index)), item); err != nil {
+					return nil, err
+				}
+			}
+			return result, nil
+		case string:
+			if IsEncrypted(typedValue) {
+				return decryptValue(aead, y.anchoredPath(path), typedValue)
+			}
+		}
+		return value, nil
+	}
+	return decrypt(strings.Split(key, "."), value)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != EncryptionKeySize {
+		return nil, fmt.Errorf("invalid encryption key: expected %d bytes but got %d", EncryptionKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// keyID - the id of a key in the metadata, for telling whether the values were encrypted with it
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+func encryptionMeta(key []byte, opts EncryptOptions) map[string]interface{} {
+	meta := map[string]interface{}{
+		"cipher": EncryptionCipher,
+		"key_id": keyID(key),
+	}
+	if len(opts.Paths) > 0 {
+		paths := make([]interface{}, len(opts.Paths))
+		for index, path := range opts.Paths {
+			paths[index] = path
+		}
+		meta["paths"] = paths
+	}
+	if opts.Regex != "" {
+		meta["regex"] = opts.Regex
+	}
+	return meta
+}
+
+func encryptOptionsFromMeta(meta map[string]interface{}) (opts EncryptOptions) {
+	if paths, ok := meta["paths"].([]interface{}); ok {
+		for _, path := range paths {
+			opts.Paths = append(opts.Paths, fmt.Sprint(path))
+		}
+	}
+	if regex, ok := meta["regex"].(string); ok {
+		opts.Regex = regex
+	}
+	return opts
+}
+
+// encryptValue - encrypt a value into "ENC[AES256_GCM,data:<data>,iv:<iv>,type:<type>]", where
+// the type is the type to decrypt the value to
+func encryptValue(aead cipher.AEAD, path []string, value interface{}) (string, error) {
+	var valueType string
+
+	switch value.(type) {
+	case string:
+		valueType = "str"
+	case int, int64, uint64:
+		valueType = "int"
+	case float64:
+		valueType = "float"
+	case bool:
+		valueType = "bool"
+	case time.Time:
+		valueType = "timestamp"
+	default:
+		return "", fmt.Errorf("cannot encrypt value of type '%T'", value)
+	}
+
+	text := fmt.Sprint(value)
+	if datetime, ok := value.(time.Time); ok {
+		text = datetime.Format(time.RFC3339Nano)
+	}
+
+	iv := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	data := aead.Seal(nil, iv, []byte(text), []byte(strings.Join(path, ".")))
+
+	return fmt.Sprintf("%sdata:%s,iv:%s,type:%s%s", encryptedPrefix,
+		base64.StdEncoding.EncodeToString(data), base64.StdEncoding.EncodeToString(iv), valueType, encryptedSuffix), nil
+}
+
+// decryptValue - decrypt an encrypted value (see encryptValue) into a value of its type
+func decryptValue(aead cipher.AEAD, path []string, value string) (interface{}, error) {
+	fields := map[string]string{}
+	for _, field := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(value, encryptedPrefix), encryptedSuffix), ",") {
+		if parts := strings.SplitN(field, ":", 2); len(parts) == 2 {
+			fields[parts[0]] = parts[1]
+		}
+	}
+
+	data, err := base64.StdEncoding.DecodeString(fields["data"])
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid encrypted data")
+	}
+	iv, err := base64.StdEncoding.DecodeString(fields["iv"])
+	if err != nil || len(iv) != aead.NonceSize() {
+		return nil, errors.New("Invalid encrypted data: invalid iv")
+	}
+	plaintext, err := aead.Open(nil, iv, data, []byte(strings.Join(path, ".")))
+	if err != nil {
+		return nil, errors.Wrap(ErrWrongEncryptionKey, "Failed to decrypt (or the value was moved)")
+	}
+
+	text := string(plaintext)
+	switch fields["type"] {
+	case "int":
+		// The integers are typed as when decoded from yaml, i.e. int when they fit and uint64
+		// when they are too large for an int64
+		if strings.HasPrefix(text, "-") {
+			return parseInt(text)
+		}
+		if intValue, err := strconv.ParseUint(text, 10, 64); err != nil || intValue > math.MaxInt64 {
+			return intValue, err
+		}
+		return parseInt(text)
+	case "timestamp":
+		return time.Parse(time.RFC3339Nano, text)
+	case "float":
+		return strconv.ParseFloat(text, 64)
+	case "bool":
+		return strconv.ParseBool(text)
+	}
+	return text, nil
+}
+
+// parseInt - parse an integer as an int, or as an int64 when it does not fit in an int
+func parseInt(text string) (interface{}, error) {
+	intValue, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if int64(int(intValue)) == intValue {
+		return int(intValue), nil
+	}
+	return intValue, nil
+}
@@ -0,0 +1,174 @@
+package yamldoc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+var _ = Describe("Yaml value encryption", func() {
+	const secretsYaml = `
+# The database settings
+db:
+  host: db.example.com
+  password: s3cr3t # rotated monthly
+  port: 5432
+api:
+  token: abc123
+  retries: 3
+  keys:
+    - one
+    - two
+`
+	var (
+		yaml     YamlDoc
+		yamlText = strings.TrimSpace(secretsYaml)
+		key      = bytes.Repeat([]byte{7}, EncryptionKeySize)
+	)
+
+	BeforeEach(func() {
+		var err error
+		yaml, err = FromString(yamlText, KeepNodes())
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("parses the keys encoded as base64 or hex", func() {
+		parsed, err := ParseEncryptionKey([]byte(base64.StdEncoding.EncodeToString(key) + "\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsed).To(Equal(key))
+
+		parsed, err = ParseEncryptionKey([]byte(hex.EncodeToString(key)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsed).To(Equal(key))
+
+		_, err = ParseEncryptionKey([]byte("too short"))
+		Expect(err).To(HaveOccurred())
+	})
+	It("encrypts the values matching the path patterns and keeps the keys and comments", func() {
+		encrypted, err := yaml.Encrypt(key, EncryptOptions{Paths: []string{"**.password", "api.keys"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(encrypted).To(Equal(3))
+
+		Expect(IsEncrypted(yaml.Data()["db"].(map[string]interface{})["password"])).To(BeTrue())
+		Expect(IsEncrypted(yaml.Data()["api"].(map[string]interface{})["keys"].([]interface{})[1])).To(BeTrue())
+		checkGetValue(yaml, "db.host", "db.example.com")
+		checkGetValue(yaml, "api.token", "abc123")
+
+		text, err := yaml.Text()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(text).To(HavePrefix("# The database settings\ndb:\n  host: db.example.com\n  password: ENC[AES256_GCM,data:"))
+		Expect(text).To(ContainSubstring("# rotated monthly"))
+		Expect(text).To(ContainSubstring("goyaml_encryption:\n  cipher: AES256_GCM\n"))
+	})
+	It("encrypts the values matching the regex and keeps their types", func() {
+		encrypted, err := yaml.Encrypt(key, EncryptOptions{Regex: `(token|port)$`})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(encrypted).To(Equal(2))
+
+		decrypted, err := yaml.Decrypt(key)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decrypted).To(Equal(2))
+		checkGetValue(yaml, "db.port", 5432)
+		checkText(yaml, yamlText)
+	})
+	It("encrypts the values matching the rules of the metadata", func() {
+		_, err := yaml.Encrypt(key, EncryptOptions{Paths: []string{"**.password"}})
+		Expect(err).ToNot(HaveOccurred())
+		checkSetValue(yaml, "cache.password", "other")
+
+		encrypted, err := yaml.Encrypt(key, EncryptOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(encrypted).To(Equal(1))
+		Expect(IsEncrypted(yaml.Data()["cache"].(map[string]interface{})["password"])).To(BeTrue())
+	})
+	It("fails to encrypt without any rules", func() {
+		_, err := yaml.Encrypt(key, EncryptOptions{})
+		Expect(err).To(Equal(ErrNoEncryptionRules))
+	})
+	It("decrypts the values transparently when they are read", func() {
+		_, err := yaml.Encrypt(key, EncryptOptions{Paths: []string{"db"}})
+		Expect(err).ToNot(HaveOccurred())
+		text, err := yaml.Text()
+		Expect(err).ToNot(HaveOccurred())
+
+		encryptedYaml, err := FromString(text, DecryptWith(key))
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(encryptedYaml, "db.password", "s3cr3t")
+		checkGetIntValue(encryptedYaml, "db.port", 5432)
+		checkGetValue(encryptedYaml, "db", map[string]interface{}{
+			"host": "db.example.com", "password": "s3cr3t", "port": 5432,
+		})
+		Expect(IsEncrypted(encryptedYaml.Data()["db"].(map[string]interface{})["password"])).To(BeTrue())
+	})
+	It("encrypts the anchored values once, at the paths of their anchors", func() {
+		yaml, err := FromString("base: &b {password: x}\nprod: *b\nstaging: {<<: *b, host: h}", KeepNodes())
+		Expect(err).ToNot(HaveOccurred())
+
+		encrypted, err := yaml.Encrypt(key, EncryptOptions{Paths: []string{"**.password"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(encrypted).To(Equal(1))
+		text, err := yaml.Text()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(text).To(ContainSubstring("prod: *b\nstaging: {<<: *b, host: h}"))
+
+		encryptedYaml, err := FromString(text, KeepNodes(), DecryptWith(key))
+		Expect(err).ToNot(HaveOccurred())
+		for _, path := range []string{"base.password", "prod.password", "staging.password"} {
+			checkGetValue(encryptedYaml, path, "x")
+		}
+
+		decrypted, err := yaml.Decrypt(key)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decrypted).To(Equal(1))
+		checkGetValue(yaml, "prod.password", "x")
+	})
+	It("fails to encrypt the values matching only at the paths of their aliases", func() {
+		yaml, err := FromString("base: &b {password: x}\nprod: *b", KeepNodes())
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = yaml.Encrypt(key, EncryptOptions{Paths: []string{"prod.password"}})
+		Expect(err).To(MatchError(ContainSubstring("Key 'prod.password'")))
+		Expect(errors.Cause(err)).To(Equal(ErrAliasedValue))
+		checkGetValue(yaml, "base.password", "x")
+	})
+	It("encrypts and decrypts the large integers and the timestamps with their types", func() {
+		yaml, err := FromString("a: 18446744073709551615\nb: -9223372036854775808\nc: 2001-12-14t21:59:43.10-05:00", KeepNodes())
+		Expect(err).ToNot(HaveOccurred())
+		values := yaml.Data()
+
+		encrypted, err := yaml.Encrypt(key, EncryptOptions{Paths: []string{"*"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(encrypted).To(Equal(3))
+
+		_, err = yaml.Decrypt(key)
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "a", uint64(18446744073709551615))
+		checkGetValue(yaml, "b", values["b"])
+		created, err := yaml.Get("c")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created).To(BeAssignableToTypeOf(time.Time{}))
+		Expect(created.(time.Time).Equal(values["c"].(time.Time))).To(BeTrue())
+		_, offset := created.(time.Time).Zone()
+		Expect(offset).To(Equal(-5 * 60 * 60))
+	})
+	It("fails to decrypt with another key or values moved to other keys", func() {
+		_, err := yaml.Encrypt(key, EncryptOptions{Paths: []string{"db.password"}})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = yaml.Decrypt(bytes.Repeat([]byte{8}, EncryptionKeySize))
+		Expect(err).To(Equal(ErrWrongEncryptionKey))
+
+		password, err := yaml.Get("db.password")
+		Expect(err).ToNot(HaveOccurred())
+		checkSetValue(yaml, "db.host", password)
+		_, err = yaml.Decrypt(key)
+		Expect(errors.Cause(err)).To(Equal(ErrWrongEncryptionKey))
+		Expect(IsEncrypted(yaml.Data()["db"].(map[string]interface{})["password"])).To(BeTrue())
+	})
+})
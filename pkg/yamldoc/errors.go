@@ -3,6 +3,8 @@ package yamldoc
 import (
 	"fmt"
 	"reflect"
+
+	"github.com/pkg/errors"
 )
 
 type wrongTypeError struct {
@@ -39,6 +41,27 @@ func IsWrongTypeError(err error) bool {
 	return isWrongType
 }
 
+// LimitError - error generated when loading yaml that exceeds one of the limits (see Limits)
+type LimitError struct {
+	// Limit - the limit that was exceeded
+	Limit Limit
+	// Max - the value of the limit
+	Max int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("The yaml exceeds the %s limit of %d", e.Limit, e.Max)
+}
+
+// IsLimitError - check if the error is (or wraps) a limit error.
+//
+// This type of error will occur when loading yaml that exceeds one of the limits specified with
+// the load options, e.g. MaxBytes or MaxDepth.
+func IsLimitError(err error) bool {
+	var limitErr *LimitError
+	return errors.As(err, &limitErr)
+}
+
 // type keyNotFoundError struct {
 // 	key string
 // }
@@ -0,0 +1,263 @@
+package yamldoc
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// KeyCase - how the keys are transformed when flattened (see FlattenOptions)
+type KeyCase string
+
+const (
+	// KeyCaseAsIs - keep the keys as they are
+	KeyCaseAsIs KeyCase = "as-is"
+	// KeyCaseUpperSnake - transform the keys to upper snake case, e.g. "apiKey" or "api-key" to
+	// "API_KEY".  The keys are unflattened in lower case, e.g. "api_key".
+	KeyCaseUpperSnake KeyCase = "upper-snake"
+)
+
+// KeyCases - the valid key cases
+var KeyCases = []KeyCase{KeyCaseAsIs, KeyCaseUpperSnake}
+
+// FlattenOptions - how the yaml is flattened to (and unflattened from) keys and values, e.g.
+// for ".properties" or ".env" files
+type FlattenOptions struct {
+	// Separator - the separator of the keys of the nested values ("." if not specified)
+	Separator string
+	// Prefix - the prefix of the flattened keys, e.g. "APP_".  Keys without the prefix are skipped
+	// when unflattening.
+	Prefix string
+	// KeyCase - how the keys are transformed (KeyCaseAsIs if not specified)
+	KeyCase KeyCase
+	// Brackets - flatten the indexes of the arrays as "servers[0]" instead of as keys, e.g.
+	// "servers.0".  Both are unflattened to arrays.
+	Brackets bool
+}
+
+// FlatEntry - a flattened key and its value
+type FlatEntry struct {
+	Key   string
+	Value string
+}
+
+func (opts FlattenOptions) separator() string {
+	if opts.Separator == "" {
+		return "."
+	}
+	return opts.Separator
+}
+
+// Flatten - flatten the data into keys and values, e.g. "db.host=localhost" for "db: {host:
+// localhost}".  The keys are sorted, while the items of the arrays are in order.  Null values are
+// flattened to empty values, while empty maps and arrays are skipped.  Keys that would not be
+// unflattened as they are, since they contain the separator or "[" once transformed (e.g. "a.b",
+// or "apiKey" as "API_KEY" with the "_" separator), or that are flattened as the same key once
+// transformed (e.g. "apiKey" and "api_key" as "API_KEY"), fail with an error.
+func Flatten(data map[string]interface{}, opts FlattenOptions) ([]FlatEntry, error) {
+	var (
+		entries []FlatEntry
+		flatten func(key string, value interface{}) error
+	)
+
+	switch opts.KeyCase {
+	case "", KeyCaseAsIs, KeyCaseUpperSnake:
+	default:
+		return nil, fmt.Errorf("invalid key case '%s'", opts.KeyCase)
+	}
+
+	flatten = func(key string, value interface{}) error {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for mapKey := range v {
+				keys = append(keys, mapKey)
+			}
+			sort.Strings(keys)
+			flatKeys := make(map[string]string, len(keys))
+			for _, mapKey := range keys {
+				if mapKey == "" {
+					return fmt.Errorf("key '%s': cannot flatten empty keys", key)
+				}
+				childKey := mapKey
+				if opts.KeyCase == KeyCaseUpperSnake {
+					childKey = upperSnakeCase(mapKey)
+				}
+				if !isFlatKey(childKey, opts.separator()) {
+					return fmt.Errorf("key '%s': cannot flatten keys containing the separator '%s' or '['",
+						strings.TrimPrefix(key+opts.separator()+childKey, opts.separator()), opts.separator())
+				}
+				if key != "" {
+					childKey = key + opts.separator() + childKey
+				}
+				if other, found := flatKeys[childKey]; found {
+					return fmt.Errorf("key '%s': cannot flatten both keys '%s' and '%s' as the same key",
+						childKey, other, mapKey)
+				}
+				flatKeys[childKey] = mapKey
+				if err := flatten(childKey, v[mapKey]); err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			for index, item := range v {
+				childKey := key + opts.separator() + strconv.Itoa(index)
+				if opts.Brackets {
+					childKey = fmt.Sprintf("%s[%d]", key, index)
+				}
+				if err := flatten(childKey, item); err != nil {
+					return err
+				}
+			}
+		case nil:
+			entries = append(entries, FlatEntry{Key: opts.Prefix + key})
+		case time.Time:
+			entries = append(entries, FlatEntry{Key: opts.Prefix + key, Value: v.Format(time.RFC3339Nano)})
+		default:
+			entries = append(entries, FlatEntry{Key: opts.Prefix + key, Value: fmt.Sprint(v)})
+		}
+		return nil
+	}
+	if err := flatten("", data); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Unflatten - unflatten the keys and values into data (see Flatten).  The keys that are indexes
+// (e.g. "servers.0" or "servers[0]") are unflattened to arrays and the values are typed, e.g.
+// "10" is an int and "true" a bool.  Keys that are both a value and the parent of other values,
+// e.g. "db=x" and "db.host=y", fail with an error.
+func Unflatten(entries []FlatEntry, opts FlattenOptions) (map[string]interface{}, error) {
+	root := map[string]interface{}{}
+
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Key, opts.Prefix) {
+			continue
+		}
+		keys, err := splitFlatKey(strings.TrimPrefix(entry.Key, opts.Prefix), opts)
+		if err != nil {
+			return nil, err
+		}
+
+		current := root
+		for index, key := range keys {
+			if index == len(keys)-1 {
+				if _, found := current[key]; found {
+					return nil, fmt.Errorf("key '%s' is both a value and the parent of other values (or is duplicated)", entry.Key)
+				}
+				current[key] = parseFlatValue(entry.Value)
+				break
+			}
+			child, found := current[key]
+			if !found {
+				child = map[string]interface{}{}
+				current[key] = child
+			}
+			mapChild, ok := child.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("key '%s' is the child of a value", entry.Key)
+			}
+			current = mapChild
+		}
+	}
+	return toArrays(root).(map[string]interface{}), nil
+}
+
+// splitFlatKey - split a flattened key into its keys, e.g. "servers[0].host" into "servers", "0" and "host"
+func splitFlatKey(flatKey string, opts FlattenOptions) (keys []string, err error) {
+	for _, key := range strings.Split(flatKey, opts.separator()) {
+		var indexes []string
+
+		if open := strings.Index(key, "["); open >= 0 && strings.HasSuffix(key, "]") {
+			for _, index := range strings.Split(key[open+1:len(key)-1], "][") {
+				if _, err := strconv.Atoi(index); err != nil {
+					return nil, fmt.Errorf("invalid index '%s' of key '%s'", index, flatKey)
+				}
+				indexes = append(indexes, index)
+			}
+			key = key[:open]
+		}
+		if key == "" {
+			return nil, fmt.Errorf("invalid key '%s'", flatKey)
+		}
+		if opts.KeyCase == KeyCaseUpperSnake {
+			key = strings.ToLower(key)
+		}
+		keys = append(append(keys, key), indexes...)
+	}
+	return keys, nil
+}
+
+// toArrays - convert the maps whose keys are the indexes 0 to n-1 to arrays
+func toArrays(value interface{}) interface{} {
+	mapValue, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	for key, item := range mapValue {
+		mapValue[key] = toArrays(item)
+	}
+
+	array := make([]interface{}, len(mapValue))
+	for key, item := range mapValue {
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= len(array) || strconv.Itoa(index) != key {
+			return mapValue
+		}
+		array[index] = item
+	}
+	if len(array) == 0 {
+		return mapValue
+	}
+	return array
+}
+
+// parseFlatValue - get the typed value of a flattened value, e.g. 10 for "10"
+func parseFlatValue(value string) interface{} {
+	if intValue, err := strconv.Atoi(value); err == nil && strconv.Itoa(intValue) == value {
+		return intValue
+	}
+	if value == "true" || value == "false" {
+		return value == "true"
+	}
+	if floatValue, err := strconv.ParseFloat(value, 64); err == nil && strings.ContainsAny(value, ".eE") &&
+		!strings.ContainsAny(value, "nNiI") {
+		return floatValue
+	}
+	return value
+}
+
+// isFlatKey - check that a flattened key is unflattened as a single key, i.e. it contains neither
+// "[" nor the separator, even when joined with other keys (e.g. "a_" and "b" as "a___b" with the
+// "__" separator)
+func isFlatKey(key, separator string) bool {
+	if strings.Contains(key, "[") {
+		return false
+	}
+	parts := strings.Split(separator+key+separator, separator)
+	return len(parts) == 3 && parts[1] == key
+}
+
+// upperSnakeCase - transform a key to upper snake case, e.g. "apiKey" or "api-key" to "API_KEY"
+func upperSnakeCase(key string) string {
+	var (
+		builder strings.Builder
+		runes   = []rune(key)
+	)
+	for index, r := range runes {
+		switch {
+		case unicode.IsUpper(r) && index > 0 && (unicode.IsLower(runes[index-1]) || unicode.IsDigit(runes[index-1])):
+			builder.WriteRune('_')
+			builder.WriteRune(r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			builder.WriteRune(unicode.ToUpper(r))
+		default:
+			builder.WriteRune('_')
+		}
+	}
+	return builder.String()
+}
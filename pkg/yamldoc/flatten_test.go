@@ -0,0 +1,105 @@
+package yamldoc
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Yaml flattening", func() {
+	var data = map[string]interface{}{
+		"db": map[string]interface{}{
+			"host":   "localhost",
+			"port":   5432,
+			"apiKey": nil,
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"name": "one", "weight": 1.5},
+			map[string]interface{}{"name": "two", "enabled": true},
+		},
+		"empty": map[string]interface{}{},
+	}
+
+	It("flattens the data with the indexes as keys", func() {
+		entries, err := Flatten(data, FlattenOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(Equal([]FlatEntry{
+			{Key: "db.apiKey", Value: ""},
+			{Key: "db.host", Value: "localhost"},
+			{Key: "db.port", Value: "5432"},
+			{Key: "servers.0.name", Value: "one"},
+			{Key: "servers.0.weight", Value: "1.5"},
+			{Key: "servers.1.enabled", Value: "true"},
+			{Key: "servers.1.name", Value: "two"},
+		}))
+	})
+	It("flattens the data with the key transforms and the indexes in brackets", func() {
+		entries, err := Flatten(data, FlattenOptions{Separator: "__", Prefix: "APP_", KeyCase: KeyCaseUpperSnake, Brackets: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries[0]).To(Equal(FlatEntry{Key: "APP_DB__API_KEY", Value: ""}))
+		Expect(entries[3]).To(Equal(FlatEntry{Key: "APP_SERVERS[0]__NAME", Value: "one"}))
+
+		_, err = Flatten(data, FlattenOptions{KeyCase: "lower"})
+		Expect(err).To(MatchError("invalid key case 'lower'"))
+	})
+	It("fails to flatten keys containing the separator or brackets", func() {
+		_, err := Flatten(map[string]interface{}{"a.b": 1}, FlattenOptions{})
+		Expect(err).To(MatchError("key 'a.b': cannot flatten keys containing the separator '.' or '['"))
+
+		_, err = Flatten(map[string]interface{}{"a": map[string]interface{}{"b[0]": 1}}, FlattenOptions{})
+		Expect(err).To(MatchError("key 'a.b[0]': cannot flatten keys containing the separator '.' or '['"))
+
+		_, err = Flatten(data, FlattenOptions{Separator: "_", KeyCase: KeyCaseUpperSnake})
+		Expect(err).To(MatchError("key 'DB_API_KEY': cannot flatten keys containing the separator '_' or '['"))
+
+		// A key ending with "_" would be joined with the next key as "A___B", i.e. "A" and "_B"
+		_, err = Flatten(map[string]interface{}{"a_": map[string]interface{}{"b": 1}}, FlattenOptions{Separator: "__"})
+		Expect(err).To(MatchError("key 'a_': cannot flatten keys containing the separator '__' or '['"))
+	})
+	It("fails to flatten keys that are the same once transformed", func() {
+		collidingData := map[string]interface{}{
+			"db": map[string]interface{}{"apiKey": "x", "api_key": "y"},
+		}
+		_, err := Flatten(collidingData, FlattenOptions{Separator: "__", KeyCase: KeyCaseUpperSnake})
+		Expect(err).To(MatchError("key 'DB__API_KEY': cannot flatten both keys 'apiKey' and 'api_key' as the same key"))
+
+		entries, err := Flatten(collidingData, FlattenOptions{Separator: "__"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+	})
+	It("unflattens the entries with typed values and arrays", func() {
+		result, err := Unflatten([]FlatEntry{
+			{Key: "APP_DB_HOST", Value: "localhost"},
+			{Key: "APP_DB_PORT", Value: "5432"},
+			{Key: "APP_SERVERS_0_NAME", Value: "one"},
+			{Key: "APP_SERVERS_0_WEIGHT", Value: "1.5"},
+			{Key: "APP_SERVERS_1_ENABLED", Value: "true"},
+			{Key: "APP_TAGS[1]", Value: "007"},
+			{Key: "APP_TAGS[0]", Value: "a"},
+			{Key: "OTHER", Value: "skipped"},
+		}, FlattenOptions{Separator: "_", Prefix: "APP_", KeyCase: KeyCaseUpperSnake})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(map[string]interface{}{
+			"db": map[string]interface{}{"host": "localhost", "port": 5432},
+			"servers": []interface{}{
+				map[string]interface{}{"name": "one", "weight": 1.5},
+				map[string]interface{}{"enabled": true},
+			},
+			"tags": []interface{}{"a", "007"},
+		}))
+	})
+	It("keeps the maps of indexes that are not consecutive", func() {
+		result, err := Unflatten([]FlatEntry{{Key: "a.1", Value: "x"}, {Key: "a.3", Value: "y"}}, FlattenOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(map[string]interface{}{"a": map[string]interface{}{"1": "x", "3": "y"}}))
+	})
+	It("fails to unflatten keys that are both values and parents", func() {
+		_, err := Unflatten([]FlatEntry{{Key: "db", Value: "x"}, {Key: "db.host", Value: "y"}}, FlattenOptions{})
+		Expect(err).To(MatchError("key 'db.host' is the child of a value"))
+
+		_, err = Unflatten([]FlatEntry{{Key: "db.host", Value: "y"}, {Key: "db", Value: "x"}}, FlattenOptions{})
+		Expect(err).To(MatchError(ContainSubstring("key 'db' is both a value and the parent of other values")))
+
+		_, err = Unflatten([]FlatEntry{{Key: "db..host", Value: "y"}}, FlattenOptions{})
+		Expect(err).To(MatchError("invalid key 'db..host'"))
+	})
+})
@@ -0,0 +1,113 @@
+package yamldoc
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// strTag - the tag of string values
+const strTag = "!!str"
+
+// envReference - "${VAR}", "${VAR:-default}" and "${VAR:?error}" or the "$${" escape
+var envReference = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?:(:-|:\?)([^}]*))?\}`)
+
+// envInterpolation - which environment variables are interpolated
+type envInterpolation struct {
+	// prefixes - the prefixes of the variables allowed (nil for all)
+	prefixes []string
+}
+
+// InterpolateEnv - interpolate the environment variables in the string values of the yaml
+// when it is loaded, so that the same yaml can be used for several environments:
+//
+// - "${VAR}" is replaced with the value of the variable (or "" if it is not set)
+//
+// - "${VAR:-default}" is replaced with the default if the variable is not set (or is empty)
+//
+// - "${VAR:?message}" fails to load the yaml with the message if the variable is not set (or is empty)
+//
+// - "$${" is replaced with "${", for values that need the text as is
+//
+// Only the variables starting with one of the prefixes are interpolated (or all the variables
+// if no prefixes are specified), while any other references are kept as they are.  Values that
+// are not quoted are typed after the interpolation, e.g. "port: ${PORT:-8080}" is an int.
+//
+// Since the values are replaced, writing out the yaml writes out the interpolated values.
+func InterpolateEnv(prefixes ...string) LoadOption {
+	return func(opts *loadOptions) {
+		opts.interpolation = &envInterpolation{prefixes: prefixes}
+	}
+}
+
+// allowed - check if the variable is allowed to be interpolated
+func (e *envInterpolation) allowed(name string) bool {
+	if len(e.prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range e.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// interpolateNodes - interpolate the environment variables in the string values of the nodes
+func (e *envInterpolation) interpolateNodes(root *yaml.Node) (err error) {
+	walkNodes(root, "", func(node *yaml.Node, path string) {
+		if err != nil || node.Kind != yaml.ScalarNode || node.Tag != strTag || !strings.Contains(node.Value, "${") {
+			return
+		}
+		var value string
+		if value, err = e.interpolate(node.Value); err != nil {
+			err = fmt.Errorf("key '%s': %s", path, err)
+			return
+		}
+		node.Value = value
+		// Values that are neither quoted nor tagged are typed by their interpolated value
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+	})
+	return err
+}
+
+// interpolate - interpolate the environment variables in the text
+func (e *envInterpolation) interpolate(text string) (string, error) {
+	var (
+		result strings.Builder
+		last   int
+	)
+	for _, match := range envReference.FindAllStringSubmatchIndex(text, -1) {
+		result.WriteString(text[last:match[0]])
+		last = match[1]
+
+		if text[match[0]:match[1]] == "$${" {
+			result.WriteString("${")
+			continue
+		}
+		name := text[match[2]:match[3]]
+		if !e.allowed(name) {
+			result.WriteString(text[match[0]:match[1]])
+			continue
+		}
+		value, found := os.LookupEnv(name)
+		if match[4] >= 0 && (!found || value == "") {
+			operator, arg := text[match[4]:match[5]], text[match[6]:match[7]]
+			if operator == ":?" {
+				if arg == "" {
+					arg = "not set"
+				}
+				return "", fmt.Errorf("environment variable '%s': %s", name, arg)
+			}
+			value = arg
+		}
+		result.WriteString(value)
+	}
+	result.WriteString(text[last:])
+	return result.String(), nil
+}
@@ -0,0 +1,75 @@
+package yamldoc
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Yaml environment variable interpolation", func() {
+	const interpolatedYaml = `
+host: ${APP_HOST}
+port: ${APP_PORT:-8080}
+quoted: "${APP_PORT:-8080}"
+url: http://${APP_HOST}:${APP_PORT:-8080}/${OTHER_PATH}
+escaped: $${APP_HOST}
+items:
+  - ${APP_HOST}
+`
+	BeforeEach(func() {
+		os.Setenv("APP_HOST", "example.com")
+		os.Setenv("OTHER_PATH", "api")
+		os.Unsetenv("APP_PORT")
+	})
+	AfterEach(func() {
+		os.Unsetenv("APP_HOST")
+		os.Unsetenv("OTHER_PATH")
+	})
+
+	It("keeps the references without the option", func() {
+		yaml, err := FromString(interpolatedYaml)
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "host", "${APP_HOST}")
+	})
+	It("interpolates the environment variables", func() {
+		yaml, err := FromString(interpolatedYaml, InterpolateEnv())
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "host", "example.com")
+		checkGetValue(yaml, "port", 8080)
+		checkGetValue(yaml, "quoted", "8080")
+		checkGetValue(yaml, "url", "http://example.com:8080/api")
+		checkGetValue(yaml, "escaped", "${APP_HOST}")
+		checkGetValue(yaml, "items", []interface{}{"example.com"})
+	})
+	It("uses the value of the variable instead of the default", func() {
+		os.Setenv("APP_PORT", "9090")
+		defer os.Unsetenv("APP_PORT")
+
+		yaml, err := FromString(interpolatedYaml, InterpolateEnv())
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "port", 9090)
+	})
+	It("interpolates only the variables with the allowed prefixes", func() {
+		yaml, err := FromString(interpolatedYaml, InterpolateEnv("APP_"))
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "url", "http://example.com:8080/${OTHER_PATH}")
+	})
+	It("interpolates unset variables without a default as empty", func() {
+		yaml, err := FromString("a: x${APP_MISSING}y", InterpolateEnv())
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "a", "xy")
+	})
+	It("fails to load the yaml when a required variable is not set", func() {
+		_, err := FromString("db:\n  password: ${APP_PASSWORD:?the database password is required}", InterpolateEnv())
+		Expect(err).To(MatchError("key 'db.password': environment variable 'APP_PASSWORD': the database password is required"))
+
+		_, err = FromString("password: ${APP_PASSWORD:?}", InterpolateEnv())
+		Expect(err).To(MatchError("key 'password': environment variable 'APP_PASSWORD': not set"))
+	})
+	It("writes out the interpolated values", func() {
+		yaml, err := FromString("host: ${APP_HOST}\nport: ${APP_PORT:-8080}", InterpolateEnv(), KeepNodes())
+		Expect(err).ToNot(HaveOccurred())
+		checkText(yaml, "host: example.com\nport: 8080")
+	})
+})
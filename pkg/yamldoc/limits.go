@@ -0,0 +1,174 @@
+package yamldoc
+
+import (
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Limit - the name of a limit for loading untrusted yaml
+type Limit string
+
+const (
+	// LimitBytes - the limit of the size of the yaml contents
+	LimitBytes Limit = "max bytes"
+	// LimitDepth - the limit of the nesting depth of maps and arrays
+	LimitDepth Limit = "max depth"
+	// LimitNodes - the limit of the number of nodes (keys, values, maps, arrays and aliases)
+	LimitNodes Limit = "max nodes"
+	// LimitAliasExpansion - the limit of the number of nodes once the aliases (and the
+	// references, see ResolveRefs) are expanded
+	LimitAliasExpansion Limit = "max alias expansion"
+)
+
+// Limits - the limits for loading untrusted yaml, so that a hostile document (e.g. a "billion
+// laughs" document, where aliases expand to a huge number of values) cannot exhaust the
+// memory.  A zero (or negative) limit means unlimited.
+type Limits struct {
+	// MaxBytes - the maximum size of the yaml contents, in bytes
+	MaxBytes int64
+	// MaxDepth - the maximum nesting depth of maps and arrays, where the top level map has depth 1
+	MaxDepth int
+	// MaxNodes - the maximum number of nodes in the yaml
+	MaxNodes int
+	// MaxAliasExpansion - the maximum number of nodes once the aliases are expanded.  The values
+	// of the references and includes resolved (see ResolveRefs and ResolveTags) count towards it
+	// too, since they expand the yaml just like aliases do.
+	MaxAliasExpansion int
+}
+
+// WithLimits - load the yaml within the limits
+func WithLimits(limits Limits) LoadOption {
+	return func(opts *loadOptions) {
+		opts.limits = limits
+	}
+}
+
+// MaxBytes - load yaml contents of up to "max" bytes
+func MaxBytes(max int64) LoadOption {
+	return func(opts *loadOptions) {
+		opts.limits.MaxBytes = max
+	}
+}
+
+// MaxDepth - load yaml with maps and arrays nested up to "max" levels
+func MaxDepth(max int) LoadOption {
+	return func(opts *loadOptions) {
+		opts.limits.MaxDepth = max
+	}
+}
+
+// MaxNodes - load yaml with up to "max" nodes
+func MaxNodes(max int) LoadOption {
+	return func(opts *loadOptions) {
+		opts.limits.MaxNodes = max
+	}
+}
+
+// MaxAliasExpansion - load yaml with up to "max" nodes once the aliases are expanded
+func MaxAliasExpansion(max int) LoadOption {
+	return func(opts *loadOptions) {
+		opts.limits.MaxAliasExpansion = max
+	}
+}
+
+// ReadAll - read all the contents of the reader.  If the contents exceed the MaxBytes limit of
+// the options, then reading stops and a *LimitError is returned.
+func ReadAll(reader io.Reader, opts ...LoadOption) (contents []byte, err error) {
+	return readAll(reader, newLoadOptions(opts).limits.MaxBytes)
+}
+
+func readAll(reader io.Reader, maxBytes int64) (contents []byte, err error) {
+	if maxBytes <= 0 {
+		return io.ReadAll(reader)
+	}
+
+	if contents, err = io.ReadAll(io.LimitReader(reader, maxBytes+1)); err != nil {
+		return nil, err
+	}
+	if int64(len(contents)) > maxBytes {
+		return nil, &LimitError{Limit: LimitBytes, Max: maxBytes}
+	}
+	return contents, nil
+}
+
+// hasNodeLimits - check if any of the limits requires checking the nodes of the yaml
+func (l Limits) hasNodeLimits() bool {
+	return l.MaxDepth > 0 || l.MaxNodes > 0 || l.MaxAliasExpansion > 0
+}
+
+// check - check that the nodes of the yaml are within the limits
+func (l Limits) check(root *yaml.Node) error {
+	counter := &nodeCounter{
+		expanded: map[*yaml.Node]nodeCount{},
+	}
+	total := counter.count(root)
+
+	switch {
+	case l.MaxNodes > 0 && counter.nodes > l.MaxNodes:
+		return &LimitError{Limit: LimitNodes, Max: int64(l.MaxNodes)}
+	case l.MaxAliasExpansion > 0 && total.nodes > l.MaxAliasExpansion:
+		return &LimitError{Limit: LimitAliasExpansion, Max: int64(l.MaxAliasExpansion)}
+	case l.MaxDepth > 0 && total.depth > l.MaxDepth:
+		return &LimitError{Limit: LimitDepth, Max: int64(l.MaxDepth)}
+	}
+	return nil
+}
+
+// nodeCount - the number of nodes and the depth of a node, once its aliases are expanded
+type nodeCount struct {
+	nodes int
+	depth int
+}
+
+type nodeCounter struct {
+	// nodes - the number of nodes, without expanding the aliases
+	nodes int
+	// expanded - the counts of the nodes already counted, so that each anchored node is
+	// counted once, no matter how many aliases refer to it
+	expanded map[*yaml.Node]nodeCount
+}
+
+func (c *nodeCounter) count(node *yaml.Node) nodeCount {
+	if node == nil {
+		return nodeCount{}
+	}
+	if counted, found := c.expanded[node]; found {
+		return counted
+	}
+	// Guard against an anchor that contains an alias to itself, which fails when decoding
+	c.expanded[node] = nodeCount{}
+
+	result := nodeCount{}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			result = c.count(child)
+		}
+		c.expanded[node] = result
+		return result
+	case yaml.AliasNode:
+		c.nodes++
+		result = c.count(node.Alias)
+		c.expanded[node] = result
+		return result
+	}
+
+	c.nodes++
+	result.nodes = 1
+	for _, child := range node.Content {
+		counted := c.count(child)
+		// Saturate, so that a huge expansion does not overflow
+		if result.nodes += counted.nodes; result.nodes < 0 {
+			result.nodes = int(^uint(0) >> 1)
+		}
+		if counted.depth > result.depth {
+			result.depth = counted.depth
+		}
+	}
+	if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
+		result.depth++
+	}
+	c.expanded[node] = result
+	return result
+}
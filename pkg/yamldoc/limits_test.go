@@ -0,0 +1,67 @@
+package yamldoc
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Yaml load limits", func() {
+	const laughs = `
+a: &a [lol, lol, lol, lol, lol, lol, lol, lol, lol]
+b: &b [*a, *a, *a, *a, *a, *a, *a, *a, *a]
+c: &c [*b, *b, *b, *b, *b, *b, *b, *b, *b]
+d: &d [*c, *c, *c, *c, *c, *c, *c, *c, *c]
+`
+	var yamlText = strings.TrimSpace(_SampleYaml)
+
+	checkLimitError := func(err error, limit Limit, max int64) {
+		Expect(IsLimitError(err)).To(BeTrue())
+		Expect(err).To(Equal(&LimitError{Limit: limit, Max: max}))
+	}
+
+	It("loads yaml within the limits", func() {
+		yaml, err := FromString(yamlText, WithLimits(Limits{
+			MaxBytes:          int64(len(yamlText)),
+			MaxDepth:          3,
+			MaxNodes:          21,
+			MaxAliasExpansion: 21,
+		}))
+		Expect(err).ToNot(HaveOccurred())
+		checkText(yaml, yamlText)
+	})
+	It("fails to load yaml larger than the max bytes", func() {
+		_, err := FromString(yamlText, MaxBytes(int64(len(yamlText)-1)))
+		checkLimitError(err, LimitBytes, int64(len(yamlText)-1))
+	})
+	It("fails to load yaml nested deeper than the max depth", func() {
+		_, err := FromString(yamlText, MaxDepth(2))
+		checkLimitError(err, LimitDepth, 2)
+	})
+	It("fails to load yaml with more nodes than the max nodes", func() {
+		_, err := FromString(yamlText, MaxNodes(20))
+		checkLimitError(err, LimitNodes, 20)
+	})
+	It("fails to load yaml with aliases expanding to more nodes than the max alias expansion", func() {
+		// The aliases expand to more than 7000 values, although the yaml has fewer than 100 nodes
+		_, err := FromString(laughs, MaxNodes(100), MaxAliasExpansion(1000))
+		checkLimitError(err, LimitAliasExpansion, 1000)
+
+		// The depth includes the nesting of the aliases
+		_, err = FromString(laughs, MaxDepth(4))
+		checkLimitError(err, LimitDepth, 4)
+
+		yaml, err := FromString(strings.Join(strings.Split(laughs, "\n")[:3], "\n"), MaxAliasExpansion(200), MaxDepth(3))
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "a", []interface{}{"lol", "lol", "lol", "lol", "lol", "lol", "lol", "lol", "lol"})
+	})
+	It("reads contents up to the max bytes", func() {
+		contents, err := ReadAll(strings.NewReader(yamlText), MaxBytes(int64(len(yamlText))))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal(yamlText))
+
+		_, err = ReadAll(strings.NewReader(yamlText), MaxBytes(10))
+		checkLimitError(err, LimitBytes, 10)
+	})
+})
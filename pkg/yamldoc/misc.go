@@ -35,3 +35,67 @@ func convertNested(value interface{}) interface{} {
 	}
 	return value
 }
+
+// convertMaps - convert the maps within the data that are decoded with non-string keys (e.g.
+// the maps with merge keys "<<") to maps with string keys, so that keys can be looked up in them
+func convertMaps(data map[string]interface{}) {
+	for key, value := range data {
+		data[key] = convertMapValue(value)
+	}
+}
+
+func convertMapValue(value interface{}) interface{} {
+	switch x := value.(type) {
+	case map[interface{}]interface{}:
+		mapValue := convert(x)
+		convertMaps(mapValue)
+		return mapValue
+	case map[string]interface{}:
+		convertMaps(x)
+	case []interface{}:
+		for i, v := range x {
+			x[i] = convertMapValue(v)
+		}
+	}
+	return value
+}
+
+// deepCopy - copy the maps and arrays of a yaml value recursively.  Any other values
+// (scalars or custom objects set by the caller) are copied as is.
+func deepCopy(value interface{}) interface{} {
+	switch x := value.(type) {
+	case map[string]interface{}:
+		mapValue := make(map[string]interface{}, len(x))
+		for k, v := range x {
+			mapValue[k] = deepCopy(v)
+		}
+		return mapValue
+	case map[interface{}]interface{}:
+		mapValue := make(map[interface{}]interface{}, len(x))
+		for k, v := range x {
+			mapValue[k] = deepCopy(v)
+		}
+		return mapValue
+	case []interface{}:
+		array := make([]interface{}, len(x))
+		for i, v := range x {
+			array[i] = deepCopy(v)
+		}
+		return array
+	}
+	return value
+}
+
+// mergeMaps - deep merge the "from" map into the "into" map
+func mergeMaps(into, from map[string]interface{}) {
+	for k, v := range from {
+		intoMap, intoIsMap := into[k].(map[string]interface{})
+		fromMap, fromIsMap := v.(map[string]interface{})
+
+		if intoIsMap && fromIsMap {
+			mergeMaps(intoMap, fromMap)
+		} else {
+			into[k] = v
+		}
+	}
+}
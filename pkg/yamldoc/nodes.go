@@ -0,0 +1,459 @@
+package yamldoc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrAnchorInUse - error generated when deleting a value with an anchor that is used by aliases
+	ErrAnchorInUse = errors.New("The value has an anchor that is used by aliases elsewhere in the yaml")
+	// ErrMergedKey - error generated when deleting a key that is merged (with "<<") from another map
+	ErrMergedKey = errors.New("The key is merged from another map and cannot be deleted")
+)
+
+// mergeTag - the tag of the merge key ("<<")
+const mergeTag = "!!merge"
+
+// Anchor - an anchor (e.g. "&defaults") of a value in the yaml and the aliases (e.g. "*defaults")
+// that refer to it
+type Anchor struct {
+	// Name - the name of the anchor
+	Name string
+	// Path - the key path (in "dot" notation) of the anchored value.  Array items are referred
+	// to by their index and merged maps by "<<", e.g. "services.0.<<"
+	Path string
+	// Aliases - the key paths of the aliases that refer to the anchored value
+	Aliases []string
+}
+
+// KeepNodes - keep the nodes of the yaml, so that its anchors, aliases and merge keys ("<<"),
+// as well as the order of its keys, are kept when it is changed and written out.
+//
+// Without this option, the aliases are expanded when the yaml is loaded, so that writing it
+// out duplicates the anchored values.  With this option:
+//
+// - Set and Delete change the nodes.  Changing a value through an alias (or a map merged with
+// "<<") changes the anchored value, so all its aliases see the change.  However, setting a key
+// merged from another map overrides it in the map it is merged into.
+//
+// - Delete fails with ErrAnchorInUse when the value has an anchor that aliases refer to, and
+// with ErrMergedKey when the key is only merged from another map.
+//
+// - SetData, Merge and changes made directly to the map returned by Data are not tracked in
+// the nodes.  SetData and Merge create the nodes again from the data, so the anchors are lost.
+func KeepNodes() LoadOption {
+	return func(opts *loadOptions) {
+		opts.keepNodes = true
+	}
+}
+
+// Anchors - get the anchors of the yaml, in the order they appear.  If the nodes of the yaml
+// are not kept (see KeepNodes), then the aliases have already been expanded and there are no
+// anchors.
+func (y *yamlDoc) Anchors() []Anchor {
+	if y.doc == nil {
+		return []Anchor{}
+	}
+
+	var (
+		anchors = []Anchor{}
+		indexes = map[*yaml.Node]int{}
+		aliases = map[*yaml.Node][]string{}
+	)
+	walkNodes(y.rootMapping(), "", func(node *yaml.Node, path string) {
+		if node.Anchor != "" {
+			indexes[node] = len(anchors)
+			anchors = append(anchors, Anchor{Name: node.Anchor, Path: path})
+		}
+		if node.Kind == yaml.AliasNode {
+			aliases[node.Alias] = append(aliases[node.Alias], path)
+		}
+	})
+	for node, paths := range aliases {
+		if index, found := indexes[node]; found {
+			anchors[index].Aliases = paths
+		}
+	}
+	for index := range anchors {
+		if anchors[index].Aliases == nil {
+			anchors[index].Aliases = []string{}
+		}
+	}
+	return anchors
+}
+
+// FlattenAliases - replace the aliases with copies of the anchored values, merge the maps of
+// the merge keys ("<<") into the maps they are merged into and remove the anchors.  The values
+// of the yaml do not change, only the way it is written out.  If the nodes of the yaml are not
+// kept (see KeepNodes), then the aliases have already been expanded and nothing changes.
+//
+// The yaml is marked as changed (see IsDirty) only if it had any aliases, anchors or merge keys.
+func (y *yamlDoc) FlattenAliases() {
+	if y.doc != nil && flattenNode(y.doc) {
+		y.nodesChanged = true
+	}
+}
+
+// untagMergeKeys - remove the tags of the merge keys, which are resolved again when decoding,
+// so that the merge keys are written out as "<<" instead of "!!merge <<"
+func untagMergeKeys(doc *yaml.Node) {
+	walkNodes(doc, "", func(node *yaml.Node, _ string) {
+		if node.Kind == yaml.MappingNode {
+			for index := 0; index+1 < len(node.Content); index += 2 {
+				if isMergeKey(node.Content[index]) {
+					node.Content[index].Tag = ""
+				}
+			}
+		}
+	})
+}
+
+// rootMapping - get the top level map node of the yaml, creating it if needed
+func (y *yamlDoc) rootMapping() *yaml.Node {
+	if len(y.doc.Content) == 0 {
+		y.doc.Content = []*yaml.Node{newMappingNode()}
+	}
+	return y.doc.Content[0]
+}
+
+// setNodes - create the nodes from the data, when the nodes are kept
+func (y *yamlDoc) setNodes() {
+	if y.doc != nil {
+		y.doc = nodesFromData(y.data)
+	}
+}
+
+// applyToNodes - apply a change to the nodes and update the data from them
+func (y *yamlDoc) applyToNodes(change func(root *yaml.Node) error) error {
+	var data = map[string]interface{}{}
+
+	if err := change(y.rootMapping()); err != nil {
+		return err
+	}
+	oldData := y.data
+	y.data = data
+	if err := y.decodeNodes(y.doc); err != nil {
+		y.data = oldData
+		return err
+	}
+	convertMaps(data)
+	y.notifyChange("", oldData, true, y.data, true)
+	return nil
+}
+
+// setNode - set the value at the key path in the nodes
+func setNode(root *yaml.Node, keys []string, value interface{}) error {
+	var (
+		valueNode = &yaml.Node{}
+		current   = root
+	)
+	if err := valueNode.Encode(value); err != nil {
+		return err
+	}
+
+	for index, key := range keys {
+		mapping := resolveAlias(current)
+		if mapping.Kind != yaml.MappingNode {
+			return fmt.Errorf("key '%s' is not a map container", strings.Join(keys[:index], "."))
+		}
+
+		child, merged := findKey(mapping, key)
+		if index == len(keys)-1 {
+			if child == nil || merged {
+				// A key merged from another map is overridden in this map
+				mapping.Content = append(mapping.Content, newKeyNode(key), valueNode)
+			} else {
+				// Setting an alias sets the anchored value
+				replaceNode(resolveAlias(child), valueNode)
+			}
+			break
+		}
+
+		if child == nil {
+			child = newMappingNode()
+			mapping.Content = append(mapping.Content, newKeyNode(key), child)
+		} else if isNullNode(resolveAlias(child)) {
+			replaceNode(resolveAlias(child), newMappingNode())
+		}
+		current = child
+	}
+	return nil
+}
+
+// deleteNode - delete the value at the key path from the nodes
+func deleteNode(doc *yaml.Node, root *yaml.Node, keys []string) (deleted bool, err error) {
+	var current = root
+
+	for index, key := range keys {
+		mapping := resolveAlias(current)
+		if mapping.Kind != yaml.MappingNode {
+			return false, nil
+		}
+
+		if index < len(keys)-1 {
+			if current, _ = findKey(mapping, key); current == nil {
+				return false, nil
+			}
+			continue
+		}
+
+		keyIndex := ownKeyIndex(mapping, key)
+		if keyIndex < 0 {
+			if child, _ := findKey(mapping, key); child != nil {
+				return false, ErrMergedKey
+			}
+			return false, nil
+		}
+		if anchorInUse(doc, mapping.Content[keyIndex+1]) {
+			return false, ErrAnchorInUse
+		}
+		mapping.Content = append(mapping.Content[:keyIndex], mapping.Content[keyIndex+2:]...)
+		deleted = true
+	}
+	return deleted, nil
+}
+
+// nodesFromData - create the nodes of a yaml document from the data
+func nodesFromData(data map[string]interface{}) *yaml.Node {
+	var mapping = &yaml.Node{}
+
+	if err := mapping.Encode(data); err != nil || mapping.Kind != yaml.MappingNode {
+		mapping = newMappingNode()
+	}
+	return &yaml.Node{
+		Kind:    yaml.DocumentNode,
+		Content: []*yaml.Node{mapping},
+	}
+}
+
+func newMappingNode() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+func newKeyNode(key string) *yaml.Node {
+	node := &yaml.Node{}
+	node.SetString(key)
+	return node
+}
+
+func isNullNode(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null"
+}
+
+// isMergeKey - check if the node is a merge key ("<<"), including the ones untagged by untagMergeKeys
+func isMergeKey(node *yaml.Node) bool {
+	if node.Kind != yaml.ScalarNode || node.Value != "<<" {
+		return false
+	}
+	if node.Tag == "" {
+		return node.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) == 0
+	}
+	return node.ShortTag() == mergeTag
+}
+
+// resolveAlias - get the anchored node of an alias
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// replaceNode - replace the contents of a node, keeping its anchor and comments, so that
+// the aliases that refer to it see the new contents
+func replaceNode(node, newNode *yaml.Node) {
+	var (
+		anchor      = node.Anchor
+		headComment = node.HeadComment
+		lineComment = node.LineComment
+		footComment = node.FootComment
+	)
+	*node = *newNode
+	node.Anchor = anchor
+	node.HeadComment = headComment
+	node.LineComment = lineComment
+	node.FootComment = footComment
+}
+
+// ownKeyIndex - get the index of the key node of a key in a map, ignoring the merged maps
+func ownKeyIndex(mapping *yaml.Node, key string) int {
+	for index := 0; index+1 < len(mapping.Content); index += 2 {
+		if keyNode := mapping.Content[index]; !isMergeKey(keyNode) && keyNode.Value == key {
+			return index
+		}
+	}
+	return -1
+}
+
+// findKey - get the value node of a key in a map.  If the key is not in the map itself, then
+// it is looked up in the maps merged into it, in which case merged=true.
+func findKey(mapping *yaml.Node, key string) (value *yaml.Node, merged bool) {
+	if index := ownKeyIndex(mapping, key); index >= 0 {
+		return mapping.Content[index+1], false
+	}
+	for _, source := range mergedMappings(mapping) {
+		if value, _ = findKey(source, key); value != nil {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// mergedMappings - get the maps merged into a map with "<<", where earlier maps take precedence
+func mergedMappings(mapping *yaml.Node) (sources []*yaml.Node) {
+	for index := 0; index+1 < len(mapping.Content); index += 2 {
+		if !isMergeKey(mapping.Content[index]) {
+			continue
+		}
+		value := resolveAlias(mapping.Content[index+1])
+		switch value.Kind {
+		case yaml.MappingNode:
+			sources = append(sources, value)
+		case yaml.SequenceNode:
+			for _, item := range value.Content {
+				if item = resolveAlias(item); item.Kind == yaml.MappingNode {
+					sources = append(sources, item)
+				}
+			}
+		}
+	}
+	return sources
+}
+
+// walkNodes - call visit for the node and all the nodes within it (but not through aliases)
+// with their key paths
+func walkNodes(node *yaml.Node, path string, visit func(node *yaml.Node, path string)) {
+	visit(node, path)
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			walkNodes(child, path, visit)
+		}
+	case yaml.MappingNode:
+		for index := 0; index+1 < len(node.Content); index += 2 {
+			walkNodes(node.Content[index+1], joinPath(path, node.Content[index].Value), visit)
+		}
+	case yaml.SequenceNode:
+		for index, child := range node.Content {
+			walkNodes(child, joinPath(path, strconv.Itoa(index)), visit)
+		}
+	}
+}
+
+// anchorInUse - check if any anchor of the node (or the nodes within it) is used by an alias
+// outside of it
+func anchorInUse(doc *yaml.Node, node *yaml.Node) bool {
+	var (
+		anchored = map[*yaml.Node]bool{}
+		within   = map[*yaml.Node]bool{}
+		inUse    = false
+	)
+	walkNodes(node, "", func(n *yaml.Node, _ string) {
+		within[n] = true
+		if n.Anchor != "" {
+			anchored[n] = true
+		}
+	})
+	if len(anchored) == 0 {
+		return false
+	}
+	walkNodes(doc, "", func(n *yaml.Node, _ string) {
+		if n.Kind == yaml.AliasNode && anchored[n.Alias] && !within[n] {
+			inUse = true
+		}
+	})
+	return inUse
+}
+
+// copyNode - deep copy a node.  Aliases refer to the copies of the anchored nodes.
+func copyNode(node *yaml.Node, copies map[*yaml.Node]*yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if copied, found := copies[node]; found {
+		return copied
+	}
+
+	copied := &yaml.Node{}
+	*copied = *node
+	copies[node] = copied
+
+	if node.Content != nil {
+		copied.Content = make([]*yaml.Node, len(node.Content))
+		for index, child := range node.Content {
+			copied.Content[index] = copyNode(child, copies)
+		}
+	}
+	copied.Alias = copyNode(node.Alias, copies)
+	return copied
+}
+
+// flattenNode - replace the aliases within the node with copies of the anchored nodes, merge
+// the maps of the merge keys and remove the anchors
+func flattenNode(node *yaml.Node) (changed bool) {
+	for index, child := range node.Content {
+		if child.Kind == yaml.AliasNode && child.Alias != nil {
+			copied := copyNode(child.Alias, map[*yaml.Node]*yaml.Node{})
+			copied.HeadComment = child.HeadComment
+			copied.LineComment = child.LineComment
+			copied.FootComment = child.FootComment
+			node.Content[index] = copied
+			changed = true
+		}
+		if flattenNode(node.Content[index]) {
+			changed = true
+		}
+	}
+	if node.Anchor != "" {
+		node.Anchor = ""
+		changed = true
+	}
+
+	if node.Kind == yaml.MappingNode && flattenMergeKeys(node) {
+		changed = true
+	}
+	return changed
+}
+
+// flattenMergeKeys - replace the merge keys of a (flattened) map with the keys of the merged
+// maps that are not in the map itself.  It returns whether the map had any merge keys.
+func flattenMergeKeys(mapping *yaml.Node) bool {
+	var (
+		sources = mergedMappings(mapping)
+		keys    = map[string]bool{}
+		content = []*yaml.Node{}
+	)
+	if len(sources) == 0 {
+		return false
+	}
+	for index := 0; index+1 < len(mapping.Content); index += 2 {
+		if keyNode := mapping.Content[index]; !isMergeKey(keyNode) {
+			keys[keyNode.Value] = true
+		}
+	}
+	for index := 0; index+1 < len(mapping.Content); index += 2 {
+		keyNode, valueNode := mapping.Content[index], mapping.Content[index+1]
+		if !isMergeKey(keyNode) {
+			content = append(content, keyNode, valueNode)
+			continue
+		}
+		for _, source := range mergedMappings(&yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{keyNode, valueNode}}) {
+			for sourceIndex := 0; sourceIndex+1 < len(source.Content); sourceIndex += 2 {
+				if sourceKey := source.Content[sourceIndex]; !keys[sourceKey.Value] {
+					keys[sourceKey.Value] = true
+					content = append(content,
+						copyNode(sourceKey, map[*yaml.Node]*yaml.Node{}),
+						copyNode(source.Content[sourceIndex+1], map[*yaml.Node]*yaml.Node{}))
+				}
+			}
+		}
+	}
+	mapping.Content = content
+	return true
+}
@@ -0,0 +1,115 @@
+package yamldoc
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Yaml anchors and aliases", func() {
+	const anchoredYaml = `
+defaults: &defaults
+  retries: 3
+  timeout: 10
+service-a:
+  <<: *defaults
+  timeout: 20
+service-b:
+  settings: *defaults
+`
+	var (
+		yaml     YamlDoc
+		yamlText = strings.TrimSpace(anchoredYaml)
+	)
+
+	BeforeEach(func() {
+		var err error
+		yaml, err = FromString(yamlText, KeepNodes())
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("keeps the anchors, aliases and merge keys", func() {
+		checkText(yaml, yamlText)
+		checkGetValue(yaml, "service-a.retries", 3)
+		checkGetValue(yaml, "service-a.timeout", 20)
+		checkGetValue(yaml, "service-b.settings.timeout", 10)
+	})
+	It("expands the aliases without the option", func() {
+		yaml, err := FromString(yamlText)
+		Expect(err).ToNot(HaveOccurred())
+		text, err := yaml.Text()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(text).ToNot(ContainSubstring("*defaults"))
+		Expect(yaml.Anchors()).To(BeEmpty())
+	})
+	It("lists the anchors and the aliases that refer to them", func() {
+		Expect(yaml.Anchors()).To(Equal([]Anchor{
+			{Name: "defaults", Path: "defaults", Aliases: []string{"service-a.<<", "service-b.settings"}},
+		}))
+	})
+	It("sets the anchored value through an alias", func() {
+		checkSetValue(yaml, "service-b.settings.retries", 5)
+		checkGetValue(yaml, "defaults.retries", 5)
+		checkGetValue(yaml, "service-a.retries", 5)
+		checkText(yaml, strings.Replace(yamlText, "retries: 3", "retries: 5", 1))
+	})
+	It("overrides a merged key in the map it is merged into", func() {
+		checkSetValue(yaml, "service-a.retries", 7)
+		checkGetValue(yaml, "defaults.retries", 3)
+		checkText(yaml, strings.Replace(yamlText, "timeout: 20", "timeout: 20\n  retries: 7", 1))
+	})
+	It("keeps the anchor of a value that is replaced", func() {
+		checkSetValue(yaml, "defaults", map[string]interface{}{"retries": 1})
+		checkGetValue(yaml, "service-b.settings", map[string]interface{}{"retries": 1})
+		checkGetValue(yaml, "service-a", map[string]interface{}{"retries": 1, "timeout": 20})
+	})
+	It("fails to delete an anchored value that is used by aliases or a merged key", func() {
+		_, err := yaml.Delete("defaults")
+		Expect(err).To(Equal(ErrAnchorInUse))
+		_, err = yaml.Delete("service-a.retries")
+		Expect(err).To(Equal(ErrMergedKey))
+		checkText(yaml, yamlText)
+
+		checkDeleteValue(yaml, "service-b", true)
+		// The merged value is no longer overridden
+		deleted, err := yaml.Delete("service-a.timeout")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deleted).To(BeTrue())
+		checkGetValue(yaml, "service-a.timeout", 10)
+	})
+	It("flattens the aliases", func() {
+		yaml.FlattenAliases()
+		Expect(yaml.Anchors()).To(BeEmpty())
+		checkText(yaml, `defaults:
+  retries: 3
+  timeout: 10
+service-a:
+  retries: 3
+  timeout: 20
+service-b:
+  settings:
+    retries: 3
+    timeout: 10`)
+		Expect(yaml.IsDirty()).To(BeTrue())
+		Expect(yaml.ChangedPaths()).To(BeEmpty())
+
+		// Nothing left to flatten
+		yaml.MarkClean()
+		yaml.FlattenAliases()
+		Expect(yaml.IsDirty()).To(BeFalse())
+	})
+	It("restores the anchors of a snapshot and a clone", func() {
+		clone := yaml.Clone()
+		Expect(yaml.Transaction(func(doc YamlDoc) error {
+			doc.Set("defaults.retries", 5)
+			_, err := doc.Set("defaults.retries.x", "fails")
+			return err
+		})).To(HaveOccurred())
+		checkText(yaml, yamlText)
+
+		checkSetValue(clone, "service-b.settings.retries", 5)
+		checkGetValue(clone, "service-a.retries", 5)
+		checkGetValue(yaml, "service-a.retries", 3)
+	})
+})
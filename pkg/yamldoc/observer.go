@@ -0,0 +1,173 @@
+package yamldoc
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ChangeType - the type of change made to a value in the yaml
+type ChangeType string
+
+const (
+	// ChangeAdded - a value was added at a path that did not exist
+	ChangeAdded ChangeType = "added"
+	// ChangeUpdated - the value at an existing path was replaced with a different value
+	ChangeUpdated ChangeType = "updated"
+	// ChangeDeleted - the value at a path was removed
+	ChangeDeleted ChangeType = "deleted"
+)
+
+// ChangeEvent - describes a change made to a single value in the yaml
+type ChangeEvent struct {
+	// Type - the type of the change
+	Type ChangeType
+	// Path - the key path (in "dot" notation) of the value that changed
+	Path string
+	// OldValue - the value before the change (nil when added)
+	OldValue interface{}
+	// NewValue - the value after the change (nil when deleted)
+	NewValue interface{}
+}
+
+type observer struct {
+	pattern []string
+	notify  func(ev ChangeEvent)
+}
+
+// OnChange - register a function to be called whenever a value matching the path pattern changes.
+//
+// The pattern uses the same "dot" notation as the keys, where a "*" segment matches any single
+// key and a "**" segment matches any number of keys.  A pattern also matches all the paths
+// nested under it, so "a.b" is notified when "a.b.c" changes and "" is notified of all changes.
+//
+// Changes are reported per value, i.e. replacing a map reports one event for each of the
+// values that were added, updated or deleted within it.  Observers are notified by Set,
+// SetWithOptions, Delete, SetData, Merge and Restore (which includes rolled back transactions).
+//
+// The returned function removes the observer.  Observers are not copied by Clone, and they
+// belong to this document only, so they are dropped when a yaml file replaces the document it
+// wraps, i.e. when it is (re)loaded with yamlfile's Load or LoadReader.
+func (y *yamlDoc) OnChange(pathPattern string, notify func(ev ChangeEvent)) (remove func()) {
+	obs := &observer{
+		notify: notify,
+	}
+	if pathPattern != "" {
+		obs.pattern = strings.Split(pathPattern, ".")
+	}
+	y.observers = append(y.observers, obs)
+
+	return func() {
+		for index, o := range y.observers {
+			if o == obs {
+				y.observers = append(y.observers[:index], y.observers[index+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// hasObservers - check if anyone needs to be notified about changes
+func (y *yamlDoc) hasObservers() bool {
+	return len(y.observers) > 0
+}
+
+// notifyChange - notify the observers about the differences between the old and new value at a path
+func (y *yamlDoc) notifyChange(path string, oldValue interface{}, oldExists bool, newValue interface{}, newExists bool) {
+	if !y.hasObservers() {
+		return
+	}
+	for _, ev := range diffValues(path, oldValue, oldExists, newValue, newExists, nil) {
+		for _, obs := range y.observers {
+			if matchPath(obs.pattern, strings.Split(ev.Path, ".")) {
+				obs.notify(ev)
+			}
+		}
+	}
+}
+
+// lookup - get the value at the key path without any conversions
+func (y *yamlDoc) lookup(key string) (value interface{}, found bool) {
+	var currData = y.data
+
+	keys := strings.Split(key, ".")
+	for index, key := range keys {
+		if value, found = currData[key]; !found {
+			return nil, false
+		}
+		if index == len(keys)-1 {
+			break
+		}
+		mapValue, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		currData = mapValue
+	}
+	return
+}
+
+// diffValues - compute the change events between an old and a new value at a path.  Maps are
+// compared key by key, while any other values (including arrays) are compared as a whole.
+func diffValues(path string, oldValue interface{}, oldExists bool, newValue interface{}, newExists bool, events []ChangeEvent) []ChangeEvent {
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+
+	switch {
+	case oldExists && newExists && oldIsMap && newIsMap,
+		oldIsMap && len(oldMap) > 0 && !newExists,
+		newIsMap && len(newMap) > 0 && !oldExists:
+		keys := make([]string, 0, len(oldMap)+len(newMap))
+		for k := range oldMap {
+			keys = append(keys, k)
+		}
+		for k := range newMap {
+			if _, inOld := oldMap[k]; !inOld {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			oldChild, oldChildExists := oldMap[k]
+			newChild, newChildExists := newMap[k]
+			events = diffValues(joinPath(path, k), oldChild, oldChildExists, newChild, newChildExists, events)
+		}
+	case oldExists && !newExists:
+		events = append(events, ChangeEvent{Type: ChangeDeleted, Path: path, OldValue: oldValue})
+	case !oldExists && newExists:
+		events = append(events, ChangeEvent{Type: ChangeAdded, Path: path, NewValue: newValue})
+	case oldExists && newExists && !reflect.DeepEqual(oldValue, newValue):
+		events = append(events, ChangeEvent{Type: ChangeUpdated, Path: path, OldValue: oldValue, NewValue: newValue})
+	}
+	return events
+}
+
+// matchPath - check if the path (or one of its parents) matches the pattern
+func matchPath(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	if pattern[0] == "**" {
+		for index := 0; index <= len(path); index++ {
+			if matchPath(pattern[1:], path[index:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if pattern[0] != "*" && pattern[0] != path[0] {
+		return false
+	}
+	return matchPath(pattern[1:], path[1:])
+}
+
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
@@ -0,0 +1,104 @@
+package yamldoc
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Yaml change notifications", func() {
+	var (
+		yaml     YamlDoc
+		events   []ChangeEvent
+		yamlText = strings.TrimSpace(_SampleYaml)
+		record   = func(ev ChangeEvent) {
+			events = append(events, ev)
+		}
+	)
+
+	BeforeEach(func() {
+		var err error
+		yaml, err = FromString(yamlText)
+		Expect(err).ToNot(HaveOccurred())
+		events = nil
+	})
+
+	It("notifies when a value is added, updated or deleted", func() {
+		yaml.OnChange("a.b.c", record)
+
+		checkSetValue(yaml, "a.b.c", "changed")
+		checkDeleteValue(yaml, "a.b.c", true)
+		checkSetValue(yaml, "a.b.c", "again")
+
+		Expect(events).To(Equal([]ChangeEvent{
+			{Type: ChangeUpdated, Path: "a.b.c", OldValue: "value-c", NewValue: "changed"},
+			{Type: ChangeDeleted, Path: "a.b.c", OldValue: "changed"},
+			{Type: ChangeAdded, Path: "a.b.c", NewValue: "again"},
+		}))
+	})
+	It("does not notify when the value is unchanged or the path does not match", func() {
+		yaml.OnChange("a.b.c", record)
+
+		checkSetValue(yaml, "a.b.c", "value-c")
+		checkSetValue(yaml, "a.d.e", true)
+
+		Expect(events).To(BeEmpty())
+	})
+	It("matches wildcards and nested paths", func() {
+		var wildcardEvents, nestedEvents, allEvents []ChangeEvent
+
+		yaml.OnChange("a.*.f", func(ev ChangeEvent) { wildcardEvents = append(wildcardEvents, ev) })
+		yaml.OnChange("a.d", func(ev ChangeEvent) { nestedEvents = append(nestedEvents, ev) })
+		yaml.OnChange("**.f", func(ev ChangeEvent) { allEvents = append(allEvents, ev) })
+
+		checkSetValue(yaml, "a.d.f", 20)
+		checkSetValue(yaml, "a.d.e", true)
+		checkSetValue(yaml, "f", 1)
+
+		Expect(wildcardEvents).To(HaveLen(1))
+		Expect(wildcardEvents[0].Path).To(Equal("a.d.f"))
+		Expect(nestedEvents).To(HaveLen(2))
+		Expect(allEvents).To(HaveLen(2))
+	})
+	It("reports each value changed when replacing data", func() {
+		yaml.OnChange("", record)
+
+		yaml.SetData(map[string]interface{}{
+			"a": map[string]interface{}{
+				"b": map[string]interface{}{
+					"c": "value-c",
+				},
+				"d": "replaced",
+			},
+			"g": "added",
+		})
+
+		Expect(events).To(ConsistOf(
+			ChangeEvent{Type: ChangeDeleted, Path: "a.custom.bool-prop", OldValue: true},
+			ChangeEvent{Type: ChangeDeleted, Path: "a.custom.int-prop", OldValue: 100},
+			ChangeEvent{Type: ChangeDeleted, Path: "a.custom.string-prop", OldValue: "string-value"},
+			ChangeEvent{Type: ChangeUpdated, Path: "a.d", OldValue: map[string]interface{}{"e": false, "f": 10}, NewValue: "replaced"},
+			ChangeEvent{Type: ChangeAdded, Path: "g", NewValue: "added"},
+		))
+	})
+	It("notifies when a transaction is rolled back", func() {
+		yaml.OnChange("a.b.c", record)
+
+		Expect(yaml.Transaction(func(doc YamlDoc) error {
+			doc.Set("a.b.c", "changed")
+			_, err := doc.Set("a.b.c.x", "fails")
+			return err
+		})).ToNot(Succeed())
+
+		Expect(events).To(HaveLen(2))
+		Expect(events[1]).To(Equal(ChangeEvent{Type: ChangeUpdated, Path: "a.b.c", OldValue: "changed", NewValue: "value-c"}))
+	})
+	It("stops notifying once the observer is removed", func() {
+		remove := yaml.OnChange("a.b.c", record)
+		remove()
+
+		checkSetValue(yaml, "a.b.c", "changed")
+		Expect(events).To(BeEmpty())
+	})
+})
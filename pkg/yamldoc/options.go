@@ -0,0 +1,29 @@
+package yamldoc
+
+// LoadOption - an option for loading a yaml document (see New)
+type LoadOption func(opts *loadOptions)
+
+type loadOptions struct {
+	limits    Limits
+	keepNodes bool
+	tags      *tagOptions
+	// interpolation - the environment variables interpolated in the values (see InterpolateEnv)
+	interpolation *envInterpolation
+	// resolveRefs - resolve the references and includes of the yaml (see ResolveRefs)
+	resolveRefs bool
+	// dir - the directory of the relative paths of the resolved tags and references (see TagsDir)
+	dir string
+	// decryptionKey - the key for decrypting the encrypted values when they are read (see DecryptWith)
+	decryptionKey []byte
+	// refs - the resolver of the files being included (see resolveInclude), for detecting
+	// circular includes and limiting how much they expand
+	refs *refResolver
+}
+
+func newLoadOptions(opts []LoadOption) *loadOptions {
+	result := &loadOptions{}
+	for _, opt := range opts {
+		opt(result)
+	}
+	return result
+}
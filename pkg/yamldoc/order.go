@@ -0,0 +1,293 @@
+package yamldoc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrBeforeAndAfter - error generated when both SetOptions.Before and SetOptions.After are specified
+var ErrBeforeAndAfter = errors.New("Only one of the keys to set the key before or after can be specified")
+
+// SetOptions - where to place the key set with SetWithOptions in its map
+type SetOptions struct {
+	// Before - the key (in the same map) to place the key before
+	Before string
+	// After - the key (in the same map) to place the key after
+	After string
+}
+
+// SortOptions - how to sort with SortKeys
+type SortOptions struct {
+	// Recursive - also sort the maps within the value (and, with By, the arrays of maps within it)
+	Recursive bool
+	// By - sort the arrays of maps by the value of this key of their maps, instead of by their items
+	By string
+}
+
+// Comparator - reports whether a sorts before b, where a and b are the keys of a map or the
+// values of the items of an array (see SortKeys)
+type Comparator func(a, b interface{}) bool
+
+// DefaultComparator - sorts numbers by their value and any other values by their text, with
+// the numbers first and the missing (nil) values last.  The keys of the maps are compared as the values they are
+// written as, so the number keys (e.g. "10: x") sort by their value, while the quoted ones
+// (e.g. '"10": x') sort by their text.
+func DefaultComparator(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a != nil
+	}
+	aNumber, aIsNumber := toFloat(a)
+	bNumber, bIsNumber := toFloat(b)
+	if aIsNumber && bIsNumber {
+		return aNumber < bNumber
+	} else if aIsNumber || bIsNumber {
+		return aIsNumber
+	}
+	return fmt.Sprintf("%v", a) < fmt.Sprintf("%v", b)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch x := value.(type) {
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+// SetWithOptions - set the value at key (see Set) and place the key before or after another
+// key of the same map.  A new key is inserted there, while an existing key is moved there.  If
+// neither Before nor After are specified, then this is the same as Set.  The nodes of the yaml
+// must be kept (see KeepNodes), otherwise ErrNodesNotKept is returned.
+func (y *yamlDoc) SetWithOptions(key string, value interface{}, opts SetOptions) (valueSet bool, err error) {
+	if opts.Before == "" && opts.After == "" {
+		return y.Set(key, value)
+	}
+	if key == "" {
+		return false, ErrEmptyKey
+	}
+	if opts.Before != "" && opts.After != "" {
+		return false, ErrBeforeAndAfter
+	}
+	if y.doc == nil {
+		return false, ErrNodesNotKept
+	}
+
+	var (
+		keys      = strings.Split(key, ".")
+		lastKey   = keys[len(keys)-1]
+		parentKey = strings.Join(keys[:len(keys)-1], ".")
+		sibling   = opts.Before + opts.After
+	)
+	if err = y.applyToNodes(func(root *yaml.Node) error {
+		// The other key must be in the map already, so it is checked before setting the value
+		mapping := root
+		if parentKey != "" {
+			if _, mapping = findKeyNodes(root, keys[:len(keys)-1]); mapping == nil {
+				return errors.Wrapf(ErrKeyNotFound, "Key '%s'", parentKey)
+			}
+		}
+		if mapping = resolveAlias(mapping); mapping.Kind != yaml.MappingNode || ownKeyIndex(mapping, sibling) < 0 {
+			return errors.Wrapf(ErrKeyNotFound, "Key '%s'", strings.TrimPrefix(parentKey+"."+sibling, "."))
+		}
+		if err := setNode(root, keys, value); err != nil {
+			return err
+		}
+		if moveKey(mapping, lastKey, sibling, opts.After != "") {
+			y.nodesChanged = true
+		}
+		return nil
+	}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// moveKey - move a key of a map before (or after) another key of the map
+func moveKey(mapping *yaml.Node, key, sibling string, after bool) (moved bool) {
+	index := ownKeyIndex(mapping, key)
+	if index < 0 || key == sibling {
+		return false
+	}
+	pair := append([]*yaml.Node{}, mapping.Content[index:index+2]...)
+	content := append(append([]*yaml.Node{}, mapping.Content[:index]...), mapping.Content[index+2:]...)
+
+	target := ownKeyIndex(&yaml.Node{Content: content}, sibling)
+	if after {
+		target += 2
+	}
+	content = append(content[:target], append(pair, content[target:]...)...)
+	if target == index {
+		return false
+	}
+	mapping.Content = content
+	return true
+}
+
+// SortKeys - sort the keys of the map at key (or of the whole yaml when the key is empty) or
+// the items of the array at key, using the comparator (or DefaultComparator if nil).  The maps
+// merged with "<<" stay first.  Arrays are sorted by their items or, with By, by the value of
+// that key of their maps.  With Recursive, the maps within the value are sorted too, as well as
+// the arrays of maps within it when By is specified.  The nodes of the yaml must be kept (see
+// KeepNodes), otherwise ErrNodesNotKept is returned.
+func (y *yamlDoc) SortKeys(key string, comparator Comparator, opts SortOptions) error {
+	if y.doc == nil {
+		return ErrNodesNotKept
+	}
+	if comparator == nil {
+		comparator = DefaultComparator
+	}
+
+	return y.applyToNodes(func(root *yaml.Node) error {
+		node := root
+		if key != "" {
+			if _, node = findKeyNodes(root, strings.Split(key, ".")); node == nil {
+				return ErrKeyNotFound
+			}
+		}
+		if node = resolveAlias(node); node.Kind != yaml.MappingNode && node.Kind != yaml.SequenceNode {
+			return fmt.Errorf("key '%s' is not a map or an array", key)
+		}
+		sorter := &nodeSorter{comparator: comparator, opts: opts}
+		sorter.sort(node, true)
+		if sorter.changed {
+			y.nodesChanged = true
+		}
+		return nil
+	})
+}
+
+type nodeSorter struct {
+	comparator Comparator
+	opts       SortOptions
+	changed    bool
+	// visited - the nodes already sorted, since aliases can refer to them more than once
+	visited map[*yaml.Node]bool
+}
+
+func (s *nodeSorter) sort(node *yaml.Node, top bool) {
+	if node = resolveAlias(node); s.visited[node] {
+		return
+	} else if s.visited == nil {
+		s.visited = map[*yaml.Node]bool{}
+	}
+	s.visited[node] = true
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		s.sortMapping(node)
+		if s.opts.Recursive {
+			for index := 1; index < len(node.Content); index += 2 {
+				if !isMergeKey(node.Content[index-1]) {
+					s.sort(node.Content[index], false)
+				}
+			}
+		}
+	case yaml.SequenceNode:
+		if top || (s.opts.By != "" && hasKeyInItems(node, s.opts.By)) {
+			s.sortSequence(node)
+		}
+		if s.opts.Recursive {
+			for _, item := range node.Content {
+				s.sort(item, false)
+			}
+		}
+	}
+}
+
+func (s *nodeSorter) sortMapping(mapping *yaml.Node) {
+	var merges, pairs [][]*yaml.Node
+
+	for index := 0; index+1 < len(mapping.Content); index += 2 {
+		if pair := mapping.Content[index : index+2]; isMergeKey(pair[0]) {
+			merges = append(merges, pair)
+		} else {
+			pairs = append(pairs, pair)
+		}
+	}
+	keys := make(map[*yaml.Node]interface{}, len(pairs))
+	for _, pair := range pairs {
+		keys[pair[0]] = keyValue(pair[0])
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return s.comparator(keys[pairs[i][0]], keys[pairs[j][0]])
+	})
+
+	content := make([]*yaml.Node, 0, len(mapping.Content))
+	for _, pair := range append(merges, pairs...) {
+		content = append(content, pair...)
+	}
+	s.setContent(mapping, content)
+}
+
+func (s *nodeSorter) sortSequence(sequence *yaml.Node) {
+	var (
+		items  = append([]*yaml.Node{}, sequence.Content...)
+		values = make(map[*yaml.Node]interface{}, len(items))
+	)
+	for _, item := range items {
+		values[item] = s.sortValue(item)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return s.comparator(values[items[i]], values[items[j]])
+	})
+	s.setContent(sequence, items)
+}
+
+// sortValue - the value of an array item to sort by
+func (s *nodeSorter) sortValue(item *yaml.Node) (value interface{}) {
+	if item = resolveAlias(item); s.opts.By != "" {
+		if item.Kind != yaml.MappingNode {
+			return nil
+		}
+		if item, _ = findKey(item, s.opts.By); item == nil {
+			return nil
+		}
+	}
+	if err := item.Decode(&value); err != nil {
+		return nil
+	}
+	return value
+}
+
+// keyValue - the value of a map key to sort by, e.g. the number 10 for "10: x" but the string
+// "10" for the quoted key '"10": x'
+func keyValue(keyNode *yaml.Node) interface{} {
+	var value interface{}
+
+	if keyNode.Kind != yaml.ScalarNode || keyNode.Decode(&value) != nil || value == nil {
+		return keyNode.Value
+	}
+	return value
+}
+
+func (s *nodeSorter) setContent(node *yaml.Node, content []*yaml.Node) {
+	for index := range content {
+		if content[index] != node.Content[index] {
+			node.Content = content
+			s.changed = true
+			return
+		}
+	}
+}
+
+// hasKeyInItems - check if any of the maps of the array has the key
+func hasKeyInItems(sequence *yaml.Node, key string) bool {
+	for _, item := range sequence.Content {
+		if item = resolveAlias(item); item.Kind == yaml.MappingNode {
+			if value, _ := findKey(item, key); value != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
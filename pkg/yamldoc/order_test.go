@@ -0,0 +1,148 @@
+package yamldoc
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+var _ = Describe("Yaml key ordering", func() {
+	const orderedYaml = `
+name: service-a
+port: 8080
+settings:
+  timeout: 10
+  retries: 3
+users:
+  - name: mary
+    id: 2
+  - name: bob
+    id: 10
+  - name: alice
+`
+	var (
+		yaml     YamlDoc
+		yamlText = strings.TrimSpace(orderedYaml)
+	)
+
+	BeforeEach(func() {
+		var err error
+		yaml, err = FromString(yamlText, KeepNodes())
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Context("Setting a key before or after another key", func() {
+		It("inserts a new key before another key", func() {
+			_, err := yaml.SetWithOptions("host", "localhost", SetOptions{Before: "port"})
+			Expect(err).ToNot(HaveOccurred())
+			checkText(yaml, strings.Replace(yamlText, "port:", "host: localhost\nport:", 1))
+		})
+		It("inserts a new key after another key of a nested map", func() {
+			_, err := yaml.SetWithOptions("settings.delay", 5, SetOptions{After: "timeout"})
+			Expect(err).ToNot(HaveOccurred())
+			checkText(yaml, strings.Replace(yamlText, "timeout: 10\n", "timeout: 10\n  delay: 5\n", 1))
+		})
+		It("moves an existing key", func() {
+			_, err := yaml.SetWithOptions("settings.retries", 3, SetOptions{Before: "timeout"})
+			Expect(err).ToNot(HaveOccurred())
+			checkText(yaml, strings.Replace(yamlText, "timeout: 10\n  retries: 3", "retries: 3\n  timeout: 10", 1))
+			Expect(yaml.IsDirty()).To(BeTrue())
+		})
+		It("appends the key without the options", func() {
+			_, err := yaml.SetWithOptions("host", "localhost", SetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			checkText(yaml, yamlText+"\nhost: localhost")
+		})
+		It("fails when the other key is not in the map", func() {
+			_, err := yaml.SetWithOptions("settings.delay", 5, SetOptions{After: "port"})
+			Expect(errors.Cause(err)).To(Equal(ErrKeyNotFound))
+			Expect(err).To(MatchError(ContainSubstring("Key 'settings.port'")))
+			checkText(yaml, yamlText)
+		})
+		It("fails when both options are specified", func() {
+			_, err := yaml.SetWithOptions("host", "localhost", SetOptions{Before: "port", After: "name"})
+			Expect(err).To(Equal(ErrBeforeAndAfter))
+		})
+		It("fails when the nodes are not kept", func() {
+			yaml, err := FromString(yamlText)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = yaml.SetWithOptions("host", "localhost", SetOptions{Before: "port"})
+			Expect(err).To(Equal(ErrNodesNotKept))
+		})
+	})
+	Context("Sorting keys", func() {
+		It("sorts the keys of a map", func() {
+			Expect(yaml.SortKeys("settings", nil, SortOptions{})).To(Succeed())
+			checkText(yaml, strings.Replace(yamlText, "timeout: 10\n  retries: 3", "retries: 3\n  timeout: 10", 1))
+			Expect(yaml.IsDirty()).To(BeTrue())
+		})
+		It("sorts the number keys by their value", func() {
+			yaml, err := FromString("codes:\n  10: ten\n  9: nine\n  \"100\": hundred\n  b: bee\n", KeepNodes())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(yaml.SortKeys("codes", nil, SortOptions{})).To(Succeed())
+			checkText(yaml, "codes:\n  9: nine\n  10: ten\n  \"100\": hundred\n  b: bee")
+		})
+		It("sorts the keys of the whole yaml recursively with a comparator", func() {
+			reverse := func(a, b interface{}) bool {
+				return DefaultComparator(b, a)
+			}
+			Expect(yaml.SortKeys("", reverse, SortOptions{Recursive: true})).To(Succeed())
+			checkText(yaml, `users:
+  - name: mary
+    id: 2
+  - name: bob
+    id: 10
+  - name: alice
+settings:
+  timeout: 10
+  retries: 3
+port: 8080
+name: service-a`)
+		})
+		It("sorts an array of maps by the value of a key", func() {
+			Expect(yaml.SortKeys("users", nil, SortOptions{By: "id"})).To(Succeed())
+			checkGetValue(yaml, "users", []interface{}{
+				map[string]interface{}{"name": "mary", "id": 2},
+				map[string]interface{}{"name": "bob", "id": 10},
+				map[string]interface{}{"name": "alice"},
+			})
+			Expect(yaml.SortKeys("users", nil, SortOptions{By: "name"})).To(Succeed())
+			value, err := yaml.Get("users")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value.([]interface{})[0]).To(HaveKeyWithValue("name", "alice"))
+		})
+		It("sorts the arrays of maps within recursively", func() {
+			Expect(yaml.SortKeys("", nil, SortOptions{Recursive: true, By: "name"})).To(Succeed())
+			checkText(yaml, `name: service-a
+port: 8080
+settings:
+  retries: 3
+  timeout: 10
+users:
+  - name: alice
+  - id: 10
+    name: bob
+  - id: 2
+    name: mary`)
+		})
+		It("keeps the merge keys first", func() {
+			yaml, err := FromString("base: &base\n  b: 1\nitem:\n  z: 1\n  <<: *base\n  a: 2", KeepNodes())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(yaml.SortKeys("item", nil, SortOptions{})).To(Succeed())
+			checkText(yaml, "base: &base\n  b: 1\nitem:\n  <<: *base\n  a: 2\n  z: 1")
+		})
+		It("does not change the yaml when the keys are already sorted", func() {
+			Expect(yaml.SortKeys("settings", nil, SortOptions{})).To(Succeed())
+			Expect(yaml.IsDirty()).To(BeTrue())
+			yaml.MarkClean()
+			Expect(yaml.SortKeys("settings", nil, SortOptions{})).To(Succeed())
+			Expect(yaml.IsDirty()).To(BeFalse())
+		})
+		It("fails for values that are not maps or arrays", func() {
+			Expect(yaml.SortKeys("name", nil, SortOptions{})).To(MatchError("key 'name' is not a map or an array"))
+			Expect(yaml.SortKeys("missing", nil, SortOptions{})).To(Equal(ErrKeyNotFound))
+		})
+	})
+})
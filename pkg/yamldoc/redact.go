@@ -0,0 +1,243 @@
+package yamldoc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// RedactStrategy - how the redacted values are replaced
+type RedactStrategy string
+
+const (
+	// RedactMask - replace the values with a fixed mask (see RedactedMask)
+	RedactMask RedactStrategy = "mask"
+	// RedactHashPrefix - replace the values with the prefix of their SHA-256 hash, e.g.
+	// "sha256:1a2b3c4d", so that equal values can still be told apart from different ones
+	RedactHashPrefix RedactStrategy = "hash"
+	// RedactKeepLength - replace each character of the values with "*", keeping their length
+	RedactKeepLength RedactStrategy = "keep-length"
+
+	// RedactedMask - the mask of the values redacted with RedactMask
+	RedactedMask = "[REDACTED]"
+)
+
+// RedactStrategies - the valid redact strategies
+var RedactStrategies = []RedactStrategy{RedactMask, RedactHashPrefix, RedactKeepLength}
+
+// RedactRule - the values to redact and how.  A value is redacted when its path matches the
+// path pattern, one of its keys matches the key regex or it matches the value regex, in which
+// case only the parts of the value matching the regex are redacted.
+type RedactRule struct {
+	// Path - the path pattern of the values to redact, e.g. "db.password" or "**.credentials".  As
+	// with OnChange, a "*" segment matches any single key, a "**" segment any number of keys and all
+	// the values nested under a matching path are redacted too.
+	Path string
+	// Key - the regex matching one of the keys of the paths of the values to redact, e.g. "(?i)password"
+	Key string
+	// Value - the regex matching the (parts of the) values to redact, e.g. PEM blocks
+	Value string
+	// Strategy - how the values are replaced (RedactMask if not specified)
+	Strategy RedactStrategy
+}
+
+// DefaultRedactRules - the rules redacting the values of the keys with the words "password",
+// "token", "secret" or "key" (in lower, title or upper case), e.g. "db_password", "apiKey",
+// "API_KEY" or "tokens".  The words must be separated from the rest of the keys by "_", "-",
+// non-letters or camel case, so keys like "monkey" or "keyboard" are not redacted.  Go regexes
+// have no lookarounds, so the boundaries are matched as characters (or the start and end).
+var DefaultRedactRules = []RedactRule{
+	// e.g. "password", "db_password" or "auth-tokens"
+	{Key: `(^|[^a-zA-Z])((password|token|secret)s?|key)($|[^a-z])`},
+	// e.g. "dbPassword", "apiKey" or "KeyID"
+	{Key: `((Password|Token|Secret)s?|Key)($|[^a-z])`},
+	// e.g. "DB_PASSWORD" or "API_KEY"
+	{Key: `(^|[^A-Z])((PASSWORD|TOKEN|SECRET)S?|KEY)($|[^A-Z])`},
+}
+
+// compiledRedactRule - a redact rule with its patterns parsed
+type compiledRedactRule struct {
+	path     []string
+	key      *regexp.Regexp
+	value    *regexp.Regexp
+	strategy RedactStrategy
+}
+
+// Redact - get a copy of the yaml with the values matching the rules redacted (see
+// DefaultRedactRules), e.g. for sharing it.  The keys, the comments (when the nodes are kept) and
+// the structure of the yaml stay the same, so the redacted yaml is still valid yaml.  The values
+// matched by several rules are redacted with the first rule.  When the nodes are kept, the values
+// of anchors are redacted where they are anchored, so an anchored value redacted through one of
+// its aliases (or a map merging it with "<<") is redacted for all of them.
+func Redact(doc YamlDoc, rules []RedactRule) (YamlDoc, error) {
+	compiledRules := make([]*compiledRedactRule, len(rules))
+	for index, rule := range rules {
+		compiled, err := compileRedactRule(rule)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid redact rule %d", index+1)
+		}
+		compiledRules[index] = compiled
+	}
+
+	result, ok := doc.Clone().(*yamlDoc)
+	if !ok {
+		result = &yamlDoc{data: deepCopy(doc.Data()).(map[string]interface{})}
+	}
+	redact := func(path []string, value interface{}) (interface{}, bool) {
+		if value == nil {
+			return value, false
+		}
+		for _, rule := range compiledRules {
+			if redacted, matched := rule.redact(path, value); matched {
+				return redacted, true
+			}
+		}
+		return value, false
+	}
+
+	if result.doc != nil {
+		if err := result.applyToNodes(func(root *yaml.Node) error {
+			return redactNodes(root, nil, redact, map[*yaml.Node]bool{})
+		}); err != nil {
+			return nil, err
+		}
+	} else if _, err := result.transformValues(func(path []string, value interface{}) (interface{}, bool, error) {
+		redacted, matched := redact(path, value)
+		return redacted, matched, nil
+	}); err != nil {
+		return nil, err
+	}
+	result.MarkClean()
+	return result, nil
+}
+
+// redactNodes - redact the values of the nodes at their key paths, following the aliases and the
+// merge keys ("<<"), so that the anchored values are redacted where they are anchored rather than
+// overridden in the maps they are merged into
+func redactNodes(node *yaml.Node, path []string, redact func(path []string, value interface{}) (interface{}, bool),
+	redacted map[*yaml.Node]bool) error {
+	node = resolveAlias(node)
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for _, key := range mappingKeys(node) {
+			child, _ := findKey(node, key)
+			if err := redactNodes(child, append(path[:len(path):len(path)], key), redact, redacted); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for index, item := range node.Content {
+			if err := redactNodes(item, append(path[:len(path):len(path)], strconv.Itoa(index)), redact, redacted); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		var value interface{}
+
+		if redacted[node] {
+			return nil
+		}
+		if err := node.Decode(&value); err != nil {
+			return errors.Wrapf(err, "Key '%s'", strings.Join(path, "."))
+		}
+		if newValue, matched := redact(path, value); matched {
+			valueNode := &yaml.Node{}
+			if err := valueNode.Encode(newValue); err != nil {
+				return err
+			}
+			replaceNode(node, valueNode)
+			redacted[node] = true
+		}
+	}
+	return nil
+}
+
+// mappingKeys - get the keys of a map, including the keys merged into it with "<<"
+func mappingKeys(mapping *yaml.Node) (keys []string) {
+	seen := map[string]bool{}
+	add := func(key string) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	for index := 0; index+1 < len(mapping.Content); index += 2 {
+		if keyNode := mapping.Content[index]; !isMergeKey(keyNode) {
+			add(keyNode.Value)
+		}
+	}
+	for _, source := range mergedMappings(mapping) {
+		for _, key := range mappingKeys(source) {
+			add(key)
+		}
+	}
+	return keys
+}
+
+func compileRedactRule(rule RedactRule) (result *compiledRedactRule, err error) {
+	result = &compiledRedactRule{strategy: rule.Strategy}
+
+	switch rule.Strategy {
+	case "":
+		result.strategy = RedactMask
+	case RedactMask, RedactHashPrefix, RedactKeepLength:
+	default:
+		return nil, fmt.Errorf("invalid strategy '%s'", rule.Strategy)
+	}
+	if rule.Path == "" && rule.Key == "" && rule.Value == "" {
+		return nil, errors.New("no path, key or value specified")
+	}
+	if rule.Path != "" {
+		result.path = strings.Split(rule.Path, ".")
+	}
+	if rule.Key != "" {
+		if result.key, err = regexp.Compile(rule.Key); err != nil {
+			return nil, err
+		}
+	}
+	if rule.Value != "" {
+		if result.value, err = regexp.Compile(rule.Value); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// redact - redact the value if it matches the rule
+func (r *compiledRedactRule) redact(path []string, value interface{}) (interface{}, bool) {
+	text := fmt.Sprint(value)
+
+	if r.path != nil && matchPath(r.path, path) {
+		return r.replace(text), true
+	}
+	if r.key != nil {
+		for _, key := range path {
+			if _, err := strconv.Atoi(key); err != nil && r.key.MatchString(key) {
+				return r.replace(text), true
+			}
+		}
+	}
+	if r.value != nil && r.value.MatchString(text) {
+		return r.value.ReplaceAllStringFunc(text, r.replace), true
+	}
+	return value, false
+}
+
+// replace - replace the text according to the strategy
+func (r *compiledRedactRule) replace(text string) string {
+	switch r.strategy {
+	case RedactHashPrefix:
+		sum := sha256.Sum256([]byte(text))
+		return "sha256:" + hex.EncodeToString(sum[:4])
+	case RedactKeepLength:
+		return strings.Repeat("*", len([]rune(text)))
+	}
+	return RedactedMask
+}
@@ -0,0 +1,117 @@
+package yamldoc
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Yaml redaction", func() {
+	const sensitiveYaml = `
+# The database settings
+db:
+  host: db.example.com
+  password: s3cr3t # rotated monthly
+  port: 5432
+api:
+  apiKey: abc123
+  tokens:
+    - first
+    - second
+cert: |-
+  -----BEGIN CERTIFICATE-----
+  MIIBszCCAVmgAwIBAgIU
+  -----END CERTIFICATE-----
+`
+	var (
+		yaml     YamlDoc
+		yamlText = strings.TrimSpace(sensitiveYaml)
+	)
+
+	BeforeEach(func() {
+		var err error
+		yaml, err = FromString(yamlText, KeepNodes())
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("redacts the values of the default rules and keeps the keys and comments", func() {
+		redacted, err := Redact(yaml, DefaultRedactRules)
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(redacted, "db.password", RedactedMask)
+		checkGetValue(redacted, "db.host", "db.example.com")
+		checkGetValue(redacted, "api.apiKey", RedactedMask)
+		checkGetValue(redacted, "api.tokens", []interface{}{RedactedMask, RedactedMask})
+
+		text, err := redacted.Text()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(text).To(HavePrefix("# The database settings\ndb:\n  host: db.example.com\n  password: '[REDACTED]' # rotated monthly\n"))
+		Expect(redacted.IsDirty()).To(BeFalse())
+
+		// The original yaml is not changed
+		checkText(yaml, yamlText)
+	})
+	It("redacts the values of the keys with the words of the default rules only", func() {
+		yaml, err := FromString(strings.Join([]string{
+			"monkey: banana",
+			"keys: [a, b]",
+			"keyboard: {layout: us}",
+			"tokenizer: simple",
+			"DB_PASSWORD: a",
+			"api-key: b",
+			"apiKey: c",
+			"KeyID: d",
+			"secrets: {db: e}",
+			"key2: f",
+		}, "\n"))
+		Expect(err).ToNot(HaveOccurred())
+
+		redacted, err := Redact(yaml, DefaultRedactRules)
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(redacted, "monkey", "banana")
+		checkGetValue(redacted, "keys", []interface{}{"a", "b"})
+		checkGetValue(redacted, "keyboard.layout", "us")
+		checkGetValue(redacted, "tokenizer", "simple")
+		for _, key := range []string{"DB_PASSWORD", "api-key", "apiKey", "KeyID", "secrets.db", "key2"} {
+			checkGetValue(redacted, key, RedactedMask)
+		}
+	})
+	It("redacts the values with the strategies of the rules", func() {
+		redacted, err := Redact(yaml, []RedactRule{
+			{Path: "db.password", Strategy: RedactKeepLength},
+			{Path: "api.*", Strategy: RedactHashPrefix},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(redacted, "db.password", "******")
+		checkGetValue(redacted, "db.port", 5432)
+		checkGetValue(redacted, "api.apiKey", "sha256:6ca13d52")
+		checkGetValue(redacted, "cert", "-----BEGIN CERTIFICATE-----\nMIIBszCCAVmgAwIBAgIU\n-----END CERTIFICATE-----")
+	})
+	It("redacts only the parts of the values matching the value regex", func() {
+		redacted, err := Redact(yaml, []RedactRule{
+			{Value: `(?s)-----BEGIN [A-Z ]+-----.*?-----END [A-Z ]+-----`},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(redacted, "cert", RedactedMask)
+		checkGetValue(redacted, "db.password", "s3cr3t")
+	})
+	It("redacts the anchored values merged into the maps of the values redacted", func() {
+		yaml, err := FromString("secret: {<<: &base {a: 1}, b: 2}\nother: *base", KeepNodes())
+		Expect(err).ToNot(HaveOccurred())
+
+		redacted, err := Redact(yaml, DefaultRedactRules)
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(redacted, "secret", map[string]interface{}{"a": RedactedMask, "b": RedactedMask})
+		checkText(redacted, "secret: {<<: &base {a: '[REDACTED]'}, b: '[REDACTED]'}\nother: *base")
+	})
+	It("fails with invalid rules", func() {
+		_, err := Redact(yaml, []RedactRule{{Key: "("}})
+		Expect(err).To(MatchError(HavePrefix("Invalid redact rule 1")))
+
+		_, err = Redact(yaml, []RedactRule{{Path: "db", Strategy: "other"}})
+		Expect(err).To(MatchError(ContainSubstring("invalid strategy 'other'")))
+
+		_, err = Redact(yaml, []RedactRule{{Strategy: RedactMask}})
+		Expect(err).To(HaveOccurred())
+	})
+})
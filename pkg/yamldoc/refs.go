@@ -0,0 +1,326 @@
+package yamldoc
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// refKey - the key of the JSON References, e.g. "$ref: ./common.yaml#/logging"
+const refKey = "$ref"
+
+// CircularRefError - error generated when references (or includes) refer back to themselves,
+// e.g. "a.yaml" including "b.yaml", which includes "a.yaml"
+type CircularRefError struct {
+	// Chain - the references from the first one that is referred to again up to that reference
+	Chain []string
+}
+
+func (e *CircularRefError) Error() string {
+	return fmt.Sprintf("Circular reference: %s", strings.Join(e.Chain, " -> "))
+}
+
+// IsCircularRefError - check if the error is (or is caused by) a circular reference error
+func IsCircularRefError(err error) bool {
+	var circularErr *CircularRefError
+	return errors.As(err, &circularErr)
+}
+
+// ResolveRefs - resolve the JSON References (e.g. "$ref: ./common.yaml#/logging") and the
+// "!include" tags (e.g. "!include ./common.yaml#/logging") when the yaml is loaded, replacing
+// them with the values they refer to:
+//
+// - The path of the file is relative to the file that refers to it (see TagsDir for the yaml
+// loaded), while references without a path (e.g. "#/definitions/user") refer to the same file.
+//
+// - The fragment (after "#") is a JSON Pointer to the value within the file, e.g. "/logging" or
+// "/servers/0".  Without a fragment, the reference is to the whole file.
+//
+// - The other keys of a map with a "$ref" key are ignored.
+//
+// References that refer back to themselves fail with a *CircularRefError (see
+// IsCircularRefError).  The values referred to count towards the MaxAliasExpansion limit (see
+// Limits), as they expand the yaml just like aliases do.  Since the references are replaced,
+// writing out the yaml writes out the values they refer to, i.e. a fully inlined yaml.
+func ResolveRefs() LoadOption {
+	return func(opts *loadOptions) {
+		opts.resolveRefs = true
+	}
+}
+
+// refResolver - resolves the references (and includes) of a yaml.  The "$ref" keys and the
+// "!include" tags resolved with ResolveRefs, as well as the "!include" tags resolved by their
+// tag resolver (see resolveInclude), share the way the files are loaded, how circular
+// references are detected and how much the references may expand the yaml.
+type refResolver struct {
+	opts *loadOptions
+	// roots - the nodes of the files referred to, by their absolute paths
+	roots map[string]*yaml.Node
+	// chain - the references being resolved, for detecting circular references
+	chain []refLink
+	// expanded - the number of nodes of the values referred to so far
+	expanded int
+}
+
+// refLink - a reference being resolved
+type refLink struct {
+	// key - the absolute path of the file (or "" for the yaml loaded) and the pointer, since
+	// the same value can be referred to with different relative paths
+	key string
+	// name - the name of the reference in the errors (see displayName)
+	name string
+}
+
+func newRefResolver(opts *loadOptions) *refResolver {
+	return &refResolver{
+		opts:  opts,
+		roots: map[string]*yaml.Node{},
+	}
+}
+
+// resolve - resolve the references of the nodes of the yaml loaded
+func (r *refResolver) resolve(root *yaml.Node) error {
+	return r.resolveNodes(root, "", root)
+}
+
+// resolveNodes - resolve the references within the node of a file ("" for the yaml loaded)
+func (r *refResolver) resolveNodes(node *yaml.Node, file string, root *yaml.Node) error {
+	var ref string
+
+	switch {
+	case node.Kind == yaml.MappingNode:
+		if index := ownKeyIndex(node, refKey); index >= 0 && node.Content[index+1].Kind == yaml.ScalarNode {
+			ref = node.Content[index+1].Value
+		}
+	case node.Kind == yaml.ScalarNode && node.Tag == TagInclude:
+		ref = node.Value
+	}
+	if ref == "" {
+		for _, child := range node.Content {
+			if err := r.resolveNodes(child, file, root); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	target, err := r.resolveRef(ref, file, root)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to resolve '%s'", ref)
+	}
+	replaceNode(node, target)
+	return nil
+}
+
+// resolveRef - get a copy of the node referred to, with its references resolved
+func (r *refResolver) resolveRef(ref, file string, root *yaml.Node) (*yaml.Node, error) {
+	path, pointer := splitRef(ref)
+
+	if path != "" {
+		var err error
+
+		if path, err = filepath.Abs(r.path(file, path)); err != nil {
+			return nil, err
+		}
+		if root, err = r.loadRoot(path); err != nil {
+			return nil, err
+		}
+		file = path
+	}
+
+	leave, err := r.enter(file, pointer)
+	if err != nil {
+		return nil, err
+	}
+	defer leave()
+
+	node, err := r.pointerNode(root, pointer)
+	if err != nil {
+		return nil, err
+	}
+	node = copyNode(resolveAlias(node), map[*yaml.Node]*yaml.Node{})
+	if err = r.resolveNodes(node, file, root); err != nil {
+		return nil, err
+	}
+	node.Anchor = ""
+	return node, nil
+}
+
+// enter - start resolving a reference to the pointer within the file (the absolute path of the
+// file or "" for the yaml loaded).  If the reference is already being resolved, then it refers
+// back to itself and a *CircularRefError is returned.  Call the returned function once the
+// reference is resolved.
+func (r *refResolver) enter(file, pointer string) (leave func(), err error) {
+	link := refLink{key: file + "#" + pointer, name: r.displayName(file, pointer)}
+
+	for index, resolving := range r.chain {
+		if resolving.key == link.key {
+			chain := []string{}
+			for _, circular := range append(r.chain[index:], link) {
+				chain = append(chain, circular.name)
+			}
+			return nil, &CircularRefError{Chain: chain}
+		}
+	}
+	r.chain = append(r.chain, link)
+
+	return func() {
+		r.chain = r.chain[:len(r.chain)-1]
+	}, nil
+}
+
+// pointerNode - get the node that the JSON Pointer refers to, counting its nodes (with their
+// aliases expanded) towards the MaxAliasExpansion limit
+func (r *refResolver) pointerNode(root *yaml.Node, pointer string) (*yaml.Node, error) {
+	node, err := pointerNode(root, pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	counter := &nodeCounter{
+		expanded: map[*yaml.Node]nodeCount{},
+	}
+	nodes := counter.count(node).nodes
+	if max := r.opts.limits.MaxAliasExpansion; max > 0 && nodes > max-r.expanded {
+		return nil, &LimitError{Limit: LimitAliasExpansion, Max: int64(max)}
+	}
+	r.expanded += nodes
+	return node, nil
+}
+
+// path - get the path of a file referred to by a file ("" for the yaml loaded)
+func (r *refResolver) path(file, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	if file != "" {
+		return filepath.Join(filepath.Dir(file), path)
+	}
+	return filepath.Join(r.opts.dir, path)
+}
+
+// displayName - the name of a reference in the errors, relative to the yaml loaded
+func (r *refResolver) displayName(file, pointer string) string {
+	name := file
+	if dir, err := filepath.Abs(r.opts.dir); err == nil && file != "" {
+		if relative, err := filepath.Rel(dir, file); err == nil {
+			name = relative
+		}
+	}
+	if pointer != "" {
+		name += "#" + pointer
+	}
+	return name
+}
+
+// loadRoot - load the nodes of a file referred to, with the same options as the yaml loaded
+func (r *refResolver) loadRoot(path string) (*yaml.Node, error) {
+	if root, found := r.roots[path]; found {
+		return root, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	contents, err := readAll(file, r.opts.limits.MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	if contents, _, err = ToUTF8(contents); err != nil {
+		return nil, err
+	}
+
+	root := &yaml.Node{}
+	if err = yaml.NewDecoder(bytes.NewReader(contents)).Decode(root); err != nil {
+		return nil, err
+	}
+	if err = r.opts.limits.check(root); err != nil {
+		return nil, err
+	}
+	if r.opts.interpolation != nil {
+		if err = r.opts.interpolation.interpolateNodes(root); err != nil {
+			return nil, err
+		}
+	}
+	r.roots[path] = root
+	return root, nil
+}
+
+// splitRef - split a reference into the path of the file and the JSON Pointer within it
+func splitRef(ref string) (path, pointer string) {
+	if index := strings.Index(ref, "#"); index >= 0 {
+		return ref[:index], ref[index+1:]
+	}
+	return ref, ""
+}
+
+// pointerNode - get the node that a JSON Pointer (e.g. "/servers/0/name") refers to
+func pointerNode(root *yaml.Node, pointer string) (*yaml.Node, error) {
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if pointer == "" {
+		return node, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid pointer '%s'", pointer)
+	}
+
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+
+		var child *yaml.Node
+		switch node = resolveAlias(node); node.Kind {
+		case yaml.MappingNode:
+			child, _ = findKey(node, token)
+		case yaml.SequenceNode:
+			if index, err := strconv.Atoi(token); err == nil && index >= 0 && index < len(node.Content) {
+				child = node.Content[index]
+			}
+		}
+		if child == nil {
+			return nil, fmt.Errorf("pointer '%s' not found", pointer)
+		}
+		node = child
+	}
+	return node, nil
+}
+
+// pointerValue - get the value that a JSON Pointer (e.g. "/servers/0/name") refers to
+func pointerValue(data interface{}, pointer string) (interface{}, error) {
+	if pointer == "" {
+		return data, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid pointer '%s'", pointer)
+	}
+
+	value := data
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+
+		found := false
+		switch typedValue := value.(type) {
+		case map[string]interface{}:
+			value, found = typedValue[token]
+		case []interface{}:
+			if index, err := strconv.Atoi(token); err == nil && index >= 0 && index < len(typedValue) {
+				value, found = typedValue[index], true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("pointer '%s' not found", pointer)
+		}
+	}
+	return value, nil
+}
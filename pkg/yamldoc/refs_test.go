@@ -0,0 +1,158 @@
+package yamldoc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Yaml references", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "goyaml-refs")
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+	writeFile := func(name, contents string) {
+		path := filepath.Join(dir, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+	}
+
+	It("keeps the references without the option", func() {
+		yaml, err := FromString("logging:\n  $ref: ./common.yaml#/logging\n", TagsDir(dir))
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "logging.$ref", "./common.yaml#/logging")
+	})
+	It("resolves the references to other files", func() {
+		writeFile("common.yaml", "logging:\n  level: debug\n  format: json\nservers:\n  - one\n  - two\n")
+
+		yaml, err := FromString(strings.TrimSpace(`
+logging:
+  $ref: ./common.yaml#/logging
+server:
+  $ref: common.yaml#/servers/1
+common:
+  $ref: common.yaml
+`), ResolveRefs(), TagsDir(dir))
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "logging.level", "debug")
+		checkGetValue(yaml, "server", "two")
+		checkGetValue(yaml, "common.logging.format", "json")
+		Expect(yaml.Contains("logging.$ref")).To(BeFalse())
+	})
+	It("resolves the references within the same file", func() {
+		yaml, err := FromString(strings.TrimSpace(`
+definitions:
+  user:
+    name: admin
+    a~b/c: value
+admin:
+  $ref: "#/definitions/user"
+special:
+  $ref: "#/definitions/user/a~0b~1c"
+`), ResolveRefs())
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "admin.name", "admin")
+		checkGetValue(yaml, "special", "value")
+	})
+	It("resolves the includes and the nested references relative to the including file", func() {
+		writeFile("config/app.yaml", "logging: !include ../shared/logging.yaml#/logging\n")
+		writeFile("shared/logging.yaml", "logging:\n  level: info\n  output:\n    $ref: outputs.yaml#/stdout\n")
+		writeFile("shared/outputs.yaml", "stdout:\n  target: console\n")
+
+		yaml, err := FromString("app: !include config/app.yaml\n", ResolveRefs(), TagsDir(dir))
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "app.logging.level", "info")
+		checkGetValue(yaml, "app.logging.output.target", "console")
+		checkText(yaml, strings.TrimSpace(`
+app:
+  logging:
+    level: info
+    output:
+      target: console
+`))
+	})
+	It("fails to resolve circular references", func() {
+		writeFile("a.yaml", "b:\n  $ref: b.yaml#/a\n")
+		writeFile("b.yaml", "a:\n  $ref: a.yaml\n")
+
+		_, err := FromString("a:\n  $ref: a.yaml\n", ResolveRefs(), TagsDir(dir))
+		Expect(IsCircularRefError(err)).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring("Circular reference: a.yaml -> b.yaml#/a -> a.yaml")))
+		Expect(err).To(MatchError(HavePrefix("Failed to resolve 'a.yaml': Failed to resolve 'b.yaml#/a'")))
+	})
+	It("fails to resolve references to missing values", func() {
+		writeFile("common.yaml", "logging:\n  level: debug\n")
+
+		_, err := FromString("logging:\n  $ref: common.yaml#/missing\n", ResolveRefs(), TagsDir(dir))
+		Expect(err).To(MatchError(ContainSubstring("pointer '/missing' not found")))
+
+		_, err = FromString("logging:\n  $ref: missing.yaml\n", ResolveRefs(), TagsDir(dir))
+		Expect(err).To(HaveOccurred())
+	})
+	Context("The yaml is limited", func() {
+		// Each level refers to the level below 10 times, so the references expand to 10^4 values
+		var expandingYAML = func(ref func(level int) string) string {
+			var lines []string
+
+			lines = append(lines, "l0: [a, a, a, a, a, a, a, a, a, a]")
+			for level := 1; level <= 4; level++ {
+				refs := strings.TrimSuffix(strings.Repeat(ref(level-1)+", ", 10), ", ")
+				lines = append(lines, fmt.Sprintf("l%d: [%s]", level, refs))
+			}
+			return strings.Join(lines, "\n") + "\n"
+		}
+
+		It("fails when the references expand beyond the max alias expansion", func() {
+			refsYAML := expandingYAML(func(level int) string {
+				return fmt.Sprintf("{$ref: '#/l%d'}", level)
+			})
+			_, err := FromString(refsYAML, ResolveRefs(), MaxAliasExpansion(2000))
+			Expect(IsLimitError(err)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("max alias expansion limit of 2000")))
+
+			yaml, err := FromString(refsYAML, ResolveRefs())
+			Expect(err).ToNot(HaveOccurred())
+			value := yaml.Data()["l4"]
+			for level := 4; level >= 0; level-- {
+				Expect(value).To(HaveLen(10))
+				value = value.([]interface{})[9]
+			}
+			Expect(value).To(Equal("a"))
+		})
+		It("fails when the includes expand beyond the max alias expansion", func() {
+			writeFile("levels.yaml", expandingYAML(func(level int) string {
+				return fmt.Sprintf("!include levels.yaml#/l%d", level)
+			}))
+
+			_, err := FromString("levels: !include levels.yaml#/l4\n", ResolveTags(), TagsDir(dir), MaxAliasExpansion(2000))
+			Expect(IsLimitError(err)).To(BeTrue())
+
+			_, err = FromString("levels: !include levels.yaml#/l4\n", ResolveRefs(), TagsDir(dir), MaxAliasExpansion(2000))
+			Expect(IsLimitError(err)).To(BeTrue())
+		})
+		It("fails when the references are nested beyond the max depth", func() {
+			writeFile("nested.yaml", "a:\n  b:\n    c: value\n")
+
+			_, err := FromString("x:\n  $ref: nested.yaml\n", ResolveRefs(), TagsDir(dir), MaxDepth(3))
+			Expect(IsLimitError(err)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("max depth")))
+		})
+	})
+	It("includes the values of pointers with the tag resolvers", func() {
+		writeFile("common.yaml", "logging:\n  level: debug\n")
+
+		yaml, err := FromString("level: !include common.yaml#/logging/level\n", ResolveTags(), TagsDir(dir))
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "level", "debug")
+	})
+})
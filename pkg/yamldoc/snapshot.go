@@ -0,0 +1,78 @@
+package yamldoc
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidSnapshot - error generated when restoring from a nil snapshot
+var ErrInvalidSnapshot = errors.New("Invalid snapshot specified")
+
+// Snapshot - the captured state of a yaml document.  A snapshot is not affected by any
+// changes made to the document after it was taken and can be restored more than once.
+type Snapshot struct {
+	data         map[string]interface{}
+	doc          *yaml.Node
+	nodesChanged bool
+}
+
+// Clone - get a deep copy of the yaml document
+func (y *yamlDoc) Clone() YamlDoc {
+	return &yamlDoc{
+		data:          deepCopy(y.data).(map[string]interface{}),
+		baseline:      deepCopy(y.baseline).(map[string]interface{}),
+		doc:           copyNode(y.doc, map[*yaml.Node]*yaml.Node{}),
+		tagOpts:       y.tagOpts,
+		nodesChanged:  y.nodesChanged,
+		decryptionKey: y.decryptionKey,
+	}
+}
+
+// Snapshot - capture the current state of the yaml so that it can be restored later
+func (y *yamlDoc) Snapshot() *Snapshot {
+	return &Snapshot{
+		data:         deepCopy(y.data).(map[string]interface{}),
+		doc:          copyNode(y.doc, map[*yaml.Node]*yaml.Node{}),
+		nodesChanged: y.nodesChanged,
+	}
+}
+
+// Restore - restore the yaml to the state captured by a snapshot
+func (y *yamlDoc) Restore(snapshot *Snapshot) error {
+	if snapshot == nil {
+		return ErrInvalidSnapshot
+	}
+	oldData := y.data
+
+	y.data = deepCopy(snapshot.data).(map[string]interface{})
+	y.nodesChanged = snapshot.nodesChanged
+	if snapshot.doc != nil && y.doc != nil {
+		y.doc = copyNode(snapshot.doc, map[*yaml.Node]*yaml.Node{})
+	} else {
+		y.setNodes()
+	}
+	y.notifyChange("", oldData, true, y.data, true)
+	return nil
+}
+
+// Transaction - apply several changes to the yaml and roll them all back if any one fails.
+//
+// The "changes" function is called with the document to modify.  If it returns an error (or
+// panics), then the document is restored to the state it had before the transaction started
+// and the error is returned as is.
+func (y *yamlDoc) Transaction(changes func(doc YamlDoc) error) (err error) {
+	snapshot := y.Snapshot()
+
+	defer func() {
+		if r := recover(); r != nil {
+			y.Restore(snapshot)
+			panic(r)
+		}
+	}()
+
+	if err = changes(y); err != nil {
+		y.Restore(snapshot)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package yamldoc
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Yaml clone, snapshot and transaction functions", func() {
+	var (
+		yaml     YamlDoc
+		yamlText = strings.TrimSpace(_SampleYaml)
+	)
+
+	BeforeEach(func() {
+		var err error
+		yaml, err = FromString(yamlText)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(yaml).ToNot(BeNil())
+	})
+
+	It("clones a document that does not share data with the original", func() {
+		clone := yaml.Clone()
+		checkText(clone, yamlText)
+
+		checkSetValue(clone, "a.b.c", "changed")
+		checkDeleteValue(clone, "a.d", true)
+
+		// The original is untouched
+		checkText(yaml, yamlText)
+	})
+	It("restores a snapshot", func() {
+		snapshot := yaml.Snapshot()
+
+		checkSetValue(yaml, "a.b.c", "changed")
+		checkSetValue(yaml, "g", "value-g")
+		checkDeleteValue(yaml, "a.d.e", true)
+
+		Expect(yaml.Restore(snapshot)).To(Succeed())
+		checkText(yaml, yamlText)
+
+		// The same snapshot can be restored again
+		checkSetValue(yaml, "a.b.c", "changed again")
+		Expect(yaml.Restore(snapshot)).To(Succeed())
+		checkText(yaml, yamlText)
+	})
+	It("fails to restore a nil snapshot", func() {
+		Expect(yaml.Restore(nil)).To(Equal(ErrInvalidSnapshot))
+	})
+	It("keeps the changes of a successful transaction", func() {
+		err := yaml.Transaction(func(doc YamlDoc) error {
+			if _, err := doc.Set("a.b.c", "changed"); err != nil {
+				return err
+			}
+			_, err := doc.Delete("a.d")
+			return err
+		})
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "a.b.c", "changed")
+		checkContainsValue(yaml, "a.d", false)
+	})
+	It("rolls back all changes of a failed transaction", func() {
+		err := yaml.Transaction(func(doc YamlDoc) error {
+			if _, err := doc.Set("a.b.c", "changed"); err != nil {
+				return err
+			}
+			if _, err := doc.Delete("a.d"); err != nil {
+				return err
+			}
+			// "a.b.c" is a string so it cannot contain other keys
+			_, err := doc.Set("a.b.c.x", "fails")
+			return err
+		})
+		Expect(err).To(HaveOccurred())
+		checkText(yaml, yamlText)
+	})
+	It("rolls back all changes of a transaction that panics", func() {
+		Expect(func() {
+			yaml.Transaction(func(doc YamlDoc) error {
+				doc.Set("a.b.c", "changed")
+				panic(fmt.Errorf("unexpected"))
+			})
+		}).To(Panic())
+		checkText(yaml, yamlText)
+	})
+})
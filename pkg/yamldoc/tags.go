@@ -0,0 +1,395 @@
+package yamldoc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// The tags of the built-in tag resolvers
+const (
+	// TagEnv - "!env NAME" resolves to the value of the environment variable
+	TagEnv = "!env"
+	// TagFile - "!file path" resolves to the contents of the file as a string
+	TagFile = "!file"
+	// TagInclude - "!include path" resolves to the yaml of the file
+	TagInclude = "!include"
+	// TagBase64 - "!base64 data" resolves to the base64 decoded data as a string
+	TagBase64 = "!base64"
+)
+
+// TagContext - the value with a custom tag to resolve
+type TagContext struct {
+	// Tag - the tag of the value, e.g. "!env"
+	Tag string
+	// Value - the value of a scalar, e.g. "DB_PASSWORD" for "!env DB_PASSWORD"
+	Value string
+	// Node - the node of the value
+	Node *yaml.Node
+	// Dir - the directory that relative paths are resolved against ("" for the current directory)
+	Dir string
+
+	opts *loadOptions
+}
+
+// Path - get the path of a file relative to the directory of the context
+func (c *TagContext) Path(name string) string {
+	if filepath.IsAbs(name) || c.Dir == "" {
+		return name
+	}
+	return filepath.Join(c.Dir, name)
+}
+
+// TagResolver - a function that resolves the value of a node with a custom tag, e.g. the
+// value of "!env DB_PASSWORD"
+type TagResolver func(ctx *TagContext) (value interface{}, err error)
+
+var (
+	tagResolversMutex sync.RWMutex
+	tagResolvers      = map[string]TagResolver{}
+)
+
+func init() {
+	RegisterTagResolver(TagEnv, resolveEnv)
+	RegisterTagResolver(TagFile, resolveFile)
+	RegisterTagResolver(TagInclude, resolveInclude)
+	RegisterTagResolver(TagBase64, resolveBase64)
+}
+
+// RegisterTagResolver - register the resolver of a custom tag (e.g. "!vault"), replacing any
+// resolver already registered for it.  The registered tags are only resolved when the yaml is
+// loaded with ResolveTags or ResolveTagsLazily.
+func RegisterTagResolver(tag string, resolver TagResolver) {
+	tagResolversMutex.Lock()
+	defer tagResolversMutex.Unlock()
+
+	tagResolvers[tag] = resolver
+}
+
+// RegisteredTags - get the tags that have a registered resolver, sorted
+func RegisteredTags() []string {
+	tagResolversMutex.RLock()
+	defer tagResolversMutex.RUnlock()
+
+	tags := make([]string, 0, len(tagResolvers))
+	for tag := range tagResolvers {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func tagResolverFor(tag string) TagResolver {
+	tagResolversMutex.RLock()
+	defer tagResolversMutex.RUnlock()
+
+	return tagResolvers[tag]
+}
+
+// tagOptions - how the custom tags are resolved
+type tagOptions struct {
+	// tags - the tags to resolve (nil for all the registered tags)
+	tags map[string]bool
+	// lazy - resolve the values when they are read instead of when they are loaded
+	lazy bool
+}
+
+// ResolveTags - resolve the values with custom tags (e.g. "!env DB_PASSWORD") when the yaml is
+// loaded, using the registered tag resolvers (see RegisterTagResolver).  Only the specified tags
+// are resolved or, if none are specified, all the registered tags.  Since the values are replaced
+// with the resolved values, writing out the yaml writes out the resolved values.
+func ResolveTags(tags ...string) LoadOption {
+	return func(opts *loadOptions) {
+		opts.tags = newTagOptions(tags, false)
+	}
+}
+
+// ResolveTagsLazily - resolve the values with custom tags (e.g. "!env DB_PASSWORD") when they
+// are read with Get (or ResolvedData), using the registered tag resolvers (see
+// RegisterTagResolver).  Only the specified tags are resolved or, if none are specified, all the
+// registered tags.  The values are kept as *TaggedValue in the data, so writing out the yaml
+// writes out the tagged values and not the resolved ones.
+func ResolveTagsLazily(tags ...string) LoadOption {
+	return func(opts *loadOptions) {
+		opts.tags = newTagOptions(tags, true)
+	}
+}
+
+// TagsDir - the directory that relative paths of the resolved tags (e.g. "!file ./cert.pem")
+// and of the references (see ResolveRefs) are resolved against.  By default, they are resolved against the current directory.
+func TagsDir(dir string) LoadOption {
+	return func(opts *loadOptions) {
+		opts.dir = dir
+	}
+}
+
+func newTagOptions(tags []string, lazy bool) *tagOptions {
+	result := &tagOptions{lazy: lazy}
+	if len(tags) > 0 {
+		result.tags = map[string]bool{}
+		for _, tag := range tags {
+			result.tags[tag] = true
+		}
+	}
+	return result
+}
+
+// resolver - get the resolver of a tag, if it is to be resolved
+func (o *tagOptions) resolver(tag string) TagResolver {
+	if o == nil || tag == "" || tag[0] != '!' || (o.tags != nil && !o.tags[tag]) {
+		return nil
+	}
+	return tagResolverFor(tag)
+}
+
+// TaggedValue - a value with a custom tag that is resolved when it is read (see ResolveTagsLazily)
+type TaggedValue struct {
+	ctx      *TagContext
+	resolver TagResolver
+}
+
+// Tag - get the tag of the value, e.g. "!env"
+func (t *TaggedValue) Tag() string {
+	return t.ctx.Tag
+}
+
+// Value - get the value as it is in the yaml, e.g. "DB_PASSWORD" for "!env DB_PASSWORD"
+func (t *TaggedValue) Value() string {
+	return t.ctx.Value
+}
+
+// Resolve - resolve the value with the resolver of its tag
+func (t *TaggedValue) Resolve() (value interface{}, err error) {
+	if value, err = t.resolver(t.ctx); err != nil {
+		return nil, errors.Wrapf(err, "Failed to resolve '%s %s'", t.ctx.Tag, t.ctx.Value)
+	}
+	return value, nil
+}
+
+// MarshalYAML - the value is written out with its tag
+func (t *TaggedValue) MarshalYAML() (interface{}, error) {
+	return t.ctx.Node, nil
+}
+
+// MarshalJSON - the value is written out as it is in the yaml, without its tag
+func (t *TaggedValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.ctx.Value)
+}
+
+// ResolvedData - get a copy of the data with the tagged values resolved (see ResolveTagsLazily)
+func (y *yamlDoc) ResolvedData() (map[string]interface{}, error) {
+	value, _, err := resolveTagged(y.data)
+	if err != nil {
+		return nil, err
+	}
+	return deepCopy(value).(map[string]interface{}), nil
+}
+
+// resolveTagged - resolve the tagged values within the value.  Maps and arrays that contain
+// tagged values are copied (changed=true), so the data of the yaml is not changed.
+func resolveTagged(value interface{}) (result interface{}, changed bool, err error) {
+	switch x := value.(type) {
+	case *TaggedValue:
+		if result, err = x.Resolve(); err != nil {
+			return nil, false, err
+		}
+		result, _, err = resolveTagged(result)
+		return result, true, err
+	case map[string]interface{}:
+		var mapValue map[string]interface{}
+		for key, item := range x {
+			resolved, itemChanged, err := resolveTagged(item)
+			if err != nil {
+				return nil, false, err
+			}
+			if itemChanged && mapValue == nil {
+				mapValue = make(map[string]interface{}, len(x))
+				for k, v := range x {
+					mapValue[k] = v
+				}
+			}
+			if mapValue != nil {
+				mapValue[key] = resolved
+			}
+		}
+		if mapValue != nil {
+			return mapValue, true, nil
+		}
+	case []interface{}:
+		var array []interface{}
+		for index, item := range x {
+			resolved, itemChanged, err := resolveTagged(item)
+			if err != nil {
+				return nil, false, err
+			}
+			if itemChanged && array == nil {
+				array = append([]interface{}{}, x...)
+			}
+			if array != nil {
+				array[index] = resolved
+			}
+		}
+		if array != nil {
+			return array, true, nil
+		}
+	}
+	return value, false, nil
+}
+
+// attachTags - resolve (or keep as *TaggedValue) the values of the nodes with custom tags in
+// the value decoded from the node and get the value to use instead
+func attachTags(node *yaml.Node, value interface{}, opts *loadOptions) (interface{}, error) {
+	node = resolveAlias(node)
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) > 0 {
+			return attachTags(node.Content[0], value, opts)
+		}
+	case yaml.MappingNode:
+		mapValue, ok := value.(map[string]interface{})
+		if !ok {
+			return value, nil
+		}
+		// The maps merged with "<<" first, so that the keys of the map itself override them
+		sources := mergedMappings(node)
+		for index := len(sources) - 1; index >= 0; index-- {
+			if err := attachMappingTags(sources[index], mapValue, opts); err != nil {
+				return nil, err
+			}
+		}
+		if err := attachMappingTags(node, mapValue, opts); err != nil {
+			return nil, err
+		}
+	case yaml.SequenceNode:
+		arrayValue, ok := value.([]interface{})
+		if !ok || len(arrayValue) != len(node.Content) {
+			return value, nil
+		}
+		for index, child := range node.Content {
+			resolved, err := attachTags(child, arrayValue[index], opts)
+			if err != nil {
+				return nil, err
+			}
+			arrayValue[index] = resolved
+		}
+	default:
+		if resolver := opts.tags.resolver(node.Tag); resolver != nil {
+			tagged := &TaggedValue{
+				ctx: &TagContext{
+					Tag:   node.Tag,
+					Value: node.Value,
+					Node:  copyNode(node, map[*yaml.Node]*yaml.Node{}),
+					Dir:   opts.dir,
+					opts:  opts,
+				},
+				resolver: resolver,
+			}
+			tagged.ctx.Node.Anchor = ""
+			if opts.tags.lazy {
+				return tagged, nil
+			}
+			return tagged.Resolve()
+		}
+	}
+	return value, nil
+}
+
+func attachMappingTags(node *yaml.Node, mapValue map[string]interface{}, opts *loadOptions) error {
+	for index := 0; index+1 < len(node.Content); index += 2 {
+		keyNode := node.Content[index]
+		if isMergeKey(keyNode) {
+			continue
+		}
+		if value, found := mapValue[keyNode.Value]; found {
+			resolved, err := attachTags(node.Content[index+1], value, opts)
+			if err != nil {
+				return err
+			}
+			mapValue[keyNode.Value] = resolved
+		}
+	}
+	return nil
+}
+
+// resolveEnv - "!env NAME" resolves to the value of the environment variable
+func resolveEnv(ctx *TagContext) (interface{}, error) {
+	value, found := os.LookupEnv(ctx.Value)
+	if !found {
+		return nil, fmt.Errorf("environment variable '%s' is not set", ctx.Value)
+	}
+	return value, nil
+}
+
+// resolveFile - "!file path" resolves to the contents of the file as a string
+func resolveFile(ctx *TagContext) (interface{}, error) {
+	contents, err := os.ReadFile(ctx.Path(ctx.Value))
+	if err != nil {
+		return nil, err
+	}
+	return string(contents), nil
+}
+
+// resolveInclude - "!include path" resolves to the yaml of the file, loaded with the same
+// options, and "!include path#/pointer" to the value of the JSON Pointer within it (see
+// ResolveRefs).  The relative paths of the included yaml are resolved against its directory.
+//
+// The includes are resolved with the same resolver as the references, so they are told apart
+// by the absolute paths of the files (and the pointers) when detecting circular includes and
+// they count towards the MaxAliasExpansion limit.
+func resolveInclude(ctx *TagContext) (interface{}, error) {
+	path, pointer := splitRef(ctx.Value)
+	absFilename, err := filepath.Abs(ctx.Path(path))
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := ctx.opts.refs
+	if resolver == nil {
+		resolver = newRefResolver(ctx.opts)
+	}
+	leave, err := resolver.enter(absFilename, pointer)
+	if err != nil {
+		return nil, err
+	}
+	defer leave()
+
+	root, err := resolver.loadRoot(absFilename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "File '%s'", ctx.Value)
+	}
+	node, err := resolver.pointerNode(root, pointer)
+	if err != nil {
+		return nil, errors.Wrapf(err, "File '%s'", ctx.Value)
+	}
+
+	var value interface{}
+	if err = node.Decode(&value); err != nil {
+		return nil, errors.Wrapf(err, "File '%s'", ctx.Value)
+	}
+
+	// The tags of the included yaml are resolved right away, while the include is being
+	// resolved, so that including it again within is detected
+	opts := *ctx.opts
+	opts.dir = filepath.Dir(absFilename)
+	opts.tags = &tagOptions{tags: ctx.opts.tags.tags}
+	opts.refs = resolver
+
+	return attachTags(node, convertMapValue(value), &opts)
+}
+
+// resolveBase64 - "!base64 data" resolves to the decoded data as a string
+func resolveBase64(ctx *TagContext) (interface{}, error) {
+	contents, err := base64.StdEncoding.DecodeString(ctx.Value)
+	if err != nil {
+		return nil, err
+	}
+	return string(contents), nil
+}
@@ -0,0 +1,140 @@
+package yamldoc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Yaml custom tags", func() {
+	const (
+		envName  = "GOYAML_TEST_PASSWORD"
+		envValue = "s3cr3t"
+	)
+	var (
+		taggedYaml = strings.TrimSpace(`
+name: !base64 aGVsbG8=
+password: !env GOYAML_TEST_PASSWORD
+port: 8080
+`)
+		dir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		Expect(os.Setenv(envName, envValue)).To(Succeed())
+		dir, err = os.MkdirTemp("", "goyaml-tags")
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		os.Unsetenv(envName)
+		os.RemoveAll(dir)
+	})
+	writeFile := func(name, contents string) {
+		path := filepath.Join(dir, name)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+	}
+
+	It("keeps the tagged values as strings without the option", func() {
+		yaml, err := FromString(taggedYaml)
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "password", "GOYAML_TEST_PASSWORD")
+	})
+	It("resolves the tagged values when loading the yaml", func() {
+		yaml, err := FromString(taggedYaml, ResolveTags())
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "name", "hello")
+		checkGetValue(yaml, "password", envValue)
+		checkGetValue(yaml, "port", 8080)
+		checkText(yaml, "name: hello\npassword: s3cr3t\nport: 8080")
+	})
+	It("resolves only the specified tags", func() {
+		yaml, err := FromString(taggedYaml, ResolveTags(TagBase64))
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "name", "hello")
+		checkGetValue(yaml, "password", "GOYAML_TEST_PASSWORD")
+	})
+	It("fails to load the yaml when a tagged value cannot be resolved", func() {
+		os.Unsetenv(envName)
+		_, err := FromString(taggedYaml, ResolveTags())
+		Expect(err).To(MatchError(ContainSubstring("environment variable 'GOYAML_TEST_PASSWORD' is not set")))
+	})
+	Context("Resolving the tagged values lazily", func() {
+		var yaml YamlDoc
+
+		BeforeEach(func() {
+			var err error
+			yaml, err = FromString(taggedYaml, ResolveTagsLazily())
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("resolves the tagged values when they are read", func() {
+			Expect(yaml.Data()["password"]).To(BeAssignableToTypeOf(&TaggedValue{}))
+			checkGetValue(yaml, "password", envValue)
+
+			Expect(os.Setenv(envName, "changed")).To(Succeed())
+			checkGetValue(yaml, "password", "changed")
+		})
+		It("keeps the tagged values when writing out the yaml", func() {
+			checkSetValue(yaml, "port", 9090)
+			checkText(yaml, strings.Replace(taggedYaml, "8080", "9090", 1))
+		})
+		It("gets a copy of the data with the tagged values resolved", func() {
+			data, err := yaml.ResolvedData()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal(map[string]interface{}{"name": "hello", "password": envValue, "port": 8080}))
+			Expect(yaml.Data()["password"]).To(BeAssignableToTypeOf(&TaggedValue{}))
+		})
+		It("fails to get a tagged value that cannot be resolved", func() {
+			os.Unsetenv(envName)
+			_, err := yaml.Get("password")
+			Expect(err).To(MatchError(ContainSubstring("Failed to resolve '!env GOYAML_TEST_PASSWORD'")))
+		})
+		It("keeps the tagged values of the nodes", func() {
+			yaml, err := FromString(taggedYaml, ResolveTagsLazily(), KeepNodes())
+			Expect(err).ToNot(HaveOccurred())
+			checkSetValue(yaml, "port", 9090)
+			checkGetValue(yaml, "password", envValue)
+			checkText(yaml, strings.Replace(taggedYaml, "8080", "9090", 1))
+		})
+	})
+	It("reads and includes files relative to the directory", func() {
+		writeFile("cert.pem", "CERTIFICATE")
+		writeFile("common.yaml", "logging:\n  level: debug\ncert: !file cert.pem\n")
+
+		yaml, err := FromString("common: !include common.yaml\n", ResolveTagsLazily(), TagsDir(dir))
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "common.logging.level", "debug")
+		checkGetValue(yaml, "common.cert", "CERTIFICATE")
+		checkText(yaml, "common: !include common.yaml")
+	})
+	It("fails to include a file that includes itself", func() {
+		writeFile("a.yaml", "b: !include b.yaml\n")
+		writeFile("b.yaml", "a: !include a.yaml\n")
+
+		_, err := FromString("a: !include a.yaml\n", ResolveTags(), TagsDir(dir))
+		Expect(IsCircularRefError(err)).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring("Circular reference: a.yaml -> b.yaml -> a.yaml")))
+	})
+	It("includes files with the same name in different directories", func() {
+		writeFile("a/common.yaml", "b: !include ../b/common.yaml\n")
+		writeFile("b/common.yaml", "level: debug\n")
+
+		yaml, err := FromString("a: !include a/common.yaml\n", ResolveTags(), TagsDir(dir))
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "a.b.level", "debug")
+	})
+	It("resolves the tags of registered resolvers", func() {
+		RegisterTagResolver("!upper", func(ctx *TagContext) (interface{}, error) {
+			return strings.ToUpper(ctx.Value), nil
+		})
+		Expect(RegisteredTags()).To(ContainElements(TagEnv, TagFile, TagInclude, TagBase64, "!upper"))
+
+		yaml, err := FromString("items:\n  - !upper one\n  - two\n", ResolveTags())
+		Expect(err).ToNot(HaveOccurred())
+		checkGetValue(yaml, "items", []interface{}{"ONE", "two"})
+	})
+})
@@ -18,15 +18,30 @@ var ErrEmptyKey = errors.New("Empty key specified")
 const DefaultIndent = 2
 
 type yamlDoc struct {
-	data map[string]interface{}
+	data      map[string]interface{}
+	baseline  map[string]interface{}
+	observers []*observer
+	// doc - the nodes of the yaml, only when they are kept (see KeepNodes)
+	doc *yaml.Node
+	// tagOpts - the options for resolving the custom tags of the nodes (see ResolveTags)
+	tagOpts *loadOptions
+	// nodesChanged - whether only the nodes (e.g. their comments) were changed since the yaml was
+	// loaded (or marked as clean)
+	nodesChanged bool
+	// decryptionKey - the key for decrypting the encrypted values when they are read (see DecryptWith)
+	decryptionKey []byte
 }
 
 // YamlDoc - interface for manipulating yaml file
 type YamlDoc interface {
 	// Data - get the underlying map
 	Data() map[string]interface{}
+	// ResolvedData - get a copy of the data with the tagged values resolved (see ResolveTagsLazily)
+	ResolvedData() (map[string]interface{}, error)
 	// SetData - set the underlying map
 	SetData(newData map[string]interface{}) YamlDoc
+	// Merge - deep merge the data into the underlying map
+	Merge(data map[string]interface{}) YamlDoc
 	// Get - get the value at key from the yaml
 	Get(key string) (value interface{}, err error)
 	// GetString - get the string value at key from the yaml
@@ -39,6 +54,8 @@ type YamlDoc interface {
 	GetObject(key string, obj interface{}) (err error)
 	// Set - get a key from the yaml
 	Set(key string, value interface{}) (valueSet bool, err error)
+	// SetWithOptions - set a key in the yaml, placing it before or after another key (see KeepNodes)
+	SetWithOptions(key string, value interface{}, opts SetOptions) (valueSet bool, err error)
 	// Delete - delete a key from the yaml
 	Delete(key string) (deleted bool, err error)
 	// Contains - check if the specified key path is contained within the yaml
@@ -51,34 +68,142 @@ type YamlDoc interface {
 	BytesIndented(spaces int) ([]byte, error)
 	// TextIndented - get the yaml file as text indented with the specified indent
 	TextIndented(spaces int) (string, error)
+	// Clone - get a deep copy of the yaml document
+	Clone() YamlDoc
+	// Snapshot - capture the current state of the yaml so that it can be restored later
+	Snapshot() *Snapshot
+	// Restore - restore the yaml to the state captured by a snapshot
+	Restore(snapshot *Snapshot) error
+	// Transaction - apply several changes to the yaml and roll them all back if any one fails
+	Transaction(changes func(doc YamlDoc) error) error
+	// OnChange - register a function to be called whenever a value matching the path pattern changes
+	OnChange(pathPattern string, notify func(ev ChangeEvent)) (remove func())
+	// IsDirty - check if the yaml was changed since it was loaded (or marked as clean)
+	IsDirty() bool
+	// ChangedPaths - get the key paths of the values changed since the yaml was loaded (or marked as clean)
+	ChangedPaths() []string
+	// MarkClean - mark the current state of the yaml as unchanged, e.g. once it is saved
+	MarkClean()
+	// Anchors - get the anchors of the yaml and the aliases that refer to them (see KeepNodes)
+	Anchors() []Anchor
+	// FlattenAliases - replace the aliases with copies of the anchored values (see KeepNodes)
+	FlattenAliases()
+	// GetComment - get the comments of a key (see KeepNodes)
+	GetComment(key string) (comment Comment, err error)
+	// SetComment - set the comments of a key (see KeepNodes)
+	SetComment(key, head, line, foot string) error
+	// SortKeys - sort the keys of a map or the items of an array in the yaml (see KeepNodes)
+	SortKeys(key string, comparator Comparator, opts SortOptions) error
+	// Encrypt - encrypt the values matching the path patterns or the regex
+	Encrypt(key []byte, opts EncryptOptions) (encrypted int, err error)
+	// Decrypt - decrypt the encrypted values and remove the encryption metadata
+	Decrypt(key []byte) (decrypted int, err error)
 }
 
-// New - create new yaml from reader
-func New(reader io.Reader) (YamlDoc, error) {
+// New - create new yaml from reader.  A byte order mark (BOM) is skipped and UTF-16/UTF-32
+// contents are transcoded to UTF-8 (see ToUTF8).
+//
+// The options control how the yaml is loaded, e.g. WithLimits for loading untrusted yaml or
+// KeepNodes for keeping its anchors and aliases.  If the yaml exceeds any of the limits, then
+// a *LimitError is returned (see IsLimitError).
+func New(reader io.Reader, opts ...LoadOption) (YamlDoc, error) {
+	return newWithOptions(reader, newLoadOptions(opts))
+}
+
+func newWithOptions(reader io.Reader, options *loadOptions) (*yamlDoc, error) {
 	result := &yamlDoc{
 		data: map[string]interface{}{},
 	}
+	if options.tags != nil {
+		result.tagOpts = options
+	}
+	result.decryptionKey = options.decryptionKey
 
 	if reader != nil {
+		contents, err := readAll(reader, options.limits.MaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		if contents, _, err = ToUTF8(contents); err != nil {
+			return nil, err
+		}
+
 		// Create decoder
-		decoder := yaml.NewDecoder(reader)
+		decoder := yaml.NewDecoder(bytes.NewReader(contents))
+
+		if options.limits.hasNodeLimits() || options.keepNodes || options.tags != nil || options.interpolation != nil ||
+			options.resolveRefs {
+			// Check the nodes before decoding them, since decoding expands the aliases
+			var root yaml.Node
 
-		if err := decoder.Decode(result.data); err != nil {
+			if err := decoder.Decode(&root); err != nil {
+				return nil, err
+			}
+			if err := options.limits.check(&root); err != nil {
+				return nil, err
+			}
+			if options.interpolation != nil {
+				if err := options.interpolation.interpolateNodes(&root); err != nil {
+					return nil, err
+				}
+			}
+			if options.resolveRefs {
+				if err := newRefResolver(options).resolve(&root); err != nil {
+					return nil, err
+				}
+				// Check the nodes again, now that the references are expanded
+				if err := options.limits.check(&root); err != nil {
+					return nil, err
+				}
+			}
+			if err := result.decodeNodes(&root); err != nil {
+				return nil, err
+			}
+			if options.keepNodes {
+				untagMergeKeys(&root)
+				result.doc = &root
+			}
+		} else if err := decoder.Decode(result.data); err != nil {
 			return nil, err
 		}
 	}
+	convertMaps(result.data)
+	if options.keepNodes && result.doc == nil {
+		result.doc = nodesFromData(result.data)
+	}
+	result.MarkClean()
 
 	return result, nil
 }
 
+// decodeNodes - decode the nodes into the data and resolve their custom tags (see ResolveTags)
+func (y *yamlDoc) decodeNodes(root *yaml.Node) error {
+	if err := root.Decode(y.data); err != nil {
+		return err
+	}
+	if opts := y.tagOpts; opts != nil {
+		convertMaps(y.data)
+		// The includes resolved right away share the resolver, so that they are limited together
+		if !opts.tags.lazy {
+			withRefs := *opts
+			withRefs.refs = newRefResolver(opts)
+			opts = &withRefs
+		}
+		if _, err := attachTags(root, y.data, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // FromBytes - create new yaml from bytes
-func FromBytes(yamlBytes []byte) (YamlDoc, error) {
-	return New(bytes.NewBuffer(yamlBytes))
+func FromBytes(yamlBytes []byte, opts ...LoadOption) (YamlDoc, error) {
+	return New(bytes.NewBuffer(yamlBytes), opts...)
 }
 
 // FromString - create new yaml from bytes
-func FromString(yamlText string) (YamlDoc, error) {
-	return New(bytes.NewBuffer([]byte(yamlText)))
+func FromString(yamlText string, opts ...LoadOption) (YamlDoc, error) {
+	return New(bytes.NewBuffer([]byte(yamlText)), opts...)
 }
 
 // Data - get the underlying map
@@ -88,11 +213,30 @@ func (y *yamlDoc) Data() map[string]interface{} {
 
 // SetData - set the underlying map
 func (y *yamlDoc) SetData(newData map[string]interface{}) YamlDoc {
+	oldData := y.data
+
 	if newData == nil {
 		y.data = map[string]interface{}{}
 	} else {
 		y.data = newData
 	}
+	y.setNodes()
+	y.notifyChange("", oldData, true, y.data, true)
+	return y
+}
+
+// Merge - deep merge the data into the underlying map.  Maps are merged key by key, while
+// any other values (including arrays) replace the existing values.  The merged data is
+// copied, so it does not share any maps or arrays with the yaml.
+func (y *yamlDoc) Merge(data map[string]interface{}) YamlDoc {
+	var oldData interface{}
+
+	if y.hasObservers() {
+		oldData = deepCopy(y.data)
+	}
+	mergeMaps(y.data, deepCopy(data).(map[string]interface{}))
+	y.setNodes()
+	y.notifyChange("", oldData, true, y.data, true)
 	return y
 }
 
@@ -120,6 +264,11 @@ func (y *yamlDoc) Get(key string) (value interface{}, err error) {
 			if index == lastIndex {
 				break
 			}
+			if tagged, ok := value.(*TaggedValue); ok {
+				if value, err = tagged.Resolve(); err != nil {
+					return nil, err
+				}
+			}
 			if mapValue, ok := value.(map[string]interface{}); ok {
 				currData = mapValue
 				// } else {
@@ -148,7 +297,12 @@ func (y *yamlDoc) Get(key string) (value interface{}, err error) {
 			value = convert(mapValue)
 		}
 	}
-	return
+	// Resolve the tagged values (see ResolveTagsLazily)
+	if value, _, err = resolveTagged(value); err != nil {
+		return nil, err
+	}
+	// Decrypt the encrypted values (see DecryptWith)
+	return y.decryptValues(key, value)
 }
 
 // GetObject - get a custom object at key.  The value is unmarshalled into the "obj" parameter
@@ -243,8 +397,21 @@ func (y *yamlDoc) Set(key string, value interface{}) (valueSet bool, err error)
 		traversedKeys     = []string{}
 		currData      map[string]interface{}
 		dataValue     interface{}
+		oldValue      interface{}
+		oldExists     bool
 	)
 
+	if y.doc != nil {
+		if err = y.applyToNodes(func(root *yaml.Node) error {
+			return setNode(root, keys, value)
+		}); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	if y.hasObservers() {
+		oldValue, oldExists = y.lookup(key)
+	}
 	currData = y.data
 	for index, key := range keys {
 		traversedKeys = append(traversedKeys, key)
@@ -252,6 +419,7 @@ func (y *yamlDoc) Set(key string, value interface{}) (valueSet bool, err error)
 		if index == lastIndex {
 			currData[key] = value
 			valueSet = true
+			y.notifyChange(strings.Join(keys, "."), oldValue, oldExists, value, true)
 			break
 		}
 
@@ -280,12 +448,20 @@ func (y *yamlDoc) Delete(key string) (deleted bool, err error) {
 		currData  map[string]interface{}
 	)
 
+	if y.doc != nil {
+		err = y.applyToNodes(func(root *yaml.Node) (err error) {
+			deleted, err = deleteNode(y.doc, root, keys)
+			return err
+		})
+		return deleted, err
+	}
 	currData = y.data
 	for index, key := range keys {
 		if value, containsKey := currData[key]; containsKey {
 			if index == lastIndex {
 				delete(currData, key)
 				deleted = true
+				y.notifyChange(strings.Join(keys, "."), value, true, nil, false)
 				break
 			}
 			if mapValue, ok := value.(map[string]interface{}); ok {
@@ -343,8 +519,12 @@ func (y *yamlDoc) BytesIndented(spaces int) ([]byte, error) {
 	// Set the indent
 	encoder.SetIndent(spaces)
 
-	// Encode the data to the buffer
-	if err := encoder.Encode(y.data); err != nil {
+	// Encode the nodes (if kept) or the data to the buffer
+	var value interface{} = y.data
+	if y.doc != nil {
+		value = y.doc
+	}
+	if err := encoder.Encode(value); err != nil {
 		return nil, err
 	}
 
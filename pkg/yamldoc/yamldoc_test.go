@@ -226,3 +226,53 @@ var _ = Describe("Yaml functions", func() {
 		})
 	})
 })
+
+var _ = Describe("Yaml merge", func() {
+	var yaml YamlDoc
+
+	BeforeEach(func() {
+		var err error
+		yaml, err = FromString(_SampleYaml)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("merges maps key by key and replaces other values", func() {
+		other := map[string]interface{}{
+			"a": map[string]interface{}{
+				"b": "replaced",
+				"d": map[string]interface{}{
+					"f": 20,
+					"g": []interface{}{"one"},
+				},
+			},
+			"h": true,
+		}
+		yaml.Merge(other)
+
+		checkGetValue(yaml, "a.b", "replaced")
+		checkGetValue(yaml, "a.custom.int-prop", 100)
+		checkGetValue(yaml, "a.d.e", false)
+		checkGetValue(yaml, "a.d.f", 20)
+		checkGetValue(yaml, "a.d.g", []interface{}{"one"})
+		checkGetValue(yaml, "h", true)
+
+		// The merged data is not shared
+		other["a"].(map[string]interface{})["d"].(map[string]interface{})["f"] = 30
+		checkGetValue(yaml, "a.d.f", 20)
+	})
+	It("notifies observers about the merged values", func() {
+		var events []ChangeEvent
+
+		yaml.OnChange("a", func(ev ChangeEvent) { events = append(events, ev) })
+		yaml.Merge(map[string]interface{}{
+			"a": map[string]interface{}{
+				"d": map[string]interface{}{
+					"e": false,
+					"f": 20,
+				},
+			},
+		})
+		Expect(events).To(Equal([]ChangeEvent{
+			{Type: ChangeUpdated, Path: "a.d.f", OldValue: 10, NewValue: 20},
+		}))
+	})
+})
@@ -0,0 +1,122 @@
+package yamlfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultFileMode - the permissions of newly created yaml files
+const DefaultFileMode os.FileMode = 0644
+
+// maxSymlinks - the max number of symlinks followed before giving up
+const maxSymlinks = 255
+
+// writeFileAtomic - write the data to a temp file in the same directory as the file and then
+// rename it over the file.  This way the file either has its previous contents or the new
+// contents, but is never left partially written.
+//
+// The permissions and (where possible) the ownership of an existing file are kept, unless the
+// "original" file is specified, in which case its permissions and ownership are used instead
+// (e.g. for the backups of a file).  When "replaceSymlinks" is false, a symlink is followed and
+// its target is written, otherwise the symlink itself is replaced by a regular file.
+func writeFileAtomic(filename string, data []byte, replaceSymlinks bool, original os.FileInfo) (err error) {
+	var (
+		target  = filename
+		mode    = DefaultFileMode
+		info    os.FileInfo
+		tmpFile *os.File
+	)
+
+	if !replaceSymlinks {
+		if target, err = resolveSymlinks(filename); err != nil {
+			return err
+		}
+	}
+
+	// Keep the mode and owner of the existing file (or of the file the symlink points to)
+	if original != nil {
+		info = original
+		mode = info.Mode().Perm()
+	} else if info, err = os.Stat(target); err == nil {
+		mode = info.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	dir, base := filepath.Split(target)
+	if tmpFile, err = os.CreateTemp(dir, "."+base+".tmp*"); err != nil {
+		return errors.Wrapf(err, "Failed to create temp file for '%s'", filename)
+	}
+
+	defer func() {
+		if err != nil {
+			tmpFile.Close()
+			os.Remove(tmpFile.Name())
+		}
+	}()
+
+	if _, err = tmpFile.Write(data); err != nil {
+		return err
+	}
+	if err = tmpFile.Sync(); err != nil {
+		return err
+	}
+	if err = tmpFile.Chmod(mode); err != nil {
+		return err
+	}
+	if info != nil {
+		if err = copyOwner(tmpFile, info); err != nil {
+			return err
+		}
+	}
+	if err = tmpFile.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpFile.Name(), target); err != nil {
+		return err
+	}
+
+	// Make sure the rename itself is persisted.  Not all platforms support this, so it is best effort.
+	syncDir(dir)
+
+	return nil
+}
+
+// resolveSymlinks - get the final file a (possibly dangling) symlink points to
+func resolveSymlinks(filename string) (string, error) {
+	for count := 0; count < maxSymlinks; count++ {
+		info, err := os.Lstat(filename)
+		if os.IsNotExist(err) {
+			return filename, nil
+		} else if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return filename, nil
+		}
+
+		link, err := os.Readlink(filename)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(filename), link)
+		}
+		filename = link
+	}
+	return "", fmt.Errorf("too many levels of symbolic links for '%s'", filename)
+}
+
+// syncDir - flush the directory entries of a directory to disk
+func syncDir(dir string) {
+	if dir == "" {
+		dir = "."
+	}
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
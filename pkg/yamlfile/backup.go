@@ -0,0 +1,202 @@
+package yamlfile
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultBackupSuffix - the suffix of the backup files created by BackupSuffix("")
+	DefaultBackupSuffix = ".bak"
+	// DefaultHistorySize - the number of previous versions kept by BackupHistory(0)
+	DefaultHistorySize = 10
+	// HistoryDir - the directory (next to the file) where BackupHistory keeps the previous versions
+	HistoryDir = ".goyaml/history"
+)
+
+// historyTimeFormat - the format of the timestamp in the names of the history files, which
+// sorts in chronological order
+const historyTimeFormat = "20060102T150405.000000000"
+
+// historyDirMode - the permissions of the history directory (see HistoryDir)
+const historyDirMode os.FileMode = 0700
+
+// ErrNoHistory - error generated when undoing the changes of a file without any history
+var ErrNoHistory = errors.New("No previous version found in the history")
+
+// Backup - keeps the current contents of a file before it is overwritten by Save
+type Backup func(fsys fs.FS, filename string) error
+
+// BackupSuffix - keep the current contents of the file in a backup file named after the file
+// with the suffix appended, e.g. "config.yaml.bak".  If the suffix is empty, then
+// DefaultBackupSuffix is used.
+func BackupSuffix(suffix string) Backup {
+	if suffix == "" {
+		suffix = DefaultBackupSuffix
+	}
+	return func(fsys fs.FS, filename string) error {
+		info, contents, err := readBackupSource(fsys, filename)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return writeFile(fsys, filename+suffix, contents, true, info)
+	}
+}
+
+// BackupHistory - keep the current contents of the file as a new version in the history
+// directory (see HistoryDir) next to the file, removing the oldest versions so that at most
+// "versions" are kept.  If "versions" is not positive, then DefaultHistorySize is used.
+// The versions can be restored with Undo.
+func BackupHistory(versions int) Backup {
+	if versions <= 0 {
+		versions = DefaultHistorySize
+	}
+	return func(fsys fs.FS, filename string) error {
+		info, contents, err := readBackupSource(fsys, filename)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		// The history may keep the versions of files that only their owner can read
+		dir := historyDir(fsys, filename)
+		if fsys == nil {
+			if err = os.MkdirAll(dir, historyDirMode); err != nil {
+				return err
+			}
+		}
+		version := joinFilePath(fsys, dir, baseName(fsys, filename)+"."+time.Now().Format(historyTimeFormat))
+		if err = writeFile(fsys, version, contents, true, info); err != nil {
+			return err
+		}
+
+		// Remove the oldest versions
+		history, err := historyVersions(fsys, filename)
+		if err != nil {
+			return err
+		}
+		for len(history) > versions {
+			if err = removeFile(fsys, history[0]); err != nil {
+				return err
+			}
+			history = history[1:]
+		}
+		return nil
+	}
+}
+
+// WithBackup - back up the file every time it is saved (see SaveWithBackup)
+func WithBackup(backup Backup) Option {
+	return func(y *yamlFile) {
+		y.backup = backup
+	}
+}
+
+// Undo - restore the file to its latest version in the history (see BackupHistory).  The
+// version is removed from the history, so undoing again restores the version before it.  If
+// there is no version in the history, then ErrNoHistory is returned.  The options are used
+// to access the file, e.g. FS.
+func Undo(filename string, opts ...Option) (err error) {
+	var (
+		settings = New(filename, opts...)
+		history  []string
+		contents []byte
+	)
+	if settings == nil {
+		return os.ErrInvalid
+	}
+	y := settings.(*yamlFile)
+
+	if history, err = historyVersions(y.fsys, filename); err != nil {
+		return err
+	} else if len(history) == 0 {
+		return ErrNoHistory
+	}
+
+	latest := history[len(history)-1]
+	if contents, err = readFile(y.fsys, latest); err != nil {
+		return err
+	}
+	if err = writeFile(y.fsys, filename, contents, y.replaceSymlinks, nil); err != nil {
+		return err
+	}
+	return removeFile(y.fsys, latest)
+}
+
+// readBackupSource - read the contents of the file being backed up, along with its info, so that
+// the backups get the same permissions (and owner) as the file
+func readBackupSource(fsys fs.FS, filename string) (info fs.FileInfo, contents []byte, err error) {
+	if info, err = statFile(fsys, filename); err != nil {
+		return nil, nil, err
+	}
+	if contents, err = readFile(fsys, filename); err != nil {
+		return nil, nil, err
+	}
+	return info, contents, nil
+}
+
+// historyVersions - get the versions of the file in the history, from oldest to newest
+func historyVersions(fsys fs.FS, filename string) (versions []string, err error) {
+	var (
+		dir     = historyDir(fsys, filename)
+		prefix  = baseName(fsys, filename) + "."
+		entries []fs.DirEntry
+	)
+
+	if fsys == nil {
+		entries, err = os.ReadDir(dir)
+	} else {
+		entries, err = fs.ReadDir(fsys, dir)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		// Skip the versions of other files, whose names start with the same prefix
+		if _, err := time.Parse(historyTimeFormat, strings.TrimPrefix(name, prefix)); err != nil {
+			continue
+		}
+		versions = append(versions, joinFilePath(fsys, dir, name))
+	}
+	sort.Strings(versions)
+
+	return versions, nil
+}
+
+func historyDir(fsys fs.FS, filename string) string {
+	if fsys == nil {
+		return filepath.Join(filepath.Dir(filename), filepath.FromSlash(HistoryDir))
+	}
+	return path.Join(path.Dir(filename), HistoryDir)
+}
+
+func baseName(fsys fs.FS, filename string) string {
+	if fsys == nil {
+		return filepath.Base(filename)
+	}
+	return path.Base(filename)
+}
+
+func joinFilePath(fsys fs.FS, elem ...string) string {
+	if fsys == nil {
+		return filepath.Join(elem...)
+	}
+	return path.Join(elem...)
+}
@@ -0,0 +1,120 @@
+package yamlfile
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("YamlFile backups", func() {
+	var memFS *MemFS
+
+	BeforeEach(func() {
+		memFS = NewMemFS(map[string]string{
+			"conf/test.yaml": "version: 0\n",
+		})
+	})
+	saveVersion := func(yamlFile YamlFile, version int, backup Backup) {
+		_, err := yamlFile.Set("version", version)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(yamlFile.SaveWithBackup(backup)).To(Succeed())
+	}
+	checkContents := func(name, expected string) {
+		contents, err := fs.ReadFile(memFS, name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal(expected))
+	}
+
+	It("keeps a backup file with a suffix", func() {
+		_, yamlFile, err := Load("conf/test.yaml", FS(memFS))
+		Expect(err).ToNot(HaveOccurred())
+
+		saveVersion(yamlFile, 1, BackupSuffix(""))
+		checkContents("conf/test.yaml.bak", "version: 0\n")
+
+		saveVersion(yamlFile, 2, BackupSuffix("~"))
+		checkContents("conf/test.yaml~", "version: 1\n")
+		checkContents("conf/test.yaml", "version: 2\n")
+	})
+	It("backs up on every save with the WithBackup option", func() {
+		_, yamlFile, err := Load("conf/test.yaml", FS(memFS), WithBackup(BackupSuffix(".orig")))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = yamlFile.Set("version", 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(yamlFile.Save()).To(Succeed())
+		checkContents("conf/test.yaml.orig", "version: 0\n")
+	})
+	It("does not back up a new file", func() {
+		yamlFile := New("conf/new.yaml", FS(memFS))
+		saveVersion(yamlFile, 1, BackupHistory(0))
+
+		_, err := fs.Stat(memFS, "conf/"+HistoryDir)
+		Expect(err).To(HaveOccurred())
+		Expect(Undo("conf/new.yaml", FS(memFS))).To(Equal(ErrNoHistory))
+	})
+	It("keeps a rotating history that can be undone", func() {
+		_, yamlFile, err := Load("conf/test.yaml", FS(memFS))
+		Expect(err).ToNot(HaveOccurred())
+
+		for version := 1; version <= 4; version++ {
+			saveVersion(yamlFile, version, BackupHistory(2))
+		}
+		entries, err := fs.ReadDir(memFS, "conf/"+HistoryDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+
+		// Only the 2 latest versions are kept
+		for version := 3; version >= 2; version-- {
+			Expect(Undo("conf/test.yaml", FS(memFS))).To(Succeed())
+			checkContents("conf/test.yaml", fmt.Sprintf("version: %d\n", version))
+		}
+		Expect(Undo("conf/test.yaml", FS(memFS))).To(Equal(ErrNoHistory))
+		checkContents("conf/test.yaml", "version: 2\n")
+	})
+	It("keeps the history of each file separately", func() {
+		Expect(memFS.WriteFile("conf/test.yaml.bak", []byte("version: 0\n"), DefaultFileMode)).To(Succeed())
+
+		_, yamlFile, err := Load("conf/test.yaml.bak", FS(memFS))
+		Expect(err).ToNot(HaveOccurred())
+		saveVersion(yamlFile, 1, BackupHistory(0))
+
+		Expect(Undo("conf/test.yaml", FS(memFS))).To(Equal(ErrNoHistory))
+		Expect(Undo("conf/test.yaml.bak", FS(memFS))).To(Succeed())
+		checkContents("conf/test.yaml.bak", "version: 0\n")
+	})
+	It("keeps the backups with the permissions of the file", func() {
+		dir, err := os.MkdirTemp("", "backups")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		filename := filepath.Join(dir, "secrets.yaml")
+		Expect(os.WriteFile(filename, []byte("version: 0\n"), 0600)).To(Succeed())
+		Expect(os.Chmod(filename, 0600)).To(Succeed())
+
+		_, yamlFile, err := Load(filename)
+		Expect(err).ToNot(HaveOccurred())
+		saveVersion(yamlFile, 1, BackupSuffix(""))
+		saveVersion(yamlFile, 2, BackupHistory(0))
+
+		info, err := os.Stat(filename + DefaultBackupSuffix)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+		historyDir := filepath.Join(dir, filepath.FromSlash(HistoryDir))
+		info, err = os.Stat(historyDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0700)))
+
+		entries, err := os.ReadDir(historyDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		info, err = entries[0].Info()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+})
@@ -0,0 +1,315 @@
+package yamlfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/theochva/goyaml/pkg/yamldoc"
+)
+
+var (
+	// PropertiesCodec - reads and writes Java ".properties" files, e.g. "db.host=localhost", with
+	// the indexes of the arrays as "servers[0]"
+	PropertiesCodec Codec = NewPropertiesCodec(yamldoc.FlattenOptions{Brackets: true})
+	// EnvCodec - reads and writes dotenv (".env") files, e.g. "DB__HOST=localhost", with the keys in
+	// upper snake case, so the keys of the nested values are separated with "__" rather than "_",
+	// which is kept for the keys themselves, e.g. "API_KEY" for "apiKey" or "api_key"
+	EnvCodec Codec = NewEnvCodec(yamldoc.FlattenOptions{Separator: "__", KeyCase: yamldoc.KeyCaseUpperSnake})
+)
+
+// NewPropertiesCodec - create a codec for Java ".properties" files, which flattens the data with
+// the options (see yamldoc.Flatten).  The keys and values are escaped as in Java, including the
+// characters other than ASCII (e.g. "é"), so that the files can be read as ISO-8859-1.
+func NewPropertiesCodec(opts yamldoc.FlattenOptions) Codec {
+	return propertiesCodec{opts: opts}
+}
+
+// NewEnvCodec - create a codec for dotenv (".env") files, which flattens the data with the options
+// (see yamldoc.Flatten).  The values are double quoted (and escaped) when needed and the lines may
+// start with "export".
+func NewEnvCodec(opts yamldoc.FlattenOptions) Codec {
+	return envCodec{opts: opts}
+}
+
+type propertiesCodec struct {
+	opts yamldoc.FlattenOptions
+}
+
+func (c propertiesCodec) Decode(contents []byte) (map[string]interface{}, error) {
+	var (
+		entries []yamldoc.FlatEntry
+		scanner = bufio.NewScanner(bytes.NewReader(contents))
+		line    string
+	)
+
+	for scanner.Scan() {
+		// A line ending with an odd number of backslashes continues on the next line
+		text := scanner.Text()
+		if line != "" {
+			text = strings.TrimLeft(text, " \t\f")
+		} else if trimmed := strings.TrimLeft(text, " \t\f"); trimmed == "" || trimmed[0] == '#' || trimmed[0] == '!' {
+			// The comments do not continue on the next line
+			continue
+		}
+		line += text
+		if trailing := len(line) - len(strings.TrimRight(line, `\`)); trailing%2 == 1 {
+			line = line[:len(line)-1]
+			continue
+		}
+
+		trimmed := strings.TrimLeft(line, " \t\f")
+		line = ""
+
+		// The key ends at the first unescaped '=', ':' or whitespace
+		end := 0
+		for end < len(trimmed) && !strings.ContainsRune("=: \t\f", rune(trimmed[end])) {
+			if trimmed[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end > len(trimmed) {
+			end = len(trimmed)
+		}
+		value := strings.TrimLeft(trimmed[end:], " \t\f")
+		if value != "" && (value[0] == '=' || value[0] == ':') {
+			value = strings.TrimLeft(value[1:], " \t\f")
+		}
+
+		key, err := unescapeProperty(trimmed[:end])
+		if err != nil {
+			return nil, err
+		}
+		if value, err = unescapeProperty(value); err != nil {
+			return nil, err
+		}
+		entries = append(entries, yamldoc.FlatEntry{Key: key, Value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return yamldoc.Unflatten(entries, c.opts)
+}
+
+func (c propertiesCodec) Encode(data map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	entries, err := yamldoc.Flatten(data, c.opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		buf.WriteString(escapeProperty(entry.Key, true))
+		buf.WriteByte('=')
+		buf.WriteString(escapeProperty(entry.Value, false))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// escapeProperty - escape a key or a value of a ".properties" file
+func escapeProperty(text string, isKey bool) string {
+	var builder strings.Builder
+
+	for index, r := range text {
+		switch {
+		case r == '\\':
+			builder.WriteString(`\\`)
+		case r == '\n':
+			builder.WriteString(`\n`)
+		case r == '\r':
+			builder.WriteString(`\r`)
+		case r == '\t':
+			builder.WriteString(`\t`)
+		case r == '\f':
+			builder.WriteString(`\f`)
+		case r == ' ' && (isKey || index == 0):
+			builder.WriteString(`\ `)
+		case strings.ContainsRune("=:#!", r) && (isKey || index == 0):
+			builder.WriteByte('\\')
+			builder.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			for _, unit := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&builder, `\u%04x`, unit)
+			}
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// unescapeProperty - unescape a key or a value of a ".properties" file
+func unescapeProperty(text string) (string, error) {
+	var (
+		units   []uint16
+		builder strings.Builder
+	)
+	flushUnits := func() {
+		if len(units) > 0 {
+			builder.WriteString(string(utf16.Decode(units)))
+			units = nil
+		}
+	}
+
+	for index := 0; index < len(text); index++ {
+		if text[index] != '\\' || index == len(text)-1 {
+			flushUnits()
+			builder.WriteByte(text[index])
+			continue
+		}
+		index++
+		switch text[index] {
+		case 'n':
+			flushUnits()
+			builder.WriteByte('\n')
+		case 'r':
+			flushUnits()
+			builder.WriteByte('\r')
+		case 't':
+			flushUnits()
+			builder.WriteByte('\t')
+		case 'f':
+			flushUnits()
+			builder.WriteByte('\f')
+		case 'u':
+			if index+5 > len(text) {
+				return "", fmt.Errorf("invalid unicode escape in '%s'", text)
+			}
+			unit, err := strconv.ParseUint(text[index+1:index+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape in '%s'", text)
+			}
+			units = append(units, uint16(unit))
+			index += 4
+		default:
+			flushUnits()
+			builder.WriteByte(text[index])
+		}
+	}
+	flushUnits()
+	return builder.String(), nil
+}
+
+// envNameRegex - the valid names of the environment variables
+var envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// envUnquotedRegex - the values that are written out without quotes
+var envUnquotedRegex = regexp.MustCompile(`^[A-Za-z0-9_./:@,+%=-]*$`)
+
+type envCodec struct {
+	opts yamldoc.FlattenOptions
+}
+
+func (c envCodec) Decode(contents []byte) (map[string]interface{}, error) {
+	var (
+		entries []yamldoc.FlatEntry
+		scanner = bufio.NewScanner(bytes.NewReader(contents))
+		lineNo  = 0
+	)
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		parts := strings.SplitN(line, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || !envNameRegex.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid line '%s'", lineNo, line)
+		}
+		value, err := unquoteEnvValue(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", lineNo, err)
+		}
+		entries = append(entries, yamldoc.FlatEntry{Key: key, Value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return yamldoc.Unflatten(entries, c.opts)
+}
+
+func (c envCodec) Encode(data map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	entries, err := yamldoc.Flatten(data, c.opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !envNameRegex.MatchString(entry.Key) {
+			return nil, fmt.Errorf("key '%s' is not a valid environment variable name", entry.Key)
+		}
+		buf.WriteString(entry.Key)
+		buf.WriteByte('=')
+		buf.WriteString(quoteEnvValue(entry.Value))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// quoteEnvValue - double quote (and escape) the value of a dotenv file, unless it is safe without quotes
+func quoteEnvValue(value string) string {
+	if envUnquotedRegex.MatchString(value) {
+		return value
+	}
+	return `"` + strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"$", `\$`,
+		"`", "\\`",
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+	).Replace(value) + `"`
+}
+
+// unquoteEnvValue - unquote the value of a dotenv file: the single quoted values are kept as they
+// are, the double quoted values are unescaped and the unquoted values end at a " #" comment
+func unquoteEnvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quoted value %s", value)
+		}
+		return value[1 : end+1], nil
+	case strings.HasPrefix(value, `"`):
+		var builder strings.Builder
+		for index := 1; index < len(value); index++ {
+			switch value[index] {
+			case '"':
+				return builder.String(), nil
+			case '\\':
+				if index++; index < len(value) {
+					switch value[index] {
+					case 'n':
+						builder.WriteByte('\n')
+					case 'r':
+						builder.WriteByte('\r')
+					case 't':
+						builder.WriteByte('\t')
+					default:
+						builder.WriteByte(value[index])
+					}
+				}
+			default:
+				builder.WriteByte(value[index])
+			}
+		}
+		return "", fmt.Errorf("unterminated quoted value %s", value)
+	}
+	if comment := strings.Index(value, " #"); comment >= 0 {
+		value = strings.TrimSpace(value[:comment])
+	}
+	return value, nil
+}
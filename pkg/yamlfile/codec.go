@@ -0,0 +1,367 @@
+package yamlfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/theochva/goyaml/pkg/yamldoc"
+)
+
+// Codec - converts the contents of a file to and from the data of a yaml document
+type Codec interface {
+	// Decode - parse the contents of a file
+	Decode(contents []byte) (data map[string]interface{}, err error)
+	// Encode - format the data as the contents of a file
+	Encode(data map[string]interface{}) (contents []byte, err error)
+}
+
+var (
+	// YAMLCodec - reads and writes YAML (the default codec)
+	YAMLCodec Codec = yamlCodec{}
+	// JSONCodec - reads and writes JSON
+	JSONCodec Codec = jsonCodec{}
+	// TOMLCodec - reads and writes TOML
+	TOMLCodec Codec = tomlCodec{}
+)
+
+var (
+	codecsMutex sync.RWMutex
+	codecs      = map[string]Codec{
+		".yaml":       YAMLCodec,
+		".yml":        YAMLCodec,
+		".json":       JSONCodec,
+		".toml":       TOMLCodec,
+		".properties": PropertiesCodec,
+		".env":        EnvCodec,
+	}
+)
+
+// RegisterCodec - register the codec used for files with the extension (e.g. ".ini"), replacing
+// any codec already registered for it.  Extensions are not case sensitive.
+func RegisterCodec(ext string, codec Codec) {
+	codecsMutex.Lock()
+	defer codecsMutex.Unlock()
+
+	codecs[normalizeExt(ext)] = codec
+}
+
+// CodecFor - get the codec registered for the extension of the filename.  The extension of
+// compressed files is skipped, e.g. "config.json.gz" uses JSONCodec.  If there is no codec
+// registered for the extension, then YAMLCodec is returned.
+func CodecFor(filename string) Codec {
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+
+	if codec, found := codecs[normalizeExt(filepath.Ext(trimCompressionExt(filename)))]; found {
+		return codec
+	}
+	return YAMLCodec
+}
+
+// WithCodec - use the codec to load and save the file, instead of the one registered for
+// its extension
+func WithCodec(codec Codec) Option {
+	return func(y *yamlFile) {
+		y.codec = codec
+	}
+}
+
+func normalizeExt(ext string) string {
+	ext = strings.ToLower(ext)
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// docCodec - implemented by codecs that read and write yamldoc documents directly, so that the
+// yamldoc load options (e.g. limits) apply and the nodes of the documents (e.g. their anchors)
+// are kept
+type docCodec interface {
+	DecodeDoc(contents []byte, opts ...yamldoc.LoadOption) (yamldoc.YamlDoc, error)
+	EncodeDoc(doc yamldoc.YamlDoc) ([]byte, error)
+}
+
+// decodeDoc - decode the contents with the codec into a yamldoc document
+func decodeDoc(codec Codec, contents []byte, opts []yamldoc.LoadOption) (yamldoc.YamlDoc, error) {
+	if decoder, ok := codec.(docCodec); ok {
+		return decoder.DecodeDoc(contents, opts...)
+	}
+	data, err := codec.Decode(contents)
+	if err != nil {
+		return nil, err
+	}
+	doc, _ := yamldoc.New(nil, opts...)
+	return doc.SetData(data), nil
+}
+
+// encodeDoc - encode the yamldoc document with the codec
+func encodeDoc(codec Codec, doc yamldoc.YamlDoc) ([]byte, error) {
+	if encoder, ok := codec.(docCodec); ok {
+		return encoder.EncodeDoc(doc)
+	}
+	return codec.Encode(doc.Data())
+}
+
+type yamlCodec struct{}
+
+func (c yamlCodec) Decode(contents []byte) (map[string]interface{}, error) {
+	doc, err := c.DecodeDoc(contents)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Data(), nil
+}
+
+func (yamlCodec) DecodeDoc(contents []byte, opts ...yamldoc.LoadOption) (yamldoc.YamlDoc, error) {
+	return yamldoc.FromBytes(contents, opts...)
+}
+
+func (yamlCodec) EncodeDoc(doc yamldoc.YamlDoc) ([]byte, error) {
+	return doc.Bytes()
+}
+
+func (yamlCodec) Encode(data map[string]interface{}) ([]byte, error) {
+	doc, _ := yamldoc.New(nil)
+	return doc.SetData(data).Bytes()
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Decode(contents []byte) (map[string]interface{}, error) {
+	var data map[string]interface{}
+
+	// Keep the numbers as they are, so that integers are not turned into floats
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+	return normalizeValue(data).(map[string]interface{}), nil
+}
+
+func (jsonCodec) Encode(data map[string]interface{}) ([]byte, error) {
+	contents, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(contents, '\n'), nil
+}
+
+// TOMLOptions - the options of a TOML codec (see NewTOMLCodec)
+type TOMLOptions struct {
+	// Datetimes - encode the timestamps (time.Time values, i.e. the YAML values tagged as
+	// "!!timestamp", but not quoted strings such as "1979-05-27") as TOML datetimes, keeping their
+	// fractional seconds and offsets, and decode the TOML datetimes as time.Time values, which are
+	// written out as YAML timestamps.  As with YAML timestamps, the TOML local datetimes and local
+	// dates are decoded in UTC, while the TOML local times (which YAML has no timestamps for) are
+	// decoded as strings, e.g. "07:32:00".  Otherwise, the timestamps are encoded as TOML strings
+	// (in RFC 3339 format) and the TOML datetimes are decoded as strings in their TOML format, e.g.
+	// "1979-05-27T07:32:00-08:00", "1979-05-27T07:32:00", "1979-05-27" or "07:32:00".
+	Datetimes bool
+}
+
+// NewTOMLCodec - create a TOML codec with the options (TOMLCodec has the default options)
+func NewTOMLCodec(opts TOMLOptions) Codec {
+	return tomlCodec{opts: opts}
+}
+
+type tomlCodec struct {
+	opts TOMLOptions
+}
+
+func (c tomlCodec) Decode(contents []byte) (map[string]interface{}, error) {
+	var data map[string]interface{}
+
+	if err := toml.Unmarshal(contents, &data); err != nil {
+		return nil, err
+	}
+	data = normalizeValue(data).(map[string]interface{})
+	if c.opts.Datetimes {
+		data = convertTOMLValue(data, localDatetime).(map[string]interface{})
+	} else {
+		data = convertTOMLValue(data, formatTOMLDatetime).(map[string]interface{})
+	}
+	return data, nil
+}
+
+// Encode - encode the data as TOML.  Since TOML cannot represent null values or arrays mixing
+// values of different types, they fail with errors naming the keys of the values.
+func (c tomlCodec) Encode(data map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if !c.opts.Datetimes {
+		data = convertTOMLValue(data, formatDatetime).(map[string]interface{})
+	}
+	if err := checkTOMLValue(nil, data); err != nil {
+		return nil, err
+	}
+	if err := toml.NewEncoder(&buf).Encode(data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// checkTOMLValue - check that the value can be represented in TOML
+func checkTOMLValue(path []string, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return fmt.Errorf("key '%s': TOML cannot represent null values", strings.Join(path, "."))
+	case map[string]interface{}:
+		for key, item := range v {
+			if err := checkTOMLValue(append(path[:len(path):len(path)], key), item); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for index, item := range v {
+			itemPath := append(path[:len(path):len(path)], strconv.Itoa(index))
+			if err := checkTOMLValue(itemPath, item); err != nil {
+				return err
+			}
+			if itemType, firstType := tomlType(item), tomlType(v[0]); itemType != firstType {
+				return fmt.Errorf("key '%s': TOML cannot represent arrays mixing %s and %s values",
+					strings.Join(path, "."), firstType, itemType)
+			}
+		}
+	}
+	return nil
+}
+
+// tomlType - the name of the TOML type of a value
+func tomlType(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		return "float"
+	case bool:
+		return "boolean"
+	case time.Time:
+		return "datetime"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "table"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// convertTOMLValue - get a copy of the value with the values (other than maps and arrays) within
+// it converted
+func convertTOMLValue(value interface{}, convert func(value interface{}) interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = convertTOMLValue(item, convert)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for index, item := range v {
+			result[index] = convertTOMLValue(item, convert)
+		}
+		return result
+	}
+	return convert(value)
+}
+
+// The names of the locations of the TOML local datetimes, dates and times decoded
+const (
+	tomlLocalDatetime = "datetime-local"
+	tomlLocalDate     = "date-local"
+	tomlLocalTime     = "time-local"
+)
+
+// tomlLocalLayouts - the layouts of the TOML local datetimes, dates and times
+var tomlLocalLayouts = map[string]string{
+	tomlLocalDatetime: "2006-01-02T15:04:05.999999999",
+	tomlLocalDate:     "2006-01-02",
+	tomlLocalTime:     "15:04:05.999999999",
+}
+
+// formatDatetime - format the timestamps as strings in RFC 3339 format, keeping their
+// fractional seconds and offsets
+func formatDatetime(value interface{}) interface{} {
+	if datetime, ok := value.(time.Time); ok {
+		return datetime.Format(time.RFC3339Nano)
+	}
+	return value
+}
+
+// formatTOMLDatetime - format the TOML datetimes decoded as strings in their TOML format, so that
+// the local datetimes, dates and times do not get an offset
+func formatTOMLDatetime(value interface{}) interface{} {
+	if datetime, ok := value.(time.Time); ok {
+		if layout, found := tomlLocalLayouts[datetime.Location().String()]; found {
+			return datetime.Format(layout)
+		}
+	}
+	return formatDatetime(value)
+}
+
+// localDatetime - convert the TOML local datetimes and dates to UTC timestamps, the way YAML
+// reads the timestamps without an offset, and the TOML local times (which YAML has no timestamps
+// for) to strings
+func localDatetime(value interface{}) interface{} {
+	if datetime, ok := value.(time.Time); ok {
+		switch datetime.Location().String() {
+		case tomlLocalDatetime, tomlLocalDate:
+			year, month, day := datetime.Date()
+			hour, min, sec := datetime.Clock()
+			return time.Date(year, month, day, hour, min, sec, datetime.Nanosecond(), time.UTC)
+		case tomlLocalTime:
+			return formatTOMLDatetime(value)
+		}
+	}
+	return value
+}
+
+// normalizeValue - convert the values decoded from JSON or TOML to the types used for the same
+// values decoded from YAML, i.e. integers are int and arrays are []interface{}
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			return map[string]interface{}{}
+		}
+		for key, item := range v {
+			v[key] = normalizeValue(item)
+		}
+		return v
+	case []interface{}:
+		for index, item := range v {
+			v[index] = normalizeValue(item)
+		}
+		return v
+	case []map[string]interface{}:
+		result := make([]interface{}, len(v))
+		for index, item := range v {
+			result[index] = normalizeValue(item)
+		}
+		return result
+	case json.Number:
+		if intValue, err := v.Int64(); err == nil && int64(int(intValue)) == intValue {
+			return int(intValue)
+		}
+		floatValue, _ := v.Float64()
+		return floatValue
+	case int64:
+		if int64(int(v)) == v {
+			return int(v)
+		}
+		return v
+	}
+	return value
+}
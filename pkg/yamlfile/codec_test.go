@@ -0,0 +1,230 @@
+package yamlfile
+
+import (
+	"io/fs"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/theochva/goyaml/pkg/yamldoc"
+)
+
+type upperCodec struct{}
+
+func (upperCodec) Decode(contents []byte) (map[string]interface{}, error) {
+	return YAMLCodec.Decode([]byte(strings.ToLower(string(contents))))
+}
+
+func (upperCodec) Encode(data map[string]interface{}) ([]byte, error) {
+	contents, err := YAMLCodec.Encode(data)
+	return []byte(strings.ToUpper(string(contents))), err
+}
+
+var _ = Describe("YamlFile codecs", func() {
+	It("chooses the codec by the file extension", func() {
+		Expect(CodecFor("a.yaml")).To(Equal(YAMLCodec))
+		Expect(CodecFor("a.YML")).To(Equal(YAMLCodec))
+		Expect(CodecFor("a.json")).To(Equal(JSONCodec))
+		Expect(CodecFor("dir/a.toml")).To(Equal(TOMLCodec))
+		Expect(CodecFor("a")).To(Equal(YAMLCodec))
+		Expect(CodecFor("a.conf")).To(Equal(YAMLCodec))
+	})
+	It("loads and saves JSON files", func() {
+		memFS := NewMemFS(map[string]string{
+			"test.json": `{"a": {"b": {"c": "value-c"}, "d": {"e": false, "f": 10}}}`,
+		})
+		loaded, yamlFile, err := Load("test.json", FS(memFS))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded).To(BeTrue())
+		checkText(yamlFile, yamlText)
+		Expect(yamlFile.GetInt("a.d.f")).To(Equal(10))
+
+		_, err = yamlFile.Set("a.b.c", "changed")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(yamlFile.Save()).To(Succeed())
+
+		contents, err := fs.ReadFile(memFS, "test.json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contents).To(MatchJSON(`{"a": {"b": {"c": "changed"}, "d": {"e": false, "f": 10}}}`))
+	})
+	It("loads and saves TOML files", func() {
+		memFS := NewMemFS(map[string]string{
+			"test.toml": "[a.b]\nc = \"value-c\"\n[a.d]\ne = false\nf = 10\n",
+		})
+		_, yamlFile, err := Load("test.toml", FS(memFS))
+		Expect(err).ToNot(HaveOccurred())
+		checkText(yamlFile, yamlText)
+		Expect(yamlFile.GetInt("a.d.f")).To(Equal(10))
+
+		_, err = yamlFile.Set("a.d.f", 20)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(yamlFile.Save()).To(Succeed())
+
+		contents, err := fs.ReadFile(memFS, "test.toml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring("f = 20"))
+	})
+	It("fails to encode values that TOML cannot represent", func() {
+		_, err := TOMLCodec.Encode(map[string]interface{}{"a": map[string]interface{}{"b": nil}})
+		Expect(err).To(MatchError("key 'a.b': TOML cannot represent null values"))
+
+		_, err = TOMLCodec.Encode(map[string]interface{}{"a": []interface{}{"one", 2}})
+		Expect(err).To(MatchError("key 'a': TOML cannot represent arrays mixing string and integer values"))
+	})
+	It("converts the datetimes with the TOML options", func() {
+		created := time.Date(1979, time.May, 27, 7, 32, 0, 0, time.UTC)
+		data := map[string]interface{}{"created": created}
+
+		contents, err := TOMLCodec.Encode(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("created = \"1979-05-27T07:32:00Z\"\n"))
+		decoded, err := TOMLCodec.Decode([]byte("created = 1979-05-27T07:32:00Z\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal(map[string]interface{}{"created": "1979-05-27T07:32:00Z"}))
+
+		codec := NewTOMLCodec(TOMLOptions{Datetimes: true})
+		contents, err = codec.Encode(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("created = 1979-05-27T07:32:00Z\n"))
+		decoded, err = codec.Decode(contents)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded["created"]).To(BeAssignableToTypeOf(time.Time{}))
+		Expect(decoded["created"].(time.Time).Equal(created)).To(BeTrue())
+	})
+	It("converts only the YAML timestamps to TOML datetimes, keeping their precision and offsets", func() {
+		doc, err := yamldoc.FromString("s: \"2020-01-02\"\nd: 2001-12-14t21:59:43.10-05:00\n")
+		Expect(err).ToNot(HaveOccurred())
+
+		codec := NewTOMLCodec(TOMLOptions{Datetimes: true})
+		contents, err := codec.Encode(doc.Data())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("d = 2001-12-14T21:59:43.1-05:00\ns = \"2020-01-02\"\n"))
+
+		contents, err = TOMLCodec.Encode(doc.Data())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("d = \"2001-12-14T21:59:43.1-05:00\"\ns = \"2020-01-02\"\n"))
+	})
+	It("keeps the TOML local datetimes, dates and times without an offset", func() {
+		contents := []byte("dt = 1979-05-27T07:32:00.5\nl = 1979-05-27\nt = 07:32:00\n")
+
+		decoded, err := TOMLCodec.Decode(contents)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal(map[string]interface{}{
+			"dt": "1979-05-27T07:32:00.5",
+			"l":  "1979-05-27",
+			"t":  "07:32:00",
+		}))
+
+		decoded, err = NewTOMLCodec(TOMLOptions{Datetimes: true}).Decode(contents)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal(map[string]interface{}{
+			"dt": time.Date(1979, time.May, 27, 7, 32, 0, 500000000, time.UTC),
+			"l":  time.Date(1979, time.May, 27, 0, 0, 0, 0, time.UTC),
+			"t":  "07:32:00",
+		}))
+	})
+	It("encodes and decodes Java properties", func() {
+		Expect(CodecFor("app.properties")).To(Equal(PropertiesCodec))
+		data := map[string]interface{}{
+			"db":      map[string]interface{}{"host": "localhost", "port": 5432, "url": "jdbc:pg://h/db?a=b"},
+			"key one": " padded\ttext",
+			"name":    "café",
+			"servers": []interface{}{"one", "two"},
+		}
+
+		contents, err := PropertiesCodec.Encode(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal(`db.host=localhost
+db.port=5432
+db.url=jdbc:pg://h/db?a=b
+key\ one=\ padded\ttext
+name=caf\u00e9
+servers[0]=one
+servers[1]=two
+`))
+		decoded, err := PropertiesCodec.Decode(contents)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal(data))
+
+		decoded, err = PropertiesCodec.Decode([]byte("# comment \\\n! other\na : multi \\\n    line\nb value\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal(map[string]interface{}{"a": "multi line", "b": "value"}))
+	})
+	It("encodes and decodes dotenv files", func() {
+		Expect(CodecFor("prod.env")).To(Equal(EnvCodec))
+		data := map[string]interface{}{
+			"db":       map[string]interface{}{"host": "localhost", "port": 5432},
+			"greeting": "hello \"world\"\n$HOME",
+			"servers":  []interface{}{"one", "two"},
+		}
+
+		contents, err := EnvCodec.Encode(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal(`DB__HOST=localhost
+DB__PORT=5432
+GREETING="hello \"world\"\n\$HOME"
+SERVERS__0=one
+SERVERS__1=two
+`))
+		decoded, err := EnvCodec.Decode(contents)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal(data))
+
+		decoded, err = EnvCodec.Decode([]byte("# comment\nexport A='single $X'\nB=plain # comment\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal(map[string]interface{}{"a": "single $X", "b": "plain"}))
+
+		_, err = EnvCodec.Decode([]byte("not a variable\n"))
+		Expect(err).To(MatchError("line 1: invalid line 'not a variable'"))
+
+		// The keys containing "_" (once transformed) are not split into nested keys
+		data = map[string]interface{}{
+			"api_key": "abc",
+			"db_host": "x",
+			"db":      map[string]interface{}{"port_number": 5432},
+		}
+		contents, err = EnvCodec.Encode(map[string]interface{}{
+			"apiKey":  "abc",
+			"db_host": "x",
+			"db":      map[string]interface{}{"portNumber": 5432},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("API_KEY=abc\nDB__PORT_NUMBER=5432\nDB_HOST=x\n"))
+		decoded, err = EnvCodec.Decode(contents)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal(data))
+
+		_, err = NewEnvCodec(yamldoc.FlattenOptions{}).Encode(map[string]interface{}{"calling-birds": "four"})
+		Expect(err).To(MatchError("key 'calling-birds' is not a valid environment variable name"))
+	})
+	It("can use registered and explicit codecs", func() {
+		RegisterCodec("upper", upperCodec{})
+		Expect(CodecFor("test.UPPER")).To(Equal(upperCodec{}))
+
+		memFS := NewMemFS(map[string]string{
+			"test.upper": "A: VALUE",
+			"test.txt":   "A: VALUE",
+		})
+		_, yamlFile, err := Load("test.upper", FS(memFS))
+		Expect(err).ToNot(HaveOccurred())
+		checkText(yamlFile, "a: value")
+
+		_, yamlFile, err = Load("test.txt", FS(memFS), WithCodec(upperCodec{}))
+		Expect(err).ToNot(HaveOccurred())
+		checkText(yamlFile, "a: value")
+		Expect(yamlFile.Save()).To(Succeed())
+
+		contents, err := fs.ReadFile(memFS, "test.txt")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("A: VALUE\n"))
+	})
+	It("fails to load files that cannot be parsed", func() {
+		memFS := NewMemFS(map[string]string{
+			"test.json": `{"a": `,
+		})
+		_, _, err := Load("test.json", FS(memFS))
+		Expect(err).To(HaveOccurred())
+	})
+})
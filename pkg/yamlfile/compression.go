@@ -0,0 +1,118 @@
+package yamlfile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/theochva/goyaml/pkg/yamldoc"
+)
+
+// Compression - the compression of the contents of a file
+type Compression string
+
+const (
+	// NoCompression - the contents are not compressed
+	NoCompression Compression = ""
+	// Gzip - the contents are compressed with gzip (e.g. "config.yaml.gz")
+	Gzip Compression = "gzip"
+	// Zstd - the contents are compressed with zstd (e.g. "config.yaml.zst")
+	Zstd Compression = "zstd"
+)
+
+var (
+	// compressionExts - the extensions of compressed files
+	compressionExts = map[string]Compression{
+		".gz":   Gzip,
+		".zst":  Zstd,
+		".zstd": Zstd,
+	}
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// WithCompression - compress the contents of the file when saving it, instead of using the
+// compression detected when loading it or the one implied by its extension
+func WithCompression(compression Compression) Option {
+	return func(y *yamlFile) {
+		y.compression = compression
+		y.compressionSet = true
+	}
+}
+
+// compressionFor - get the compression implied by the extension of the filename
+func compressionFor(filename string) Compression {
+	return compressionExts[strings.ToLower(filepath.Ext(filename))]
+}
+
+// trimCompressionExt - remove the extension of a compressed file, e.g. "config.yaml.gz" -> "config.yaml"
+func trimCompressionExt(filename string) string {
+	if ext := filepath.Ext(filename); compressionFor(filename) != NoCompression {
+		return strings.TrimSuffix(filename, ext)
+	}
+	return filename
+}
+
+// detectCompression - detect the compression of the contents from their magic bytes
+func detectCompression(contents []byte) Compression {
+	switch {
+	case bytes.HasPrefix(contents, gzipMagic):
+		return Gzip
+	case bytes.HasPrefix(contents, zstdMagic):
+		return Zstd
+	}
+	return NoCompression
+}
+
+// decompress - decompress the contents with the compression.  The MaxBytes limit of the load
+// options (if any) applies to the decompressed contents.
+func decompress(contents []byte, compression Compression, opts ...yamldoc.LoadOption) ([]byte, error) {
+	switch compression {
+	case Gzip:
+		reader, err := gzip.NewReader(bytes.NewReader(contents))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+
+		return yamldoc.ReadAll(reader, opts...)
+	case Zstd:
+		decoder, err := zstd.NewReader(bytes.NewReader(contents))
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+
+		return yamldoc.ReadAll(decoder, opts...)
+	}
+	return contents, nil
+}
+
+// compress - compress the contents with the compression
+func compress(contents []byte, compression Compression) ([]byte, error) {
+	switch compression {
+	case Gzip:
+		var buf bytes.Buffer
+
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(contents); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case Zstd:
+		encoder, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer encoder.Close()
+
+		return encoder.EncodeAll(contents, nil), nil
+	}
+	return contents, nil
+}
@@ -0,0 +1,107 @@
+package yamlfile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/theochva/goyaml/pkg/yamldoc"
+)
+
+var _ = Describe("YamlFile compression", func() {
+	gzipped := func(text string) string {
+		contents, err := compress([]byte(text), Gzip)
+		Expect(err).ToNot(HaveOccurred())
+		return string(contents)
+	}
+	readGzipped := func(memFS *MemFS, name string) string {
+		contents, err := fs.ReadFile(memFS, name)
+		Expect(err).ToNot(HaveOccurred())
+		reader, err := gzip.NewReader(bytes.NewReader(contents))
+		Expect(err).ToNot(HaveOccurred())
+		text, err := io.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+		return string(text)
+	}
+
+	It("loads and saves gzip compressed files", func() {
+		memFS := NewMemFS(map[string]string{
+			"test.yaml.gz": gzipped("a: value-a\n"),
+		})
+		_, yamlFile, err := Load("test.yaml.gz", FS(memFS))
+		Expect(err).ToNot(HaveOccurred())
+		checkText(yamlFile, "a: value-a")
+
+		_, err = yamlFile.Set("a", "changed")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(yamlFile.Save()).To(Succeed())
+		Expect(readGzipped(memFS, "test.yaml.gz")).To(Equal("a: changed\n"))
+	})
+	It("detects compressed contents by their magic bytes", func() {
+		zstdContents, err := compress([]byte(`{"a": "value-a"}`), Zstd)
+		Expect(err).ToNot(HaveOccurred())
+		memFS := NewMemFS(map[string]string{
+			"test.json": string(zstdContents),
+		})
+
+		_, yamlFile, err := Load("test.json", FS(memFS))
+		Expect(err).ToNot(HaveOccurred())
+		checkText(yamlFile, "a: value-a")
+
+		// Saved with the same compression
+		_, err = yamlFile.Set("a", "changed")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(yamlFile.Save()).To(Succeed())
+
+		contents, err := fs.ReadFile(memFS, "test.json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(detectCompression(contents)).To(Equal(Zstd))
+		text, err := decompress(contents, Zstd)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(text).To(MatchJSON(`{"a": "changed"}`))
+	})
+	It("limits the size of the decompressed contents", func() {
+		text := "a: " + strings.Repeat("x", 10000) + "\n"
+		memFS := NewMemFS(map[string]string{
+			"test.yaml.gz": gzipped(text),
+		})
+
+		_, _, err := Load("test.yaml.gz", FS(memFS), WithLoadOptions(yamldoc.MaxBytes(1000)))
+		Expect(yamldoc.IsLimitError(err)).To(BeTrue())
+
+		_, yamlFile, err := Load("test.yaml.gz", FS(memFS), WithLoadOptions(yamldoc.MaxBytes(int64(len(text)))))
+		Expect(err).ToNot(HaveOccurred())
+		checkText(yamlFile, strings.TrimSpace(text))
+	})
+	It("compresses new files by their extension", func() {
+		memFS := NewMemFS(nil)
+
+		yamlFile := New("new.json.gz", FS(memFS))
+		Expect(CodecFor("new.json.gz")).To(Equal(JSONCodec))
+		_, err := yamlFile.Set("a", "value-a")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(yamlFile.Save()).To(Succeed())
+		Expect(readGzipped(memFS, "new.json.gz")).To(MatchJSON(`{"a": "value-a"}`))
+	})
+	It("uses the compression specified with an option", func() {
+		memFS := NewMemFS(map[string]string{
+			"test.yaml": "a: value-a\n",
+		})
+		_, yamlFile, err := Load("test.yaml", FS(memFS), WithCompression(Gzip))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(yamlFile.Save()).To(Succeed())
+		Expect(readGzipped(memFS, "test.yaml")).To(Equal("a: value-a\n"))
+	})
+	It("fails to load corrupted compressed contents", func() {
+		memFS := NewMemFS(map[string]string{
+			"test.yaml.gz": gzipped("a: value-a\n")[:12],
+		})
+		_, _, err := Load("test.yaml.gz", FS(memFS))
+		Expect(err).To(HaveOccurred())
+	})
+})
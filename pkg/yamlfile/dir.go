@@ -0,0 +1,272 @@
+package yamlfile
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/theochva/goyaml/pkg/yamldoc"
+)
+
+const (
+	// DefaultDirPattern - the pattern of the fragment files loaded by LoadDir("dir", "")
+	DefaultDirPattern = "*.yaml"
+	// DefaultDirFragment - the fragment new keys are saved to, when the directory has no fragments
+	DefaultDirFragment = "default.yaml"
+)
+
+// DirDoc - a yaml document merged from the fragment files in a directory (e.g. "conf.d"),
+// which saves each value back to the fragment it came from
+type DirDoc interface {
+	yamldoc.YamlDoc
+
+	// Dir - get the directory of the fragments
+	Dir() string
+	// Fragments - get the fragment files, in the order they were merged
+	Fragments() []string
+	// Owner - get the fragment file that owns the value at key
+	Owner(key string) string
+	// Save - saves the changed values to the fragments that own them
+	Save() (err error)
+	// SaveWithBackup - saves the changed values (see Save), after backing up the current
+	// contents of each fragment that is saved
+	SaveWithBackup(backup Backup) (err error)
+}
+
+type dirDoc struct {
+	yamldoc.YamlDoc
+	dir             string
+	opts            []Option
+	fsys            fs.FS
+	backup          Backup
+	fragments       []YamlFile
+	owners          map[string]string
+	defaultFragment string
+}
+
+// DefaultFragment - the fragment file (in the directory) that LoadDir saves new keys to.  By
+// default, this is the last fragment, so that new keys are not overridden by other fragments,
+// or DefaultDirFragment if there are no fragments.
+func DefaultFragment(name string) Option {
+	return func(y *yamlFile) {
+		y.defaultFragment = name
+	}
+}
+
+// LoadDir - load the fragment files in a directory that match the pattern (see filepath.Match)
+// and merge them into one document in lexical order, so that values in later fragments override
+// values in earlier fragments.  For example:
+//
+//	doc, err := LoadDir("conf.d", "*.yaml")
+//
+// The fragment that supplied each value is its owner (see Owner).  When the document is saved,
+// changed values are saved to the fragments that own them, new keys are saved to the default
+// fragment (see DefaultFragment) and deleted keys are deleted from all the fragments.  Only the
+// fragments that changed are saved.
+//
+// If the pattern is empty, then DefaultDirPattern is used.  The options are used for loading
+// and saving the fragments, so for example the limits of WithLoadOptions apply to each of the
+// fragments read, while the other load options (e.g. yamldoc.KeepNodes) also apply to the
+// merged document.  If a fragment cannot be read or parsed, then doc=nil and "err"
+// will contain the error information.
+func LoadDir(dir, pattern string, opts ...Option) (doc DirDoc, err error) {
+	var (
+		settings = &yamlFile{}
+		names    []string
+	)
+	for _, opt := range opts {
+		opt(settings)
+	}
+	if pattern == "" {
+		pattern = DefaultDirPattern
+	}
+
+	result := &dirDoc{
+		dir:    dir,
+		opts:   opts,
+		fsys:   settings.fsys,
+		backup: settings.backup,
+		owners: map[string]string{},
+	}
+	result.YamlDoc, _ = yamldoc.New(nil, settings.loadOpts...)
+
+	if names, err = result.glob(pattern); err != nil {
+		return nil, errors.Wrapf(err, "Directory '%s'", dir)
+	}
+	for _, name := range names {
+		var fragment YamlFile
+
+		if info, err := statFile(result.fsys, name); err != nil || info.IsDir() {
+			continue
+		}
+		if _, fragment, err = Load(name, opts...); err != nil {
+			return nil, errors.Wrapf(err, "File '%s'", name)
+		}
+
+		result.fragments = append(result.fragments, fragment)
+		recordOrigins(result.owners, "", fragment.Data(), name)
+		result.Merge(fragment.Data())
+	}
+
+	switch {
+	case settings.defaultFragment != "":
+		result.defaultFragment = result.join(settings.defaultFragment)
+	case len(result.fragments) > 0:
+		result.defaultFragment = result.fragments[len(result.fragments)-1].Filename()
+	default:
+		result.defaultFragment = result.join(DefaultDirFragment)
+	}
+	result.MarkClean()
+
+	return result, nil
+}
+
+// Dir - get the directory of the fragments
+func (d *dirDoc) Dir() string {
+	return d.dir
+}
+
+// Fragments - get the fragment files, in the order they were merged
+func (d *dirDoc) Fragments() []string {
+	names := make([]string, 0, len(d.fragments))
+	for _, fragment := range d.fragments {
+		names = append(names, fragment.Filename())
+	}
+	return names
+}
+
+// Owner - get the fragment file that owns the value at key.  For a map, this is the last
+// fragment that supplied any of its values.  Values that were added after loading are owned
+// by the default fragment.  If the key is not found, then "" is returned.
+func (d *dirDoc) Owner(key string) string {
+	if contains, _ := d.Contains(key); !contains {
+		return ""
+	}
+	if owner, found := d.owners[key]; found {
+		return owner
+	}
+	return d.defaultFragment
+}
+
+// Save - saves the changed values to the fragments that own them.  If saving a fragment fails,
+// then the fragments saved before it keep their changes.
+//
+// If the WithBackup option was specified, then each fragment is backed up before it is saved.
+func (d *dirDoc) Save() (err error) {
+	return d.SaveWithBackup(d.backup)
+}
+
+// SaveWithBackup - saves the changed values (see Save), after backing up the current contents
+// of each fragment that is saved, e.g. with BackupSuffix or BackupHistory
+func (d *dirDoc) SaveWithBackup(backup Backup) (err error) {
+	deleted := map[string]bool{}
+
+	for _, key := range d.ChangedPaths() {
+		if contains, _ := d.Contains(key); contains {
+			if err = d.saveValue(key); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Delete the top-most map (or value) that no longer exists from all the fragments,
+		// so that it is not left behind as an empty map or supplied again by another fragment
+		removed := d.removedAncestor(key)
+		if deleted[removed] {
+			continue
+		}
+		deleted[removed] = true
+		for _, fragment := range d.fragments {
+			if _, err = fragment.Delete(removed); err != nil {
+				return errors.Wrapf(err, "File '%s'", fragment.Filename())
+			}
+		}
+		for path := range d.owners {
+			if path == removed || strings.HasPrefix(path, removed+".") {
+				delete(d.owners, path)
+			}
+		}
+	}
+
+	for _, fragment := range d.fragments {
+		if !fragment.IsDirty() {
+			continue
+		}
+		if err = fragment.SaveWithBackup(backup); err != nil {
+			return errors.Wrapf(err, "File '%s'", fragment.Filename())
+		}
+	}
+	d.MarkClean()
+
+	return nil
+}
+
+// saveValue - set the (changed) value at key in the fragment that owns it
+func (d *dirDoc) saveValue(key string) (err error) {
+	var value interface{}
+
+	if value, err = d.Get(key); err != nil {
+		return err
+	}
+	owner := d.Owner(key)
+	fragment := d.fragment(owner)
+
+	// Do not share any maps or arrays between the document and the fragment
+	copied, _ := yamldoc.New(nil)
+	copied.SetData(map[string]interface{}{"value": value})
+	if value, err = copied.Clone().Get("value"); err != nil {
+		return err
+	}
+	if _, err = fragment.Set(key, value); err != nil {
+		return errors.Wrapf(err, "File '%s'", owner)
+	}
+
+	d.owners[key] = owner
+	if mapValue, ok := value.(map[string]interface{}); ok {
+		recordOrigins(d.owners, key, mapValue, owner)
+	}
+	return nil
+}
+
+// removedAncestor - get the top-most key in the path of a deleted key that no longer exists
+func (d *dirDoc) removedAncestor(key string) string {
+	keys := strings.Split(key, ".")
+	for index := 1; index < len(keys); index++ {
+		parent := strings.Join(keys[:index], ".")
+		if contains, _ := d.Contains(parent); !contains {
+			return parent
+		}
+	}
+	return key
+}
+
+// fragment - get the fragment with the filename, creating it (in lexical order) if needed
+func (d *dirDoc) fragment(filename string) YamlFile {
+	for _, fragment := range d.fragments {
+		if fragment.Filename() == filename {
+			return fragment
+		}
+	}
+
+	fragment := New(filename, d.opts...)
+	d.fragments = append(d.fragments, fragment)
+	sort.SliceStable(d.fragments, func(i, j int) bool {
+		return d.fragments[i].Filename() < d.fragments[j].Filename()
+	})
+	return fragment
+}
+
+func (d *dirDoc) glob(pattern string) ([]string, error) {
+	if d.fsys == nil {
+		return filepath.Glob(filepath.Join(d.dir, pattern))
+	}
+	return fs.Glob(d.fsys, path.Join(d.dir, pattern))
+}
+
+func (d *dirDoc) join(name string) string {
+	return joinFilePath(d.fsys, d.dir, name)
+}
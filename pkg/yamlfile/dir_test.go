@@ -0,0 +1,114 @@
+package yamlfile
+
+import (
+	"io/fs"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/theochva/goyaml/pkg/yamldoc"
+)
+
+var _ = Describe("Directory of YamlFiles", func() {
+	var memFS *MemFS
+
+	BeforeEach(func() {
+		memFS = NewMemFS(map[string]string{
+			"conf.d/10-base.yaml":     "a:\n  b: value-b\n  c: value-c\nd: 1",
+			"conf.d/20-override.yaml": "a:\n  c: override-c",
+			"conf.d/notes.txt":        "not: yaml",
+		})
+	})
+
+	checkFile := func(name, expectedText string) {
+		contents, err := fs.ReadFile(memFS, name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.TrimSpace(string(contents))).To(Equal(expectedText))
+	}
+
+	It("merges the fragments in lexical order and tracks which fragment owns each value", func() {
+		doc, err := LoadDir("conf.d", "", FS(memFS))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(doc.Dir()).To(Equal("conf.d"))
+		Expect(doc.Fragments()).To(Equal([]string{"conf.d/10-base.yaml", "conf.d/20-override.yaml"}))
+		Expect(doc.IsDirty()).To(BeFalse())
+		checkText(doc, "a:\n  b: value-b\n  c: override-c\nd: 1")
+
+		Expect(doc.Owner("a.b")).To(Equal("conf.d/10-base.yaml"))
+		Expect(doc.Owner("a.c")).To(Equal("conf.d/20-override.yaml"))
+		Expect(doc.Owner("x")).To(BeEmpty())
+	})
+	It("saves changed values to the fragments that own them", func() {
+		doc, err := LoadDir("conf.d", "*.yaml", FS(memFS))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = doc.Set("a.b", "changed-b")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = doc.Set("a.c", "changed-c")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = doc.Delete("d")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(doc.Save()).To(Succeed())
+		Expect(doc.IsDirty()).To(BeFalse())
+
+		checkFile("conf.d/10-base.yaml", "a:\n  b: changed-b\n  c: value-c")
+		checkFile("conf.d/20-override.yaml", "a:\n  c: changed-c")
+	})
+	It("saves new values to the default fragment", func() {
+		doc, err := LoadDir("conf.d", "", FS(memFS))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = doc.Set("e", "value-e")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(doc.Owner("e")).To(Equal("conf.d/20-override.yaml"))
+		Expect(doc.Save()).To(Succeed())
+		checkFile("conf.d/20-override.yaml", "a:\n  c: override-c\ne: value-e")
+
+		doc, err = LoadDir("conf.d", "", FS(memFS), DefaultFragment("99-local.yaml"))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = doc.Set("f", "value-f")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(doc.Save()).To(Succeed())
+		Expect(doc.Fragments()).To(ContainElement("conf.d/99-local.yaml"))
+		checkFile("conf.d/99-local.yaml", "f: value-f")
+		Expect(doc.Owner("f")).To(Equal("conf.d/99-local.yaml"))
+	})
+	It("backs up each fragment that is saved", func() {
+		doc, err := LoadDir("conf.d", "", FS(memFS), WithBackup(BackupSuffix("")))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = doc.Set("a.c", "changed-c")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(doc.Save()).To(Succeed())
+		checkFile("conf.d/20-override.yaml"+DefaultBackupSuffix, "a:\n  c: override-c")
+		_, err = fs.Stat(memFS, "conf.d/10-base.yaml"+DefaultBackupSuffix)
+		Expect(err).To(HaveOccurred())
+
+		_, err = doc.Set("a.b", "changed-b")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(doc.SaveWithBackup(BackupSuffix("~"))).To(Succeed())
+		checkFile("conf.d/10-base.yaml~", "a:\n  b: value-b\n  c: value-c\nd: 1")
+		checkFile("conf.d/10-base.yaml", "a:\n  b: changed-b\n  c: value-c\nd: 1")
+		_, err = fs.Stat(memFS, "conf.d/20-override.yaml~")
+		Expect(err).To(HaveOccurred())
+	})
+	It("loads the merged document with the load options", func() {
+		doc, err := LoadDir("conf.d", "", FS(memFS), WithLoadOptions(yamldoc.KeepNodes()))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = doc.Set("b", "value-b")
+		Expect(err).ToNot(HaveOccurred())
+		// The order of the keys is kept, so the new key is added last
+		checkText(doc, "a:\n  b: value-b\n  c: override-c\nd: 1\nb: value-b")
+	})
+	It("loads an empty document when no fragments match", func() {
+		doc, err := LoadDir("conf.d", "*.json", FS(memFS))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(doc.Fragments()).To(BeEmpty())
+		checkText(doc, "{}")
+	})
+	It("fails when a fragment is not valid", func() {
+		Expect(memFS.WriteFile("conf.d/30-invalid.yaml", []byte("a: b: c"), DefaultFileMode)).To(Succeed())
+
+		_, err := LoadDir("conf.d", "", FS(memFS))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("conf.d/30-invalid.yaml"))
+	})
+})
@@ -0,0 +1,58 @@
+package yamlfile
+
+import (
+	"io/fs"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/theochva/goyaml/pkg/yamldoc"
+)
+
+var _ = Describe("YamlFile character encodings", func() {
+	utf16 := func(text string) string {
+		contents, err := yamldoc.FromUTF8([]byte(text), yamldoc.Encoding{Charset: yamldoc.UTF16LE, BOM: true})
+		Expect(err).ToNot(HaveOccurred())
+		return string(contents)
+	}
+
+	It("loads UTF-16 files and saves them as UTF-8", func() {
+		memFS := NewMemFS(map[string]string{
+			"test.yaml": utf16("a: value-a\n"),
+		})
+		_, yamlFile, err := Load("test.yaml", FS(memFS))
+		Expect(err).ToNot(HaveOccurred())
+		checkText(yamlFile, "a: value-a")
+		Expect(yamlFile.Encoding()).To(Equal(yamldoc.Encoding{Charset: yamldoc.UTF16LE, BOM: true}))
+
+		Expect(yamlFile.Save()).To(Succeed())
+		contents, err := fs.ReadFile(memFS, "test.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("a: value-a\n"))
+	})
+	It("keeps the encoding of the file when saving", func() {
+		memFS := NewMemFS(map[string]string{
+			"test.json": "\xef\xbb\xbf{\"a\": \"value-a\"}",
+			"test.yaml": utf16("a: value-a\n"),
+		})
+		_, yamlFile, err := Load("test.yaml", FS(memFS), KeepEncoding())
+		Expect(err).ToNot(HaveOccurred())
+		_, err = yamlFile.Set("a", "changed")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(yamlFile.Save()).To(Succeed())
+
+		contents, err := fs.ReadFile(memFS, "test.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal(utf16("a: changed\n")))
+
+		// JSON files with a BOM
+		_, yamlFile, err = Load("test.json", FS(memFS), KeepEncoding())
+		Expect(err).ToNot(HaveOccurred())
+		checkText(yamlFile, "a: value-a")
+		Expect(yamlFile.Save()).To(Succeed())
+
+		contents, err = fs.ReadFile(memFS, "test.json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contents).To(HavePrefix("\xef\xbb\xbf{"))
+	})
+})
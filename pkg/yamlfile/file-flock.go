@@ -0,0 +1,32 @@
+//go:build !windows
+// +build !windows
+
+package yamlfile
+
+import (
+	"os"
+	"syscall"
+)
+
+// tryLock - try to take an exclusive flock on the lock file without waiting.  The lock file
+// is kept after unlocking, since removing it would race with others waiting to lock it.
+func tryLock(lockName string) (locked bool, unlock func() error, err error) {
+	var file *os.File
+
+	if file, err = os.OpenFile(lockName, os.O_RDONLY|os.O_CREATE, DefaultFileMode); err != nil {
+		return
+	}
+
+	if err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if err == syscall.EWOULDBLOCK {
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+
+	return true, func() error {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		return file.Close()
+	}, nil
+}
@@ -0,0 +1,35 @@
+package yamlfile
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// tryLock - try to take an exclusive LockFileEx lock on the lock file without waiting.  Like
+// flock, the lock is released by the system when the process exits, so a crashed process
+// does not leave a stale lock behind.  The lock file is kept after unlocking, since removing
+// it would race with others waiting to lock it.
+func tryLock(lockName string) (locked bool, unlock func() error, err error) {
+	var file *os.File
+
+	if file, err = os.OpenFile(lockName, os.O_RDWR|os.O_CREATE, DefaultFileMode); err != nil {
+		return
+	}
+
+	handle := windows.Handle(file.Fd())
+	overlapped := &windows.Overlapped{}
+	if err = windows.LockFileEx(handle, windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY,
+		0, 1, 0, overlapped); err != nil {
+		file.Close()
+		if err == windows.ERROR_LOCK_VIOLATION {
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+
+	return true, func() error {
+		windows.UnlockFileEx(handle, 0, 1, 0, overlapped)
+		return file.Close()
+	}, nil
+}
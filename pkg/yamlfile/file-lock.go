@@ -0,0 +1,103 @@
+package yamlfile
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrLockTimeout - error generated when the lock of a file could not be acquired in time
+var ErrLockTimeout = errors.New("Timed out waiting for the file lock")
+
+// lockRetryInterval - how often to retry acquiring a lock held by someone else
+const lockRetryInterval = 50 * time.Millisecond
+
+// LockedYamlFile - a YamlFile that holds an exclusive lock on the file until it is unlocked
+type LockedYamlFile interface {
+	YamlFile
+
+	// Unlock - release the lock on the file
+	Unlock() error
+}
+
+type lockedYamlFile struct {
+	YamlFile
+	unlock func() error
+}
+
+// Lock - acquire an advisory exclusive lock for the file, waiting up to "timeout" for anyone
+// else holding it.  A zero timeout fails right away if the lock is held and a negative timeout
+// waits forever.  If the lock is not acquired in time, then ErrLockTimeout is returned.
+//
+// The lock is held on a separate ".<filename>.lock" file in the same directory, since saving
+// replaces the file itself.  The lock is advisory, so it only protects against others that
+// also lock the file.  Call the returned function to release the lock.
+//
+// The lock file is named after the absolute, cleaned path of the file, so that the different
+// ways of naming the same file (e.g. "conf.d" and "conf.d/") get the same lock.
+func Lock(filename string, timeout time.Duration) (unlock func() error, err error) {
+	var (
+		dir, base = filepath.Split(lockTarget(filename))
+		lockName  = filepath.Join(dir, "."+base+".lock")
+		deadline  = time.Now().Add(timeout)
+		locked    bool
+	)
+
+	for {
+		if locked, unlock, err = tryLock(lockName); err != nil {
+			return nil, errors.Wrapf(err, "Failed to lock '%s'", filename)
+		} else if locked {
+			return unlock, nil
+		}
+
+		if timeout >= 0 && !time.Now().Before(deadline) {
+			return nil, ErrLockTimeout
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// LoadLocked - lock the file (see Lock) and then create a new YamlFile and load YAML contents
+// (see Load).  The lock is held until Unlock is called, so that the file can be loaded,
+// modified and saved without others changing it in the meantime.
+//
+// If an error occurs, then the lock is released and "err" will contain the error information.
+func LoadLocked(filename string, timeout time.Duration, opts ...Option) (loaded bool, yamlFile LockedYamlFile, err error) {
+	var unlock func() error
+
+	if filename == "" {
+		return false, nil, os.ErrInvalid
+	}
+	if unlock, err = Lock(filename, timeout); err != nil {
+		return
+	}
+
+	result := &lockedYamlFile{
+		YamlFile: New(filename, opts...),
+		unlock:   unlock,
+	}
+	if loaded, err = result.Load(); err != nil {
+		unlock()
+		return false, nil, err
+	}
+	return loaded, result, nil
+}
+
+// Unlock - release the lock on the file.  Calling it more than once has no effect.
+func (y *lockedYamlFile) Unlock() (err error) {
+	if y.unlock != nil {
+		err = y.unlock()
+		y.unlock = nil
+	}
+	return
+}
+
+// lockTarget - the absolute, cleaned path of the file being locked
+func lockTarget(filename string) string {
+	if absFilename, err := filepath.Abs(filename); err == nil {
+		return absFilename
+	}
+	return filepath.Clean(filename)
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+// +build !windows
+
+package yamlfile
+
+import (
+	"os"
+	"syscall"
+)
+
+// copyOwner - give the file the same owner and group as the original file.  Only privileged
+// users can change the owner, so permission errors are ignored.
+func copyOwner(file *os.File, original os.FileInfo) error {
+	stat, ok := original.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if int(stat.Uid) == os.Getuid() && int(stat.Gid) == os.Getgid() {
+		return nil
+	}
+	if err := file.Chown(int(stat.Uid), int(stat.Gid)); err != nil && !os.IsPermission(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+package yamlfile
+
+import (
+	"os"
+)
+
+// copyOwner - file ownership is not copied on windows
+func copyOwner(file *os.File, original os.FileInfo) error {
+	return nil
+}
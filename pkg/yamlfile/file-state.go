@@ -0,0 +1,54 @@
+package yamlfile
+
+import (
+	"crypto/sha256"
+	"io/fs"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrFileModified - error generated when saving a file that was modified (or created or
+// deleted) by someone else since it was loaded
+var ErrFileModified = errors.New("File was modified since it was loaded")
+
+// fileState - the state of a file on disk when it was loaded or saved
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+	hash    [sha256.Size]byte
+}
+
+func newFileState(info fs.FileInfo, contents []byte) *fileState {
+	return &fileState{
+		exists:  true,
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		hash:    sha256.Sum256(contents),
+	}
+}
+
+// modified - check if the file on disk is different to the recorded state.  The contents
+// are only compared when the size is the same but the modification time is different.
+func (s *fileState) modified(fsys fs.FS, filename string) (bool, error) {
+	info, err := statFile(fsys, filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s.exists, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if !s.exists || info.Size() != s.size {
+		return true, nil
+	}
+	if info.ModTime().Equal(s.modTime) {
+		return false, nil
+	}
+
+	contents, err := readFile(fsys, filename)
+	if err != nil {
+		return false, err
+	}
+	return sha256.Sum256(contents) != s.hash, nil
+}
@@ -0,0 +1,95 @@
+package yamlfile
+
+import (
+	"io/fs"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// ErrReadOnlyFS - error generated when saving a file to a file system that cannot be written to
+var ErrReadOnlyFS = errors.New("File system is read-only")
+
+// WritableFS - a file system that yaml files can be saved to, besides being loaded from
+type WritableFS interface {
+	fs.FS
+
+	// WriteFile - write the data to the named file, creating it with the permissions if needed
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+}
+
+// FS - load the file from a file system (e.g. an embed.FS or a zip.Reader) instead of the
+// OS file system.  The filename must then be a valid fs.FS path, i.e. slash separated and
+// not rooted.
+//
+// The file can only be saved if the file system implements WritableFS, otherwise Save returns
+// ErrReadOnlyFS.  Saving to a WritableFS is up to the file system, i.e. the ReplaceSymlinks
+// option and the atomic writes of the OS file system do not apply.
+func FS(fsys fs.FS) Option {
+	return func(y *yamlFile) {
+		y.fsys = fsys
+	}
+}
+
+// statFile - get the info of a file, in the file system or in the OS file system if nil
+func statFile(fsys fs.FS, name string) (fs.FileInfo, error) {
+	if fsys == nil {
+		return os.Stat(name)
+	}
+	return fs.Stat(fsys, name)
+}
+
+// openFile - open a file for reading, in the file system or in the OS file system if nil
+func openFile(fsys fs.FS, name string) (fs.File, error) {
+	if fsys == nil {
+		return os.Open(name)
+	}
+	return fsys.Open(name)
+}
+
+// readFile - read the contents of a file, in the file system or in the OS file system if nil
+func readFile(fsys fs.FS, name string) ([]byte, error) {
+	if fsys == nil {
+		return os.ReadFile(name)
+	}
+	return fs.ReadFile(fsys, name)
+}
+
+// removeFile - remove a file, in the file system (if it supports removing files) or in the
+// OS file system if nil
+func removeFile(fsys fs.FS, name string) error {
+	if fsys == nil {
+		return os.Remove(name)
+	}
+
+	removable, ok := fsys.(interface{ Remove(name string) error })
+	if !ok {
+		return ErrReadOnlyFS
+	}
+	return removable.Remove(name)
+}
+
+// writeFile - write the contents of a file, in the file system or (atomically) in the OS
+// file system if nil.  The file keeps its mode, unless the "original" file is specified, in
+// which case the file gets the mode (and owner) of the original.
+func writeFile(fsys fs.FS, name string, data []byte, replaceSymlinks bool, original fs.FileInfo) error {
+	if fsys == nil {
+		return writeFileAtomic(name, data, replaceSymlinks, original)
+	}
+
+	writable, ok := fsys.(WritableFS)
+	if !ok {
+		return ErrReadOnlyFS
+	}
+
+	// Keep the mode of the existing file
+	mode := DefaultFileMode
+	if original != nil {
+		mode = original.Mode().Perm()
+	} else if info, err := fs.Stat(fsys, name); err == nil {
+		mode = info.Mode().Perm()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return writable.WriteFile(name, data, mode)
+}
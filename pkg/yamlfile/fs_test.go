@@ -0,0 +1,104 @@
+package yamlfile
+
+import (
+	"io/fs"
+	"strings"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/theochva/goyaml/pkg/yamldoc"
+)
+
+var _ = Describe("YamlFile file systems", func() {
+	Context("An in-memory file system", func() {
+		var memFS *MemFS
+
+		BeforeEach(func() {
+			memFS = NewMemFS(map[string]string{
+				"conf/test.yaml": yamlText,
+			})
+		})
+		It("can load a file", func() {
+			loaded, yamlFile, err := Load("conf/test.yaml", FS(memFS))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded).To(BeTrue())
+			Expect(yamlFile.Exists()).To(BeTrue())
+			checkText(yamlFile, yamlText)
+		})
+		It("does not load a missing file or a directory", func() {
+			loaded, yamlFile, err := Load("conf/other.yaml", FS(memFS))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded).To(BeFalse())
+			Expect(yamlFile.Exists()).To(BeFalse())
+
+			Expect(New("conf", FS(memFS)).Exists()).To(BeFalse())
+		})
+		It("can save a file", func() {
+			_, yamlFile, err := Load("conf/test.yaml", FS(memFS))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = yamlFile.Set("a.b.c", "changed")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(yamlFile.Save()).To(Succeed())
+
+			contents, err := fs.ReadFile(memFS, "conf/test.yaml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("c: changed"))
+
+			newFile := New("conf/new.yaml", FS(memFS))
+			newFile.SetData(yamlStringMap)
+			Expect(newFile.Save()).To(Succeed())
+
+			info, err := fs.Stat(memFS, "conf/new.yaml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(DefaultFileMode))
+		})
+		It("keeps the anchors of a file when requested", func() {
+			anchoredText := "defaults: &defaults\n  retries: 3\nservice:\n  settings: *defaults\n"
+			Expect(memFS.WriteFile("conf/anchors.yaml", []byte(anchoredText), DefaultFileMode)).To(Succeed())
+
+			_, yamlFile, err := Load("conf/anchors.yaml", FS(memFS), WithLoadOptions(yamldoc.KeepNodes()))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = yamlFile.Set("service.settings.retries", 5)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(yamlFile.Save()).To(Succeed())
+
+			contents, err := fs.ReadFile(memFS, "conf/anchors.yaml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal(strings.Replace(anchoredText, "3", "5", 1)))
+		})
+		It("detects a file modified by someone else", func() {
+			_, yamlFile, err := Load("conf/test.yaml", FS(memFS))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(memFS.WriteFile("conf/test.yaml", []byte("x: 1"), DefaultFileMode)).To(Succeed())
+			Expect(yamlFile.Save()).To(Equal(ErrFileModified))
+
+			Expect(memFS.Remove("conf/test.yaml")).To(Succeed())
+			loaded, err := yamlFile.Reload()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded).To(BeFalse())
+			Expect(yamlFile.Data()).To(BeEmpty())
+			Expect(yamlFile.IsDirty()).To(BeFalse())
+		})
+		It("rejects invalid paths", func() {
+			Expect(memFS.WriteFile("/abs.yaml", []byte("x: 1"), DefaultFileMode)).ToNot(Succeed())
+			Expect(memFS.WriteFile("../up.yaml", []byte("x: 1"), DefaultFileMode)).ToNot(Succeed())
+		})
+	})
+	Context("A read-only file system", func() {
+		It("can load but not save a file", func() {
+			readOnly := fstest.MapFS{
+				"test.yaml": &fstest.MapFile{Data: []byte(yamlText)},
+			}
+
+			loaded, yamlFile, err := Load("test.yaml", FS(readOnly))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded).To(BeTrue())
+			checkText(yamlFile, yamlText)
+
+			Expect(yamlFile.Save()).To(Equal(ErrReadOnlyFS))
+		})
+	})
+})
@@ -0,0 +1,111 @@
+package yamlfile
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/theochva/goyaml/pkg/yamldoc"
+)
+
+// LayeredDoc - a yaml document merged from several layered yaml files
+type LayeredDoc interface {
+	yamldoc.YamlDoc
+
+	// Origin - get the file that supplied the value at key
+	Origin(key string) string
+	// Layers - get the files that were loaded, in the order they were merged
+	Layers() []string
+}
+
+type layeredDoc struct {
+	yamldoc.YamlDoc
+	layers  []string
+	origins map[string]string
+}
+
+// LoadLayers - load several yaml files and merge them into one document, in the order they
+// are specified, so that values in later files override values in earlier files (see
+// yamldoc.YamlDoc.Merge).  For example:
+//
+//	doc, err := LoadLayers([]string{"defaults.yaml", "env/prod.yaml", "local.yaml"})
+//
+// Files that do not exist are optional layers and are skipped.  The options are used for
+// loading each of the files, e.g. WithLoadOptions(yamldoc.WithLimits(...)) applies the limits
+// to every layer.  If a file cannot be read or parsed, then doc=nil and "err" will contain the
+// error information.
+func LoadLayers(filenames []string, opts ...Option) (doc LayeredDoc, err error) {
+	result := &layeredDoc{
+		layers:  []string{},
+		origins: map[string]string{},
+	}
+	result.YamlDoc, _ = yamldoc.New(nil)
+
+	for _, filename := range filenames {
+		var (
+			loaded bool
+			layer  YamlFile
+		)
+		if loaded, layer, err = Load(filename, opts...); err != nil {
+			return nil, errors.Wrapf(err, "File '%s'", filename)
+		} else if !loaded {
+			continue
+		}
+
+		result.layers = append(result.layers, filename)
+		recordOrigins(result.origins, "", layer.Data(), filename)
+		result.Merge(layer.Data())
+	}
+
+	// Values changed after loading no longer come from any of the files
+	result.OnChange("", result.forgetOrigin)
+	result.MarkClean()
+
+	return result, nil
+}
+
+// Origin - get the file that supplied the value at key.  For a map, this is the last file
+// that supplied any of its values.  If the key is not found or its value was set after
+// loading the files, then "" is returned.
+func (d *layeredDoc) Origin(key string) string {
+	if contains, _ := d.Contains(key); !contains {
+		return ""
+	}
+	return d.origins[key]
+}
+
+// Layers - get the files that were loaded, in the order they were merged
+func (d *layeredDoc) Layers() []string {
+	return d.layers
+}
+
+// recordOrigins - record the file as the origin of all the values (nested or not) in the data.
+// The values that are not maps replace any map merged before them, so the origins of the
+// values nested in it are deleted.
+func recordOrigins(origins map[string]string, parent string, data map[string]interface{}, filename string) {
+	for key, value := range data {
+		path := key
+		if parent != "" {
+			path = parent + "." + key
+		}
+		origins[path] = filename
+
+		if mapValue, ok := value.(map[string]interface{}); ok {
+			recordOrigins(origins, path, mapValue, filename)
+			continue
+		}
+		for nested := range origins {
+			if strings.HasPrefix(nested, path+".") {
+				delete(origins, nested)
+			}
+		}
+	}
+}
+
+func (d *layeredDoc) forgetOrigin(ev yamldoc.ChangeEvent) {
+	for path := range d.origins {
+		if path == ev.Path || strings.HasPrefix(path, ev.Path+".") {
+			delete(d.origins, path)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package yamlfile
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/theochva/goyaml/pkg/yamldoc"
+)
+
+var _ = Describe("Layered YamlFiles", func() {
+	var (
+		dir                    string
+		defaults, env, missing string
+	)
+	BeforeEach(func() {
+		var err error
+
+		dir, err = os.MkdirTemp("", "layers")
+		Expect(err).ToNot(HaveOccurred())
+
+		defaults = filepath.Join(dir, "defaults.yaml")
+		env = filepath.Join(dir, "env.yaml")
+		missing = filepath.Join(dir, "local.yaml")
+		Expect(os.WriteFile(defaults, []byte(yamlText), 0644)).To(Succeed())
+		Expect(os.WriteFile(env, []byte("a:\n  d:\n    f: 20\n  g: value-g"), 0644)).To(Succeed())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+	It("merges the layers and tracks where each value came from", func() {
+		doc, err := LoadLayers([]string{defaults, env, missing})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(doc.Layers()).To(Equal([]string{defaults, env}))
+
+		checkText(doc, "a:\n  b:\n    c: value-c\n  d:\n    e: false\n    f: 20\n  g: value-g")
+
+		Expect(doc.Origin("a.b.c")).To(Equal(defaults))
+		Expect(doc.Origin("a.d.e")).To(Equal(defaults))
+		Expect(doc.Origin("a.d.f")).To(Equal(env))
+		Expect(doc.Origin("a.g")).To(Equal(env))
+		Expect(doc.Origin("a.d")).To(Equal(env))
+		Expect(doc.Origin("a.x")).To(BeEmpty())
+	})
+	It("forgets the origin of values changed after loading", func() {
+		doc, err := LoadLayers([]string{defaults, env})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = doc.Set("a.d.f", 30)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(doc.Origin("a.d.f")).To(BeEmpty())
+		Expect(doc.Origin("a.d.e")).To(Equal(defaults))
+	})
+	It("fails when a layer is not valid", func() {
+		Expect(os.WriteFile(missing, []byte("x: [1"), 0644)).To(Succeed())
+
+		doc, err := LoadLayers([]string{defaults, env, missing})
+		Expect(err).To(HaveOccurred())
+		Expect(doc).To(BeNil())
+	})
+	It("forgets the origins of the values nested in maps replaced by later layers", func() {
+		origins := map[string]string{}
+		recordOrigins(origins, "", map[string]interface{}{
+			"db": map[string]interface{}{"host": "h", "pool": map[string]interface{}{"size": 1}},
+		}, defaults)
+		recordOrigins(origins, "", map[string]interface{}{"db": "sqlite"}, env)
+		Expect(origins).To(Equal(map[string]string{"db": env}))
+	})
+	It("loads each layer with the options", func() {
+		doc, err := LoadLayers([]string{defaults, env}, WithLoadOptions(yamldoc.WithLimits(yamldoc.Limits{MaxBytes: 30})))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix("File '" + defaults + "'"))
+		Expect(yamldoc.IsLimitError(err)).To(BeTrue())
+		Expect(doc).To(BeNil())
+	})
+})
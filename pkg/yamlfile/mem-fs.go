@@ -0,0 +1,67 @@
+package yamlfile
+
+import (
+	"io/fs"
+	"sync"
+	"testing/fstest"
+	"time"
+)
+
+// MemFS - an in-memory WritableFS, meant for tests.  It is safe for concurrent use.
+type MemFS struct {
+	mutex sync.RWMutex
+	files fstest.MapFS
+}
+
+// NewMemFS - create an in-memory file system with the specified files (name -> contents)
+func NewMemFS(files map[string]string) *MemFS {
+	result := &MemFS{
+		files: fstest.MapFS{},
+	}
+	for name, contents := range files {
+		result.files[name] = &fstest.MapFile{
+			Data:    []byte(contents),
+			Mode:    DefaultFileMode,
+			ModTime: time.Now(),
+		}
+	}
+	return result
+}
+
+// Open - open the named file (see fs.FS)
+func (m *MemFS) Open(name string) (fs.File, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.files.Open(name)
+}
+
+// WriteFile - write the data to the named file, creating it with the permissions if needed
+func (m *MemFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Files are replaced rather than modified, so that files already open keep their contents
+	m.files[name] = &fstest.MapFile{
+		Data:    append([]byte{}, data...),
+		Mode:    perm.Perm(),
+		ModTime: time.Now(),
+	}
+	return nil
+}
+
+// Remove - remove the named file
+func (m *MemFS) Remove(name string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, found := m.files[name]; !found {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, name)
+	return nil
+}
@@ -0,0 +1,237 @@
+package yamlfile
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultWatchInterval - how often Watch checks the file for changes
+	DefaultWatchInterval = time.Second
+	// DefaultWatchDebounce - how long the file must be left unchanged before Watch reloads it
+	DefaultWatchDebounce = 100 * time.Millisecond
+)
+
+// WatchInterval - how often Watch checks the file for changes (default is DefaultWatchInterval)
+func WatchInterval(interval time.Duration) Option {
+	return func(y *yamlFile) {
+		y.watchInterval = interval
+	}
+}
+
+// WatchDebounce - how long the file must be left unchanged before Watch reloads it, so that
+// a series of rapid writes results in a single reload (default is DefaultWatchDebounce)
+func WatchDebounce(debounce time.Duration) Option {
+	return func(y *yamlFile) {
+		y.watchDebounce = debounce
+	}
+}
+
+// Watch - load the file and then reload it whenever it changes, until the context is done.
+//
+// The "onChange" function is called with the loaded YamlFile and then with a new YamlFile
+// every time the file is reloaded.  Since every reload creates a new YamlFile, the one
+// passed to "onChange" can be handed over to other goroutines, which keep using it
+// unchanged, e.g. with a WatchedFile:
+//
+//	config := &WatchedFile{}
+//	go Watch(ctx, "config.yaml", config.OnChange)
+//	...
+//	yamlFile, err := config.Current()
+//
+// If a reload fails (e.g. the YAML is not valid or the file was deleted), then "onChange" is
+// called with the previous YamlFile and the error.  If the file cannot be checked for changes
+// (e.g. its directory is no longer accessible), then the error is reported once, until a
+// different error occurs or it is resolved, when "onChange" is called with the current
+// YamlFile and no error.  The file is checked for changes by polling, so it works on all
+// platforms and file systems.  The options are used when loading the file, along with
+// WatchInterval and WatchDebounce.
+//
+// Watch blocks until the context is done and then returns the context's error.
+func Watch(ctx context.Context, filename string, onChange func(yamlFile YamlFile, err error), opts ...Option) error {
+	var (
+		current  YamlFile
+		seen     *fileState
+		err      error
+		reported error
+		settings = &yamlFile{
+			watchInterval: DefaultWatchInterval,
+			watchDebounce: DefaultWatchDebounce,
+		}
+	)
+
+	if filename == "" {
+		return os.ErrInvalid
+	}
+	for _, opt := range opts {
+		opt(settings)
+	}
+
+	current, seen, err = loadWatched(settings.fsys, filename, opts)
+	onChange(current, err)
+
+	ticker := time.NewTicker(settings.watchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		var (
+			modified bool
+			next     YamlFile
+		)
+		if modified, err = seen.modified(settings.fsys, filename); err != nil {
+			if reported == nil || reported.Error() != err.Error() {
+				onChange(current, err)
+				reported = err
+			}
+			continue
+		}
+		if !modified {
+			if reported != nil {
+				// The error was resolved
+				onChange(current, nil)
+			}
+			reported = nil
+			seen = refreshedState(settings.fsys, filename, seen)
+			continue
+		}
+		reported = nil
+
+		// Wait for the writes to the file to settle down before reloading it
+		if err = waitUntilUnchanged(ctx, settings.fsys, filename, settings.watchDebounce); err != nil {
+			return err
+		}
+
+		if next, seen, err = loadWatched(settings.fsys, filename, opts); err != nil {
+			onChange(current, err)
+			continue
+		}
+		current = next
+		onChange(current, nil)
+	}
+}
+
+// loadWatched - load the watched file and get the state of the file that was loaded
+func loadWatched(fsys fs.FS, filename string, opts []Option) (watched YamlFile, state *fileState, err error) {
+	var loaded bool
+
+	watched = New(filename, opts...)
+	if loaded, err = watched.Load(); err == nil && !loaded {
+		err = errors.Wrapf(os.ErrNotExist, "File '%s'", filename)
+	}
+	if err == nil {
+		return watched, watched.(*yamlFile).state, nil
+	}
+
+	// The state is still needed, so that the failed contents are not reloaded until changed
+	if state, _ = readFileState(fsys, filename); state == nil {
+		state = &fileState{}
+	}
+	return watched, state, err
+}
+
+// refreshedState - get the state of an unmodified file whose modification time changed (e.g.
+// it was touched or rewritten with the same contents), so that its contents are not compared
+// again every time it is checked.  The state is replaced rather than updated, since it may be
+// the state of the YamlFile handed over to "onChange".
+func refreshedState(fsys fs.FS, filename string, state *fileState) *fileState {
+	info, err := statFile(fsys, filename)
+	if err != nil || !state.exists || info.ModTime().Equal(state.modTime) {
+		return state
+	}
+	// The file may have changed again since it was checked, so only keep the state if the
+	// contents are still the same
+	if current, err := readFileState(fsys, filename); err == nil && current.exists && current.hash == state.hash {
+		return current
+	}
+	return state
+}
+
+// readFileState - get the current state of a file
+func readFileState(fsys fs.FS, filename string) (*fileState, error) {
+	file, err := openFile(fsys, filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &fileState{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	contents, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	return newFileState(info, contents), nil
+}
+
+// waitUntilUnchanged - wait until the size and modification time of the file stay the same
+// for the debounce duration
+func waitUntilUnchanged(ctx context.Context, fsys fs.FS, filename string, debounce time.Duration) error {
+	var before, after fs.FileInfo
+
+	before, _ = statFile(fsys, filename)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(debounce):
+		}
+
+		after, _ = statFile(fsys, filename)
+		if sameFileInfo(before, after) {
+			return nil
+		}
+		before = after
+	}
+}
+
+func sameFileInfo(before, after fs.FileInfo) bool {
+	if before == nil || after == nil {
+		return before == nil && after == nil
+	}
+	return before.Size() == after.Size() && before.ModTime().Equal(after.ModTime())
+}
+
+// WatchedFile - a thread-safe holder of the latest YamlFile loaded by Watch, so that other
+// goroutines can get the current configuration while it is reloaded.  The zero value is ready
+// to use, with its OnChange method passed to Watch.
+type WatchedFile struct {
+	mutex    sync.RWMutex
+	yamlFile YamlFile
+	err      error
+}
+
+// OnChange - keep the YamlFile and the error passed by Watch (see Watch)
+func (w *WatchedFile) OnChange(yamlFile YamlFile, err error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.yamlFile = yamlFile
+	w.err = err
+}
+
+// Current - get the latest YamlFile loaded and the error that Watch reported along with it, if
+// any (see Watch).  The YamlFile is shared with other goroutines, so it should only be read and
+// not modified.  Before the file is first loaded, the YamlFile is nil.
+func (w *WatchedFile) Current() (yamlFile YamlFile, err error) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	return w.yamlFile, w.err
+}
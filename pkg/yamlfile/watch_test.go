@@ -0,0 +1,202 @@
+package yamlfile
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+
+	"github.com/theochva/go-misc/pkg/osext"
+)
+
+var _ = Describe("YamlFile watching", func() {
+	type reload struct {
+		yamlFile YamlFile
+		err      error
+	}
+	var (
+		file    *os.File
+		reloads chan reload
+		cancel  context.CancelFunc
+		done    chan error
+	)
+
+	BeforeEach(func() {
+		var (
+			err error
+			ctx context.Context
+		)
+
+		file, err = osext.CreateTempWithContents("", "test*.yaml", []byte(yamlText), 0644)
+		Expect(err).ToNot(HaveOccurred())
+
+		reloads = make(chan reload, 10)
+		done = make(chan error, 1)
+		ctx, cancel = context.WithCancel(context.Background())
+
+		go func() {
+			done <- Watch(ctx, file.Name(), func(yamlFile YamlFile, err error) {
+				reloads <- reload{yamlFile: yamlFile, err: err}
+			}, WatchInterval(10*time.Millisecond), WatchDebounce(20*time.Millisecond))
+		}()
+
+		// The file is loaded right away
+		var first reload
+		Eventually(reloads).Should(Receive(&first))
+		Expect(first.err).ToNot(HaveOccurred())
+		checkText(first.yamlFile, yamlText)
+	})
+	AfterEach(func() {
+		cancel()
+		Eventually(done).Should(Receive(Equal(context.Canceled)))
+		if file != nil {
+			os.Remove(file.Name())
+		}
+	})
+	It("reloads the file when it changes", func() {
+		Expect(os.WriteFile(file.Name(), []byte("x: 1"), 0644)).To(Succeed())
+
+		var next reload
+		Eventually(reloads).Should(Receive(&next))
+		Expect(next.err).ToNot(HaveOccurred())
+		checkText(next.yamlFile, "x: 1")
+		Consistently(reloads, 100*time.Millisecond).ShouldNot(Receive())
+	})
+	It("keeps the previous file when the new contents are not valid", func() {
+		Expect(os.WriteFile(file.Name(), []byte("x: [1"), 0644)).To(Succeed())
+
+		var next reload
+		Eventually(reloads).Should(Receive(&next))
+		Expect(next.err).To(HaveOccurred())
+		checkText(next.yamlFile, yamlText)
+		// The error is reported once
+		Consistently(reloads, 100*time.Millisecond).ShouldNot(Receive())
+
+		// Once fixed, it is reloaded
+		Expect(os.WriteFile(file.Name(), []byte("x: [1]"), 0644)).To(Succeed())
+		Eventually(reloads).Should(Receive(&next))
+		Expect(next.err).ToNot(HaveOccurred())
+		checkText(next.yamlFile, "x:\n  - 1")
+	})
+	It("reports an error when the file is deleted", func() {
+		Expect(os.Remove(file.Name())).To(Succeed())
+
+		var next reload
+		Eventually(reloads).Should(Receive(&next))
+		Expect(os.IsNotExist(errors.Cause(next.err))).To(BeTrue())
+		checkText(next.yamlFile, yamlText)
+	})
+	It("keeps the latest file for other goroutines", func() {
+		watched := &WatchedFile{}
+		ctx, cancelWatched := context.WithCancel(context.Background())
+		defer cancelWatched()
+
+		go Watch(ctx, file.Name(), watched.OnChange, WatchInterval(10*time.Millisecond), WatchDebounce(20*time.Millisecond))
+		Eventually(func() YamlFile {
+			yamlFile, _ := watched.Current()
+			return yamlFile
+		}).ShouldNot(BeNil())
+
+		Expect(os.WriteFile(file.Name(), []byte("x: 1"), 0644)).To(Succeed())
+		Eventually(func() interface{} {
+			yamlFile, err := watched.Current()
+			Expect(err).ToNot(HaveOccurred())
+			value, _ := yamlFile.Get("x")
+			return value
+		}).Should(Equal(1))
+	})
+})
+
+// watchedFS - a file system that fails to stat the files with statErr and counts the files opened
+type watchedFS struct {
+	*MemFS
+	mutex   sync.Mutex
+	statErr error
+	opened  int
+}
+
+func (w *watchedFS) Stat(name string) (fs.FileInfo, error) {
+	w.mutex.Lock()
+	statErr := w.statErr
+	w.mutex.Unlock()
+
+	if statErr != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: statErr}
+	}
+	return fs.Stat(w.MemFS, name)
+}
+
+func (w *watchedFS) Open(name string) (fs.File, error) {
+	w.mutex.Lock()
+	w.opened++
+	w.mutex.Unlock()
+
+	return w.MemFS.Open(name)
+}
+
+func (w *watchedFS) setStatErr(err error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.statErr = err
+}
+
+func (w *watchedFS) openedFiles() int {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.opened
+}
+
+var _ = Describe("YamlFile watching with a file system", func() {
+	var (
+		fsys    *watchedFS
+		reloads chan error
+		cancel  context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		var ctx context.Context
+
+		fsys = &watchedFS{MemFS: NewMemFS(map[string]string{"test.yaml": yamlText})}
+		reloads = make(chan error, 10)
+		ctx, cancel = context.WithCancel(context.Background())
+
+		go Watch(ctx, "test.yaml", func(yamlFile YamlFile, err error) {
+			reloads <- err
+		}, FS(fsys), WatchInterval(10*time.Millisecond), WatchDebounce(20*time.Millisecond))
+		Eventually(reloads).Should(Receive(BeNil()))
+	})
+	AfterEach(func() {
+		cancel()
+	})
+	It("reports an error checking the file once, until it is resolved", func() {
+		fsys.setStatErr(fs.ErrPermission)
+
+		var err error
+		Eventually(reloads).Should(Receive(&err))
+		Expect(errors.Is(err, fs.ErrPermission)).To(BeTrue())
+		Consistently(reloads, 100*time.Millisecond).ShouldNot(Receive())
+
+		fsys.setStatErr(nil)
+		Eventually(reloads).Should(Receive(BeNil()))
+		Consistently(reloads, 100*time.Millisecond).ShouldNot(Receive())
+	})
+	It("does not compare the contents again when only the modification time changed", func() {
+		time.Sleep(time.Millisecond)
+		Expect(fsys.WriteFile("test.yaml", []byte(yamlText), DefaultFileMode)).To(Succeed())
+
+		// The contents are compared once, and then only the modification time is checked
+		Eventually(func() int {
+			opened := fsys.openedFiles()
+			time.Sleep(50 * time.Millisecond)
+			return fsys.openedFiles() - opened
+		}).Should(BeZero())
+		Consistently(reloads, 50*time.Millisecond).ShouldNot(Receive())
+	})
+})
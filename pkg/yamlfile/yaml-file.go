@@ -1,12 +1,14 @@
 package yamlfile
 
 import (
+	"bytes"
 	"io"
-	"os"
+	"io/fs"
+	"path/filepath"
+	"time"
 
 	"github.com/pkg/errors"
 
-	"github.com/theochva/go-misc/pkg/osext"
 	"github.com/theochva/goyaml/pkg/yamldoc"
 )
 
@@ -18,30 +20,97 @@ type YamlFile interface {
 	Exists() bool
 	// Filename - returns the filename
 	Filename() string
+	// Encoding - returns the character encoding of the file when it was loaded
+	Encoding() yamldoc.Encoding
 	// Load - loads the file (if it exists)
 	Load() (loaded bool, err error)
 	// LoadReader - load from a reader
 	LoadReader(reader io.Reader) (loaded bool, err error)
-	// Save - saves the yaml file
+	// Save - saves the yaml file, unless it was modified by someone else since it was loaded
 	Save() (err error)
+	// SaveForce - saves the yaml file, even if it was modified by someone else since it was loaded
+	SaveForce() (err error)
+	// SaveWithBackup - saves the yaml file (see Save), after backing up its current contents
+	SaveWithBackup(backup Backup) (err error)
+	// SaveIfChanged - saves the yaml file, only if it was changed since it was loaded or saved
+	SaveIfChanged() (saved bool, err error)
+	// Reload - discards any changes and loads the file again
+	Reload() (loaded bool, err error)
+	// ReloadAndApply - reloads the file, applies the changes and saves it
+	ReloadAndApply(changes func(doc yamldoc.YamlDoc) error) (err error)
 }
 
+// maxApplyAttempts - how many times ReloadAndApply tries to apply the changes
+const maxApplyAttempts = 3
+
 type yamlFile struct {
 	yamldoc.YamlDoc
-	filename string
+	filename        string
+	fsys            fs.FS
+	codec           Codec
+	compression     Compression
+	compressionSet  bool
+	encoding        yamldoc.Encoding
+	keepEncoding    bool
+	replaceSymlinks bool
+	backup          Backup
+	state           *fileState
+	watchInterval   time.Duration
+	watchDebounce   time.Duration
+	defaultFragment string
+	loadOpts        []yamldoc.LoadOption
+}
+
+// Option - an option for creating/loading a YamlFile
+type Option func(y *yamlFile)
+
+// KeepEncoding - save the file with the character encoding it had when it was loaded, e.g.
+// UTF-16 or UTF-8 with a byte order mark.  By default, files are saved as UTF-8 without a BOM.
+func KeepEncoding() Option {
+	return func(y *yamlFile) {
+		y.keepEncoding = true
+	}
 }
 
-// New - create a new yaml YamlFile object. Returns nil if filename is empty
-func New(filename string) YamlFile {
+// WithLoadOptions - load the file with the yamldoc load options, e.g. yamldoc.WithLimits for
+// files from untrusted sources.  The MaxBytes limit applies to the contents of the file both
+// before and after they are decompressed, while the other limits only apply to YAML files.
+func WithLoadOptions(opts ...yamldoc.LoadOption) Option {
+	return func(y *yamlFile) {
+		y.loadOpts = append(y.loadOpts, opts...)
+	}
+}
+
+// ReplaceSymlinks - when the file is a symlink, Save replaces the symlink with a regular file.
+// By default, Save follows the symlink and writes the file it points to.
+func ReplaceSymlinks() Option {
+	return func(y *yamlFile) {
+		y.replaceSymlinks = true
+	}
+}
+
+// New - create a new yaml YamlFile object. Returns nil if filename is empty.
+//
+// The file is read and written with the codec registered for its extension (see CodecFor),
+// so for example "config.json" is a JSON file, while the yaml document is the same.  Files
+// compressed with gzip or zstd are decompressed when loaded and compressed again when saved.
+func New(filename string, opts ...Option) YamlFile {
 	if filename == "" {
 		return nil
 	}
 
 	result := &yamlFile{
-		filename: filename,
+		filename:    filename,
+		codec:       CodecFor(filename),
+		compression: compressionFor(filename),
+		encoding:    yamldoc.DefaultEncoding,
+	}
+
+	for _, opt := range opts {
+		opt(result)
 	}
 
-	result.YamlDoc, _ = yamldoc.New(nil)
+	result.YamlDoc, _ = yamldoc.New(nil, result.loadOpts...)
 
 	return result
 }
@@ -55,8 +124,8 @@ func New(filename string) YamlFile {
 //
 // If an error occurs while opening or parsing the file then YamlFile=nil and "err" will
 // contain the error information.
-func Load(filename string) (loaded bool, yamlFile YamlFile, err error) {
-	yamlFile = New(filename)
+func Load(filename string, opts ...Option) (loaded bool, yamlFile YamlFile, err error) {
+	yamlFile = New(filename, opts...)
 
 	loaded, err = yamlFile.Load()
 	return
@@ -64,7 +133,8 @@ func Load(filename string) (loaded bool, yamlFile YamlFile, err error) {
 
 // Exists - Check whether the file actually exists
 func (y *yamlFile) Exists() bool {
-	return osext.FileExists(y.filename)
+	info, err := statFile(y.fsys, y.filename)
+	return err == nil && !info.IsDir()
 }
 
 // Filename - returns the filename
@@ -72,6 +142,11 @@ func (y *yamlFile) Filename() string {
 	return y.filename
 }
 
+// Encoding - returns the character encoding of the file when it was loaded
+func (y *yamlFile) Encoding() yamldoc.Encoding {
+	return y.encoding
+}
+
 // Load - loads the file (if it exists)
 //
 // If the file does not exist, then it returns loaded=false.
@@ -81,43 +156,191 @@ func (y *yamlFile) Filename() string {
 //
 // If an error occurs while opening or parsing the file then YamlFile is unchanged and "err" will
 // contain the error information.
+//
+// The size, modification time and a hash of the contents are recorded, so that Save can detect
+// whether the file was modified by someone else in the meantime.
 func (y *yamlFile) Load() (loaded bool, err error) {
 	// If the file does not exist at this point,
 	if !y.Exists() {
+		y.state = &fileState{}
 		return
 	}
 
 	// Otherwise, we will attempt to load the contents of the file
 	var (
-		file *os.File
+		file     fs.File
+		info     fs.FileInfo
+		contents []byte
 	)
 	// Open file
-	if file, err = os.Open(y.filename); err != nil {
+	if file, err = openFile(y.fsys, y.filename); err != nil {
 		return
 	}
 
 	defer file.Close()
 
-	return y.LoadReader(file)
+	if info, err = file.Stat(); err != nil {
+		return
+	}
+	if contents, err = yamldoc.ReadAll(file, y.loadOpts...); err != nil {
+		return
+	}
+	if loaded, err = y.LoadReader(bytes.NewReader(contents)); err != nil {
+		return
+	}
+	y.state = newFileState(info, contents)
+
+	return
 }
 
-// LoadReader - load from a reader
+// LoadReader - load from a reader, which is parsed with the codec of the file.  If the contents
+// are compressed (detected by their magic bytes), then they are decompressed first and the
+// file is saved with the same compression.  A byte order mark (BOM) is skipped and UTF-16/32
+// contents are transcoded to UTF-8 (see KeepEncoding).  The loaded contents replace the wrapped
+// yaml document, so the observers registered with OnChange before loading are dropped.
 func (y *yamlFile) LoadReader(reader io.Reader) (loaded bool, err error) {
 	if reader != nil {
-		if y.YamlDoc, err = yamldoc.New(reader); err != nil {
+		var (
+			contents []byte
+			doc      yamldoc.YamlDoc
+			encoding yamldoc.Encoding
+		)
+		if contents, err = yamldoc.ReadAll(reader, y.loadOpts...); err != nil {
 			return false, err
 		}
+		compression := detectCompression(contents)
+		if contents, err = decompress(contents, compression, y.loadOpts...); err != nil {
+			return false, errors.Wrapf(err, "Failed to decompress %s contents", compression)
+		}
+		if contents, encoding, err = yamldoc.ToUTF8(contents); err != nil {
+			return false, err
+		}
+		// The relative paths of the custom tags (e.g. "!include") are relative to the file
+		loadOpts := append([]yamldoc.LoadOption{yamldoc.TagsDir(filepath.Dir(y.filename))}, y.loadOpts...)
+		if doc, err = decodeDoc(y.codec, contents, loadOpts); err != nil {
+			return false, err
+		}
+		y.YamlDoc = doc
+		y.MarkClean()
+		if !y.compressionSet {
+			y.compression = compression
+		}
+		y.encoding = encoding
 
 		return true, nil
 	}
 	return false, nil
 }
 
-// Save - saves the yaml file
+// Save - saves the yaml file, unless it was modified by someone else since it was loaded.
+//
+// If the file was loaded and has since been modified, created or deleted on disk, then
+// ErrFileModified is returned and the file is not saved.  In that case, use SaveForce to
+// overwrite the file anyway or ReloadAndApply to apply the changes to the latest contents.
+//
+// The contents are first written to a temp file in the same directory, which then replaces
+// the file.  So, if anything fails while saving, the file keeps its previous contents.  The
+// permissions and (where possible) the ownership of an existing file are kept, while new
+// files are created with DefaultFileMode.
+//
+// If the WithBackup option was specified, then the file is backed up before it is saved.
 func (y *yamlFile) Save() (err error) {
-	yamlBytes, err := y.Bytes()
+	return y.SaveWithBackup(y.backup)
+}
+
+// SaveWithBackup - saves the yaml file (see Save), after backing up its current contents, e.g.
+// with BackupSuffix or BackupHistory.  If the file does not exist, then there is nothing to
+// back up.  If the backup fails, then the file is not saved.
+func (y *yamlFile) SaveWithBackup(backup Backup) (err error) {
+	if y.state != nil {
+		var modified bool
+
+		if modified, err = y.state.modified(y.fsys, y.filename); err != nil {
+			return err
+		} else if modified {
+			return ErrFileModified
+		}
+	}
+	return y.save(backup)
+}
+
+// SaveForce - saves the yaml file, even if it was modified by someone else since it was loaded
+func (y *yamlFile) SaveForce() (err error) {
+	return y.save(y.backup)
+}
+
+func (y *yamlFile) save(backup Backup) (err error) {
+	var info fs.FileInfo
+
+	yamlBytes, err := encodeDoc(y.codec, y.YamlDoc)
 	if err != nil {
-		return errors.Wrap(err, "Failed to get yaml bytes")
+		return errors.Wrapf(err, "Failed to encode '%s'", y.filename)
+	}
+	if y.keepEncoding {
+		if yamlBytes, err = yamldoc.FromUTF8(yamlBytes, y.encoding); err != nil {
+			return errors.Wrapf(err, "Failed to encode '%s' as %s", y.filename, y.encoding)
+		}
+	}
+	if yamlBytes, err = compress(yamlBytes, y.compression); err != nil {
+		return errors.Wrapf(err, "Failed to compress '%s'", y.filename)
+	}
+	if backup != nil {
+		if err = backup(y.fsys, y.filename); err != nil {
+			return errors.Wrapf(err, "Failed to back up '%s'", y.filename)
+		}
+	}
+	if err = writeFile(y.fsys, y.filename, yamlBytes, y.replaceSymlinks, nil); err != nil {
+		return err
+	}
+	if info, err = statFile(y.fsys, y.filename); err != nil {
+		return err
+	}
+	y.state = newFileState(info, yamlBytes)
+	y.MarkClean()
+
+	return nil
+}
+
+// SaveIfChanged - saves the yaml file (see Save), only if it was changed since it was loaded
+// or saved.  This way, setting a value to the value it already has does not rewrite the file
+// and does not change its modification time.
+func (y *yamlFile) SaveIfChanged() (saved bool, err error) {
+	if !y.IsDirty() {
+		return false, nil
+	}
+	if err = y.Save(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Reload - discards any changes and loads the file again.  If the file no longer exists,
+// then the YamlFile is emptied and loaded=false.
+func (y *yamlFile) Reload() (loaded bool, err error) {
+	if !y.Exists() {
+		y.SetData(nil)
+		y.MarkClean()
+	}
+	return y.Load()
+}
+
+// ReloadAndApply - reloads the file, applies the changes and saves it.
+//
+// This is meant for when Save returns ErrFileModified: the changes are applied again to the
+// latest contents of the file.  If the file is modified once more before it is saved, then
+// this is retried a few times before ErrFileModified is returned.  If the changes fail, then
+// they are rolled back and the error is returned.
+func (y *yamlFile) ReloadAndApply(changes func(doc yamldoc.YamlDoc) error) (err error) {
+	for attempt := 0; attempt < maxApplyAttempts; attempt++ {
+		if _, err = y.Reload(); err != nil {
+			return err
+		}
+		if err = y.Transaction(changes); err != nil {
+			return err
+		}
+		if err = y.Save(); err != ErrFileModified {
+			return err
+		}
 	}
-	return os.WriteFile(y.filename, yamlBytes, 0644)
+	return err
 }
@@ -2,8 +2,10 @@ package yamlfile
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -118,5 +120,245 @@ var _ = Describe("YamlFile functions", func() {
 			// check the yaml file's text
 			checkText(yamlFile, yamlText)
 		})
+		It("keeps the permissions of the file when saving", func() {
+			Expect(os.Chmod(file.Name(), 0600)).To(Succeed())
+
+			_, yamlFile, err := Load(file.Name())
+			Expect(err).ToNot(HaveOccurred())
+			_, err = yamlFile.Set("a.b.c", "changed")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(yamlFile.Save()).To(Succeed())
+
+			info, err := os.Stat(file.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+			// No temp files are left behind
+			tmpFiles, err := filepath.Glob(filepath.Join(filepath.Dir(file.Name()), "."+filepath.Base(file.Name())+".tmp*"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tmpFiles).To(BeEmpty())
+		})
+	})
+	Context("The file is saved only if changed", func() {
+		var file *os.File
+		BeforeEach(func() {
+			var err error
+
+			file, err = osext.CreateTempWithContents("", "test*.yaml", []byte(yamlText), 0644)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		AfterEach(func() {
+			if file != nil {
+				os.Remove(file.Name())
+			}
+		})
+		It("does not save when nothing changed", func() {
+			earlier := time.Now().Add(-time.Hour).Truncate(time.Second)
+			Expect(os.Chtimes(file.Name(), earlier, earlier)).To(Succeed())
+
+			_, yamlFile, err := Load(file.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(yamlFile.IsDirty()).To(BeFalse())
+			_, err = yamlFile.Set("a.b.c", "value-c")
+			Expect(err).ToNot(HaveOccurred())
+
+			saved, err := yamlFile.SaveIfChanged()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(saved).To(BeFalse())
+
+			info, err := os.Stat(file.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.ModTime()).To(Equal(earlier))
+		})
+		It("saves when a value changed and is clean afterwards", func() {
+			_, yamlFile, err := Load(file.Name())
+			Expect(err).ToNot(HaveOccurred())
+			_, err = yamlFile.Set("a.b.c", "changed")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(yamlFile.ChangedPaths()).To(Equal([]string{"a.b.c"}))
+
+			saved, err := yamlFile.SaveIfChanged()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(saved).To(BeTrue())
+			Expect(yamlFile.IsDirty()).To(BeFalse())
+
+			contents, err := osext.ReadFileAsString(file.Name(), true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal(strings.Replace(yamlText, "value-c", "changed", 1)))
+		})
+	})
+	Context("The file is modified by someone else", func() {
+		var (
+			file     *os.File
+			yamlFile YamlFile
+		)
+		BeforeEach(func() {
+			var err error
+
+			file, err = osext.CreateTempWithContents("", "test*.yaml", []byte(yamlText), 0644)
+			Expect(err).ToNot(HaveOccurred())
+			_, yamlFile, err = Load(file.Name())
+			Expect(err).ToNot(HaveOccurred())
+			_, err = yamlFile.Set("a.b.c", "changed")
+			Expect(err).ToNot(HaveOccurred())
+		})
+		AfterEach(func() {
+			if file != nil {
+				os.Remove(file.Name())
+			}
+		})
+		It("saves when the file is unchanged or only touched", func() {
+			later := time.Now().Add(time.Hour)
+			Expect(os.Chtimes(file.Name(), later, later)).To(Succeed())
+
+			Expect(yamlFile.Save()).To(Succeed())
+			// and saves again after its own save
+			Expect(yamlFile.Save()).To(Succeed())
+		})
+		It("fails to save when the file was modified", func() {
+			modifiedText := strings.Replace(yamlText, "value-c", "value-x", 1)
+			Expect(os.WriteFile(file.Name(), []byte(modifiedText), 0644)).To(Succeed())
+
+			Expect(yamlFile.Save()).To(Equal(ErrFileModified))
+
+			contents, err := osext.ReadFileAsString(file.Name(), true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal(modifiedText))
+		})
+		It("fails to save when the file was deleted", func() {
+			Expect(os.Remove(file.Name())).To(Succeed())
+
+			Expect(yamlFile.Save()).To(Equal(ErrFileModified))
+		})
+		It("saves anyway when forced", func() {
+			Expect(os.WriteFile(file.Name(), []byte("x: 1"), 0644)).To(Succeed())
+
+			Expect(yamlFile.SaveForce()).To(Succeed())
+
+			contents, err := osext.ReadFileAsString(file.Name(), true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal(strings.Replace(yamlText, "value-c", "changed", 1)))
+		})
+		It("reloads the file and applies the changes again", func() {
+			Expect(os.WriteFile(file.Name(), []byte("x: 1\n"+yamlText), 0644)).To(Succeed())
+
+			Expect(yamlFile.ReloadAndApply(func(doc yamldoc.YamlDoc) error {
+				_, err := doc.Set("a.b.c", "changed")
+				return err
+			})).To(Succeed())
+
+			contents, err := osext.ReadFileAsString(file.Name(), true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal(strings.Replace(yamlText, "value-c", "changed", 1) + "\nx: 1"))
+		})
+	})
+	Context("The file is locked", func() {
+		var file *os.File
+		BeforeEach(func() {
+			var err error
+
+			file, err = osext.CreateTempWithContents("", "test*.yaml", []byte(yamlText), 0644)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		AfterEach(func() {
+			if file != nil {
+				os.Remove(file.Name())
+				os.Remove(filepath.Join(filepath.Dir(file.Name()), "."+filepath.Base(file.Name())+".lock"))
+			}
+		})
+		It("holds the lock until unlocked", func() {
+			loaded, yamlFile, err := LoadLocked(file.Name(), time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded).To(BeTrue())
+			checkText(yamlFile, yamlText)
+
+			// Someone else cannot get the lock
+			_, err = Lock(file.Name(), 0)
+			Expect(err).To(Equal(ErrLockTimeout))
+			_, err = Lock(file.Name(), 100*time.Millisecond)
+			Expect(err).To(Equal(ErrLockTimeout))
+
+			Expect(yamlFile.Save()).To(Succeed())
+			Expect(yamlFile.Unlock()).To(Succeed())
+			Expect(yamlFile.Unlock()).To(Succeed())
+
+			// Now someone else can get the lock
+			unlock, err := Lock(file.Name(), 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(unlock()).To(Succeed())
+		})
+		It("locks the same file however its path is written", func() {
+			unlock, err := Lock(file.Name(), 0)
+			Expect(err).ToNot(HaveOccurred())
+			defer unlock()
+
+			dir, base := filepath.Split(file.Name())
+			_, err = Lock(filepath.Join(dir, ".", base), 0)
+			Expect(err).To(Equal(ErrLockTimeout))
+			_, err = Lock(dir+"//"+base, 0)
+			Expect(err).To(Equal(ErrLockTimeout))
+		})
+		It("waits for the lock to be released", func() {
+			unlock, err := Lock(file.Name(), 0)
+			Expect(err).ToNot(HaveOccurred())
+
+			go func() {
+				time.Sleep(100 * time.Millisecond)
+				unlock()
+			}()
+
+			_, yamlFile, err := LoadLocked(file.Name(), 5*time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(yamlFile.Unlock()).To(Succeed())
+		})
+	})
+	Context("The file is a symlink", func() {
+		var (
+			file    *os.File
+			symlink string
+		)
+		BeforeEach(func() {
+			var err error
+
+			file, err = osext.CreateTempWithContents("", "test*.yaml", []byte(yamlText), 0644)
+			Expect(err).ToNot(HaveOccurred())
+			symlink = file.Name() + ".link.yaml"
+			Expect(os.Symlink(file.Name(), symlink)).To(Succeed())
+		})
+		AfterEach(func() {
+			os.Remove(symlink)
+			if file != nil {
+				os.Remove(file.Name())
+			}
+		})
+		It("follows the symlink by default", func() {
+			_, yamlFile, err := Load(symlink)
+			Expect(err).ToNot(HaveOccurred())
+			yamlFile.Delete("a.d")
+			Expect(yamlFile.Save()).To(Succeed())
+
+			info, err := os.Lstat(symlink)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode() & os.ModeSymlink).ToNot(BeZero())
+
+			contents, err := osext.ReadFileAsString(file.Name(), true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal("a:\n  b:\n    c: value-c"))
+		})
+		It("replaces the symlink when requested", func() {
+			_, yamlFile, err := Load(symlink, ReplaceSymlinks())
+			Expect(err).ToNot(HaveOccurred())
+			yamlFile.Delete("a.d")
+			Expect(yamlFile.Save()).To(Succeed())
+
+			info, err := os.Lstat(symlink)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().IsRegular()).To(BeTrue())
+
+			// The file the symlink pointed to is untouched
+			contents, err := osext.ReadFileAsString(file.Name(), true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal(yamlText))
+		})
 	})
 })